@@ -6,6 +6,7 @@ import (
 	client "github.com/featurehub-io/featurehub-go-sdk"
 	"github.com/featurehub-io/featurehub-go-sdk/examples/http-service/internal/handler"
 	"github.com/featurehub-io/featurehub-go-sdk/pkg/analytics"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/handlers"
 	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
 )
@@ -42,6 +43,10 @@ func main() {
 	router.HandleFunc("/mapped", handler.Mapped).Methods(http.MethodGet)
 	router.HandleFunc("/random", handler.Random).Methods(http.MethodGet)
 	router.HandleFunc("/static", handler.Static).Methods(http.MethodGet)
+
+	// Health-checks (for Kubernetes liveness and readiness probes):
+	router.Handle("/healthz", handlers.NewLivenessHandler(fhClient)).Methods(http.MethodGet)
+	router.Handle("/readyz", handlers.NewReadinessHandler(fhClient)).Methods(http.MethodGet)
 	http.Handle("/", router)
 
 	// Serve:
@@ -0,0 +1,26 @@
+package analytics
+
+import (
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/interfaces"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
+)
+
+// contextAdapter lets a plain AnalyticsCollector be used as a ContextAnalyticsCollector:
+type contextAdapter struct {
+	interfaces.AnalyticsCollector
+}
+
+// AdaptAnalyticsCollector returns a ContextAnalyticsCollector for any AnalyticsCollector:
+// - collectors which are already ContextAnalyticsCollectors are returned as they are
+// - others are given the features as the event's context experienced them (the evaluated values, rather than the defaults), but never see the context itself
+func AdaptAnalyticsCollector(collector interfaces.AnalyticsCollector) interfaces.ContextAnalyticsCollector {
+	if contextCollector, ok := collector.(interfaces.ContextAnalyticsCollector); ok {
+		return contextCollector
+	}
+	return &contextAdapter{AnalyticsCollector: collector}
+}
+
+// LogContextEvent hands the event to the underlying collector's LogEvent method:
+func (a *contextAdapter) LogContextEvent(event *models.AnalyticsEvent) error {
+	return a.LogEvent(event.Action, event.Other, event.ExperiencedFeatures())
+}
@@ -0,0 +1,34 @@
+package analytics
+
+import (
+	"testing"
+
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/mocks"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdaptAnalyticsCollector(t *testing.T) {
+
+	// Context collectors don't need adapting:
+	fakeContextCollector := new(mocks.FakeContextAnalyticsCollector)
+	assert.Same(t, fakeContextCollector, AdaptAnalyticsCollector(fakeContextCollector))
+
+	// Other collectors get the features as the context experienced them:
+	fakeCollector := new(mocks.FakeAnalyticsCollector)
+	event := newTestEvent("one")
+	event.Features["feature2"] = &models.FeatureState{Key: "feature2", Value: "default"}
+	event.Context = &models.Context{Userkey: "user1"}
+	event.Evaluated = models.EvaluatedFeatures{"feature1": {Key: "feature1", StrategyID: "s1", Value: false, Version: 2}}
+	assert.NoError(t, AdaptAnalyticsCollector(fakeCollector).LogContextEvent(event))
+	action, other, features := fakeCollector.LogEventArgsForCall(0)
+	assert.Equal(t, "one", action)
+	assert.Equal(t, "true", other["testing"])
+	assert.Equal(t, &models.FeatureState{Key: "feature1", Value: false, Version: 2}, features["feature1"])
+	assert.Equal(t, "default", features["feature2"].Value)
+
+	// Without any evaluated features they get the features as they are:
+	assert.NoError(t, AdaptAnalyticsCollector(fakeCollector).LogContextEvent(newTestEvent("two")))
+	_, _, features = fakeCollector.LogEventArgsForCall(1)
+	assert.Equal(t, true, features["feature1"].Value)
+}
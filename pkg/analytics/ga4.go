@@ -0,0 +1,291 @@
+package analytics
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/errors"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
+)
+
+const (
+	defaultGA4Endpoint      = "https://www.google-analytics.com"
+	ga4CollectPath          = "/mp/collect"
+	ga4DebugCollectPath     = "/debug/mp/collect"
+	ga4FeatureParamPrefix   = "fh_"
+	ga4MaxEventsPerRequest  = 25
+	ga4MaxNameLength        = 40
+	ga4MaxParamsPerEvent    = 25
+	ga4MaxParamValueLength  = 100
+	ga4OtherClientIDKey     = "cid"
+	ga4ValidationMessageSep = "; "
+)
+
+// GA4AnalyticsCollector implements the AnalyticsCollector interface by sending events to Google Analytics 4 (with the Measurement Protocol):
+// - each analytics event becomes one GA4 event (named after the action), with a parameter for each feature ("fh_{key}") and each "other" attribute
+// - events are sent in batches (up to GA4's limit of 25 events per request)
+// - names and values are trimmed to fit GA4's limits (and parameters beyond the first 25 are left out)
+type GA4AnalyticsCollector struct {
+	apiSecret     string
+	clientID      string
+	endpoint      string
+	httpClient    *http.Client
+	measurementID string
+	validate      bool
+}
+
+// ga4Request is the body of a Measurement Protocol request:
+type ga4Request struct {
+	ClientID string      `json:"client_id"`
+	Events   []*ga4Event `json:"events"`
+}
+
+// ga4Event is one event within a Measurement Protocol request:
+type ga4Event struct {
+	Name   string                 `json:"name"`
+	Params map[string]interface{} `json:"params,omitempty"`
+}
+
+// ga4ValidationResponse is what the Measurement Protocol validation server responds with:
+type ga4ValidationResponse struct {
+	ValidationMessages []struct {
+		Description    string `json:"description"`
+		FieldPath      string `json:"fieldPath"`
+		ValidationCode string `json:"validationCode"`
+	} `json:"validationMessages"`
+}
+
+// NewGA4AnalyticsCollector returns a GA4AnalyticsCollector for the given measurement ID (eg "G-XXXXXXXXXX") and API secret:
+// - clientID identifies the client to GA4 (it can be overridden per event with a "cid" attribute)
+func NewGA4AnalyticsCollector(measurementID, apiSecret, clientID string) (*GA4AnalyticsCollector, error) {
+	var problems []errors.ConfigProblem
+	if measurementID == "" {
+		problems = append(problems, errors.ConfigProblem{Field: "measurementID", Message: "measurementID is required"})
+	}
+	if apiSecret == "" {
+		problems = append(problems, errors.ConfigProblem{Field: "apiSecret", Message: "apiSecret is required"})
+	}
+	if clientID == "" {
+		problems = append(problems, errors.ConfigProblem{Field: "clientID", Message: "clientID is required"})
+	}
+	if len(problems) > 0 {
+		return nil, errors.NewErrBadConfigProblems(problems...)
+	}
+
+	return &GA4AnalyticsCollector{
+		apiSecret:     apiSecret,
+		clientID:      clientID,
+		endpoint:      defaultGA4Endpoint,
+		httpClient:    http.DefaultClient,
+		measurementID: measurementID,
+	}, nil
+}
+
+// WithEndpoint sends events somewhere other than "https://www.google-analytics.com" (eg "https://region1.google-analytics.com" for EU data collection):
+func (ac *GA4AnalyticsCollector) WithEndpoint(endpoint string) *GA4AnalyticsCollector {
+	ac.endpoint = strings.TrimSuffix(endpoint, "/")
+	return ac
+}
+
+// WithHTTPClient configures the HTTP client used to send events to Google (eg one with a proxy or custom CAs):
+func (ac *GA4AnalyticsCollector) WithHTTPClient(httpClient *http.Client) *GA4AnalyticsCollector {
+	ac.httpClient = httpClient
+	return ac
+}
+
+// WithValidation sends events to the Measurement Protocol validation server instead (they aren't recorded, but any problems are returned as errors):
+func (ac *GA4AnalyticsCollector) WithValidation(validate bool) *GA4AnalyticsCollector {
+	ac.validate = validate
+	return ac
+}
+
+// LogEvent sends a GA4 event for the given action and metadata:
+func (ac *GA4AnalyticsCollector) LogEvent(action string, other map[string]string, featureStateAtCurrentTime map[string]*models.FeatureState) error {
+	_, err := ac.LogEvents([]*models.AnalyticsEvent{
+		{
+			Action:    action,
+			Features:  featureStateAtCurrentTime,
+			Other:     other,
+			Timestamp: time.Now(),
+		},
+	})
+	return err
+}
+
+// LogEvents sends a GA4 event for each of the given analytics events (batched by client ID):
+// - if a request fails, it stops there and returns the events which weren't delivered (that request's and the rest)
+func (ac *GA4AnalyticsCollector) LogEvents(events []*models.AnalyticsEvent) ([]*models.AnalyticsEvent, error) {
+
+	// Each request can only be for one client ID (so group the events by client ID, keeping them in order):
+	var clientIDs []string
+	eventsByClientID := make(map[string][]*models.AnalyticsEvent)
+	for _, event := range events {
+		clientID := ac.clientID
+		if clientIDOverride, ok := event.Other[ga4OtherClientIDKey]; ok && clientIDOverride != "" {
+			clientID = clientIDOverride
+		}
+		if _, ok := eventsByClientID[clientID]; !ok {
+			clientIDs = append(clientIDs, clientID)
+		}
+		eventsByClientID[clientID] = append(eventsByClientID[clientID], event)
+	}
+
+	// Send them (no more than GA4 allows per request):
+	for i, clientID := range clientIDs {
+		clientEvents := eventsByClientID[clientID]
+		for start := 0; start < len(clientEvents); start += ga4MaxEventsPerRequest {
+			end := min(start+ga4MaxEventsPerRequest, len(clientEvents))
+			ga4Events := make([]*ga4Event, 0, end-start)
+			for _, event := range clientEvents[start:end] {
+				ga4Events = append(ga4Events, newGA4Event(event))
+			}
+			if err := ac.send(&ga4Request{ClientID: clientID, Events: ga4Events}); err != nil {
+
+				// Everything from this request onwards wasn't delivered:
+				failed := append([]*models.AnalyticsEvent(nil), clientEvents[start:]...)
+				for _, remainingClientID := range clientIDs[i+1:] {
+					failed = append(failed, eventsByClientID[remainingClientID]...)
+				}
+				return failed, err
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+// send makes one Measurement Protocol request:
+func (ac *GA4AnalyticsCollector) send(request *ga4Request) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	// Use the validation server if we've been asked to:
+	collectPath := ga4CollectPath
+	if ac.validate {
+		collectPath = ga4DebugCollectPath
+	}
+	query := url.Values{"api_secret": {ac.apiSecret}, "measurement_id": {ac.measurementID}}
+	collectURL := fmt.Sprintf("%s%s?%s", ac.endpoint, collectPath, query.Encode())
+
+	// Send the request:
+	response, err := ac.httpClient.Post(collectURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	responseBody, err := io.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		return errors.NewErrFromAPI(fmt.Sprintf("GA4 responded with %s", response.Status))
+	}
+
+	// The validation server tells us about any problems with the events:
+	if ac.validate {
+		validationResponse := new(ga4ValidationResponse)
+		if err := json.Unmarshal(responseBody, validationResponse); err != nil {
+			return err
+		}
+		if len(validationResponse.ValidationMessages) > 0 {
+			messages := make([]string, len(validationResponse.ValidationMessages))
+			for i, validationMessage := range validationResponse.ValidationMessages {
+				messages[i] = fmt.Sprintf("%s (%s: %s)", validationMessage.Description, validationMessage.ValidationCode, validationMessage.FieldPath)
+			}
+			return errors.NewErrFromAPI(fmt.Sprintf("GA4 validation failed: %s", strings.Join(messages, ga4ValidationMessageSep)))
+		}
+	}
+
+	return nil
+}
+
+// newGA4Event turns an analytics event into a GA4 event (features first, then other attributes, both sorted, up to GA4's limit):
+func newGA4Event(event *models.AnalyticsEvent) *ga4Event {
+	ga4Event := &ga4Event{
+		Name:   ga4Name(event.Action),
+		Params: make(map[string]interface{}),
+	}
+
+	features := event.ExperiencedFeatures()
+	featureKeys := make([]string, 0, len(features))
+	for key := range features {
+		featureKeys = append(featureKeys, key)
+	}
+	sort.Strings(featureKeys)
+	for _, key := range featureKeys {
+		if len(ga4Event.Params) >= ga4MaxParamsPerEvent {
+			return ga4Event
+		}
+		ga4Event.Params[ga4Name(ga4FeatureParamPrefix+features[key].Key)] = ga4Value(features[key].Value)
+	}
+
+	otherKeys := make([]string, 0, len(event.Other))
+	for key := range event.Other {
+		if key != ga4OtherClientIDKey {
+			otherKeys = append(otherKeys, key)
+		}
+	}
+	sort.Strings(otherKeys)
+	for _, key := range otherKeys {
+		if len(ga4Event.Params) >= ga4MaxParamsPerEvent {
+			return ga4Event
+		}
+		ga4Event.Params[ga4Name(key)] = ga4Value(event.Other[key])
+	}
+
+	return ga4Event
+}
+
+// ga4Name makes a valid GA4 event or parameter name (letters, digits and underscores, starting with a letter, no more than 40 characters):
+func ga4Name(name string) string {
+	sanitised := []rune(strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' {
+			return r
+		}
+		return '_'
+	}, name))
+
+	if len(sanitised) == 0 || !((sanitised[0] >= 'a' && sanitised[0] <= 'z') || (sanitised[0] >= 'A' && sanitised[0] <= 'Z')) {
+		sanitised = append([]rune(ga4FeatureParamPrefix), sanitised...)
+	}
+	if len(sanitised) > ga4MaxNameLength {
+		sanitised = sanitised[:ga4MaxNameLength]
+	}
+	return string(sanitised)
+}
+
+// ga4Value makes a valid GA4 parameter value (numbers stay as numbers, everything else becomes a string of no more than 100 characters):
+func ga4Value(value interface{}) interface{} {
+	var stringValue string
+	switch typedValue := value.(type) {
+	case float64:
+		return typedValue
+	case int64:
+		return typedValue
+	case int:
+		return typedValue
+	case bool:
+		stringValue = strconv.FormatBool(typedValue)
+	case string:
+		stringValue = typedValue
+	case nil:
+		stringValue = ""
+	default:
+		stringValue = fmt.Sprintf("%v", typedValue)
+	}
+
+	if runes := []rune(stringValue); len(runes) > ga4MaxParamValueLength {
+		return string(runes[:ga4MaxParamValueLength])
+	}
+	return stringValue
+}
@@ -0,0 +1,142 @@
+package analytics
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/errors"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGA4AnalyticsCollector(t *testing.T) {
+
+	// Required config:
+	_, err := NewGA4AnalyticsCollector("", "", "client")
+	assert.IsType(t, &errors.ErrBadConfig{}, err)
+	assert.Contains(t, err.Error(), "measurementID is required")
+	assert.Contains(t, err.Error(), "apiSecret is required")
+
+	// A local stand-in for GA4 which records what it's sent:
+	var requestsMutex sync.Mutex
+	var requests []*ga4Request
+	var paths []string
+	var validationMessages string
+	status := http.StatusNoContent
+	failAfter := -1 // Fail every request after this many (unless it's negative)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "G-TEST", r.URL.Query().Get("measurement_id"))
+		assert.Equal(t, "secret", r.URL.Query().Get("api_secret"))
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		request := new(ga4Request)
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(request))
+
+		requestsMutex.Lock()
+		defer requestsMutex.Unlock()
+		requests = append(requests, request)
+		paths = append(paths, r.URL.Path)
+		if failAfter >= 0 && len(requests) > failAfter {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.URL.Path == ga4DebugCollectPath {
+			fmt.Fprintf(w, `{"validationMessages":[%s]}`, validationMessages)
+			return
+		}
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	analyticsCollector, err := NewGA4AnalyticsCollector("G-TEST", "secret", "client1")
+	assert.NoError(t, err)
+	analyticsCollector.WithEndpoint(server.URL + "/").WithHTTPClient(server.Client())
+
+	// One event, with a param for each feature and attribute:
+	testFeatures := map[string]*models.FeatureState{
+		"one":   {Key: "FEATURE_TANYA", Value: "orange"},
+		"two":   {Key: "SUBMIT_COLOR_BUTTON", Value: true},
+		"three": {Key: "number-of-things", Value: float64(3)},
+	}
+	assert.NoError(t, analyticsCollector.LogEvent("todo-add", map[string]string{"testing": "true", "page path": strings.Repeat("x", 150)}, testFeatures))
+	assert.Len(t, requests, 1)
+	assert.Equal(t, ga4CollectPath, paths[0])
+	assert.Equal(t, "client1", requests[0].ClientID)
+	assert.Len(t, requests[0].Events, 1)
+	assert.Equal(t, "todo_add", requests[0].Events[0].Name)
+	assert.Equal(t, map[string]interface{}{
+		"fh_FEATURE_TANYA":       "orange",
+		"fh_SUBMIT_COLOR_BUTTON": "true",
+		"fh_number_of_things":    float64(3),
+		"page_path":              strings.Repeat("x", 100),
+		"testing":                "true",
+	}, requests[0].Events[0].Params)
+
+	// Batches are split up by client ID, and to fit GA4's limit on events per request:
+	requests = nil
+	var events []*models.AnalyticsEvent
+	for i := 0; i < 30; i++ {
+		events = append(events, newTestEvent(fmt.Sprintf("event%d", i)))
+	}
+	overrideEvent := newTestEvent("override")
+	overrideEvent.Other["cid"] = "client2"
+	events = append(events, overrideEvent)
+	failed, err := analyticsCollector.LogEvents(events)
+	assert.NoError(t, err)
+	assert.Empty(t, failed)
+	assert.Len(t, requests, 3)
+	assert.Len(t, requests[0].Events, 25)
+	assert.Len(t, requests[1].Events, 5)
+	assert.Equal(t, "event29", requests[1].Events[4].Name)
+	assert.Equal(t, "client2", requests[2].ClientID)
+	assert.Equal(t, "override", requests[2].Events[0].Name)
+	assert.NotContains(t, requests[2].Events[0].Params, "cid")
+
+	// Events only get so many params:
+	manyFeatures := make(map[string]*models.FeatureState)
+	for i := 0; i < 30; i++ {
+		key := fmt.Sprintf("feature%02d", i)
+		manyFeatures[key] = &models.FeatureState{Key: key, Value: true}
+	}
+	requests = nil
+	assert.NoError(t, analyticsCollector.LogEvent("many", map[string]string{"testing": "true"}, manyFeatures))
+	assert.Len(t, requests[0].Events[0].Params, ga4MaxParamsPerEvent)
+	assert.Contains(t, requests[0].Events[0].Params, "fh_feature24")
+	assert.NotContains(t, requests[0].Events[0].Params, "fh_feature25")
+
+	// Names are made valid for GA4:
+	assert.Equal(t, "fh_1st_event", ga4Name("1st event"))
+	assert.Equal(t, "fh_", ga4Name(""))
+	assert.Len(t, ga4Name(strings.Repeat("a", 50)), ga4MaxNameLength)
+
+	// Validation mode uses the debug endpoint, and reports any problems:
+	analyticsCollector.WithValidation(true)
+	assert.NoError(t, analyticsCollector.LogEvent("todo-add", nil, testFeatures))
+	assert.Equal(t, ga4DebugCollectPath, paths[len(paths)-1])
+	validationMessages = `{"fieldPath":"events","description":"Event name is reserved.","validationCode":"NAME_RESERVED"}`
+	err = analyticsCollector.LogEvent("session_start", nil, testFeatures)
+	assert.IsType(t, &errors.ErrFromAPI{}, err)
+	assert.Contains(t, err.Error(), "GA4 validation failed: Event name is reserved. (NAME_RESERVED: events)")
+
+	// Errors from GA4 are returned:
+	analyticsCollector.WithValidation(false)
+	status = http.StatusForbidden
+	err = analyticsCollector.LogEvent("todo-add", nil, testFeatures)
+	assert.IsType(t, &errors.ErrFromAPI{}, err)
+	assert.Contains(t, err.Error(), "GA4 responded with 403 Forbidden")
+
+	// Only the events which weren't delivered are returned (so retrying them doesn't duplicate the rest):
+	requests = nil
+	status = http.StatusNoContent
+	failAfter = 1
+	failed, err = analyticsCollector.LogEvents(events)
+	assert.IsType(t, &errors.ErrFromAPI{}, err)
+	assert.Len(t, requests, 2)
+	assert.Len(t, failed, 6)
+	assert.Equal(t, "event25", failed[0].Action)
+	assert.Equal(t, "override", failed[5].Action)
+}
@@ -2,6 +2,7 @@ package analytics
 
 import (
 	"fmt"
+	"net/http"
 
 	ga "github.com/berdowsky/go-ogle-analytics"
 	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
@@ -12,6 +13,8 @@ const (
 )
 
 // GoogleAnalyticsCollector implements the AnalyticsCollector interface:
+//
+// Deprecated: Universal Analytics has been shut down, use GA4AnalyticsCollector instead.
 type GoogleAnalyticsCollector struct {
 	client       *ga.Client
 	clientID     string
@@ -20,6 +23,8 @@ type GoogleAnalyticsCollector struct {
 }
 
 // NewGoogleAnalyticsCollector returns a GoogleAnalyticsCollector configured with the provided metadata:
+//
+// Deprecated: Universal Analytics has been shut down, use NewGA4AnalyticsCollector instead.
 func NewGoogleAnalyticsCollector(clientID, trackingID, userAgentKey string) (*GoogleAnalyticsCollector, error) {
 	client, err := ga.NewClient(trackingID)
 	if err != nil {
@@ -34,6 +39,12 @@ func NewGoogleAnalyticsCollector(clientID, trackingID, userAgentKey string) (*Go
 	}, nil
 }
 
+// WithHTTPClient configures the HTTP client used to send events to Google (eg one with a proxy or custom CAs):
+func (ac *GoogleAnalyticsCollector) WithHTTPClient(httpClient *http.Client) *GoogleAnalyticsCollector {
+	ac.client.HttpClient = httpClient
+	return ac
+}
+
 // LogEvent generates analytics events for the given action and metadata:
 func (ac *GoogleAnalyticsCollector) LogEvent(action string, other map[string]string, featureStateAtCurrentTime map[string]*models.FeatureState) error {
 
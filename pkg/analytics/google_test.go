@@ -1,6 +1,9 @@
 package analytics
 
 import (
+	"io"
+	"net/http"
+	"strings"
 	"testing"
 
 	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
@@ -39,3 +42,29 @@ func TestGoogleAnalyticsCollector(t *testing.T) {
 	// Log an event:
 	assert.NoError(t, analyticsCollector.LogEvent("todo-add", testAttributes, testFeatures))
 }
+
+// roundTripperFunc lets a func act as an http.RoundTripper:
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestGoogleAnalyticsCollectorHTTPClient(t *testing.T) {
+
+	// Make a collector with an HTTP client which records requests instead of sending them:
+	var requests []*http.Request
+	httpClient := &http.Client{
+		Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			requests = append(requests, req)
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("")), Request: req}, nil
+		}),
+	}
+	analyticsCollector, err := NewGoogleAnalyticsCollector("test-client-id", "UA-12345678-9", "fh-analytics-test")
+	assert.NoError(t, err)
+	analyticsCollector.WithHTTPClient(httpClient)
+
+	// Log an event, which should go through our client:
+	assert.NoError(t, analyticsCollector.LogEvent("todo-add", nil, map[string]*models.FeatureState{"one": {Key: "FEATURE_TANYA", Value: "orange"}}))
+	assert.Len(t, requests, 1)
+	assert.Equal(t, "www.google-analytics.com", requests[0].URL.Host)
+	assert.Equal(t, "todo-add", requests[0].URL.Query().Get("ea"))
+}
@@ -0,0 +1,101 @@
+package analytics
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
+	"github.com/spaolacci/murmur3"
+)
+
+const (
+	defaultDedupeWindow     = time.Minute
+	defaultMaxDedupeEntries = 10000
+)
+
+// ImpressionConfig controls which impressions are logged (zero values get sensible defaults):
+type ImpressionConfig struct {
+	DedupeWindow     time.Duration // Identical impressions (same userkey, session, feature, version and strategy) are only logged once within this window (default 1m, negative to log every one)
+	MaxDedupeEntries int           // The most impressions to remember for de-duplication (default 10000)
+	SampleRate       float64       // The fraction (0 to 1) of contexts to log impressions for (default 1, ie all of them)
+}
+
+// ImpressionFilter decides which impressions are worth logging, by sampling and de-duplicating them:
+// - sampling is consistent for each userkey / session (so a sampled user has all of their impressions logged)
+// - contexts without a userkey or session are sampled at random
+type ImpressionFilter struct {
+	config ImpressionConfig
+	mutex  sync.Mutex
+	seen   map[string]time.Time
+}
+
+// NewImpressionFilter returns an ImpressionFilter with the given config:
+func NewImpressionFilter(config ImpressionConfig) *ImpressionFilter {
+	if config.DedupeWindow == 0 {
+		config.DedupeWindow = defaultDedupeWindow
+	}
+	if config.MaxDedupeEntries <= 0 {
+		config.MaxDedupeEntries = defaultMaxDedupeEntries
+	}
+	if config.SampleRate <= 0 || config.SampleRate > 1 {
+		config.SampleRate = 1
+	}
+
+	return &ImpressionFilter{
+		config: config,
+		seen:   make(map[string]time.Time),
+	}
+}
+
+// Allow tells us whether an impression should be logged:
+func (f *ImpressionFilter) Allow(impression *models.Impression) bool {
+	if !f.sampled(impression) {
+		return false
+	}
+
+	// De-duplication can be turned off:
+	if f.config.DedupeWindow < 0 {
+		return true
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	// Skip impressions we've seen recently:
+	dedupeKey := fmt.Sprintf("%s\x00%s\x00%s\x00%d\x00%s", impression.Userkey, impression.Session, impression.Key, impression.Version, impression.StrategyID)
+	if seenAt, ok := f.seen[dedupeKey]; ok && impression.Timestamp.Sub(seenAt) < f.config.DedupeWindow {
+		return false
+	}
+
+	// Make room if we need to (forgetting everything if they're all still in the window):
+	if len(f.seen) >= f.config.MaxDedupeEntries {
+		for key, seenAt := range f.seen {
+			if impression.Timestamp.Sub(seenAt) >= f.config.DedupeWindow {
+				delete(f.seen, key)
+			}
+		}
+		if len(f.seen) >= f.config.MaxDedupeEntries {
+			f.seen = make(map[string]time.Time)
+		}
+	}
+
+	f.seen[dedupeKey] = impression.Timestamp
+	return true
+}
+
+// sampled tells us whether the impression's context falls within the sample rate:
+func (f *ImpressionFilter) sampled(impression *models.Impression) bool {
+	if f.config.SampleRate >= 1 {
+		return true
+	}
+
+	if impression.Userkey == "" && impression.Session == "" {
+		return rand.Float64() < f.config.SampleRate
+	}
+
+	hash := murmur3.Sum32([]byte(impression.Userkey + "\x00" + impression.Session))
+	return float64(hash)/math.MaxUint32 < f.config.SampleRate
+}
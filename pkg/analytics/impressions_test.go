@@ -0,0 +1,83 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/mocks"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImpressionFilter(t *testing.T) {
+	now := time.Now()
+	impression := &models.Impression{Key: "feature1", Timestamp: now, Userkey: "user1", Value: true, Version: 1}
+
+	// Identical impressions are only allowed once per window:
+	impressionFilter := NewImpressionFilter(ImpressionConfig{DedupeWindow: time.Minute})
+	assert.True(t, impressionFilter.Allow(impression))
+	assert.False(t, impressionFilter.Allow(&models.Impression{Key: "feature1", Timestamp: now.Add(time.Second), Userkey: "user1", Value: true, Version: 1}))
+	assert.True(t, impressionFilter.Allow(&models.Impression{Key: "feature1", Timestamp: now.Add(time.Minute), Userkey: "user1", Value: true, Version: 1}))
+
+	// Different users, sessions, features, versions and strategies are all different impressions:
+	assert.True(t, impressionFilter.Allow(&models.Impression{Key: "feature1", Timestamp: now, Userkey: "user2", Value: true, Version: 1}))
+	assert.True(t, impressionFilter.Allow(&models.Impression{Key: "feature1", Session: "session1", Timestamp: now, Userkey: "user1", Value: true, Version: 1}))
+	assert.True(t, impressionFilter.Allow(&models.Impression{Key: "feature2", Timestamp: now, Userkey: "user1", Value: true, Version: 1}))
+	assert.True(t, impressionFilter.Allow(&models.Impression{Key: "feature1", Timestamp: now, Userkey: "user1", Value: true, Version: 2}))
+	assert.True(t, impressionFilter.Allow(&models.Impression{Key: "feature1", StrategyID: "s1", Timestamp: now, Userkey: "user1", Value: false, Version: 1}))
+
+	// De-duplication can be turned off:
+	impressionFilter = NewImpressionFilter(ImpressionConfig{DedupeWindow: -1})
+	assert.True(t, impressionFilter.Allow(impression))
+	assert.True(t, impressionFilter.Allow(impression))
+
+	// We only remember so many impressions:
+	impressionFilter = NewImpressionFilter(ImpressionConfig{MaxDedupeEntries: 2})
+	for _, userkey := range []string{"user1", "user2", "user3"} {
+		assert.True(t, impressionFilter.Allow(&models.Impression{Key: "feature1", Timestamp: now, Userkey: userkey}))
+	}
+	assert.LessOrEqual(t, len(impressionFilter.seen), 2)
+
+	// Sampling is consistent for each user, and lets through roughly the right proportion of them:
+	impressionFilter = NewImpressionFilter(ImpressionConfig{DedupeWindow: -1, SampleRate: 0.25})
+	var allowed int
+	for i := 0; i < 1000; i++ {
+		userImpression := &models.Impression{Key: "feature1", Timestamp: now, Userkey: fmt.Sprintf("user%d", i)}
+		if impressionFilter.Allow(userImpression) {
+			allowed++
+			assert.True(t, impressionFilter.Allow(userImpression))
+			assert.True(t, impressionFilter.Allow(&models.Impression{Key: "feature2", Timestamp: now, Userkey: userImpression.Userkey}))
+		}
+	}
+	assert.InDelta(t, 250, allowed, 50)
+}
+
+func TestPipelineImpressions(t *testing.T) {
+
+	// Impressions go to impression collectors (and only them):
+	pipeline := NewPipeline(PipelineConfig{})
+	fakeAnalyticsCollector := new(mocks.FakeAnalyticsCollector)
+	fakeImpressionCollector := new(mocks.FakeImpressionCollector)
+	pipeline.AddCollector(fakeAnalyticsCollector)
+	pipeline.AddImpressionCollector(fakeImpressionCollector)
+	pipeline.LogImpression(&models.Impression{Key: "feature1"})
+	pipeline.LogImpression(&models.Impression{Key: "feature2"})
+	assert.NoError(t, pipeline.Flush(context.Background()))
+	assert.Equal(t, 0, fakeAnalyticsCollector.LogEventCallCount())
+	assert.Equal(t, 1, fakeImpressionCollector.LogImpressionsCallCount())
+	impressions := fakeImpressionCollector.LogImpressionsArgsForCall(0)
+	assert.Len(t, impressions, 2)
+	assert.Equal(t, "feature2", impressions[1].Key)
+
+	// And analytics events don't go to impression collectors:
+	pipeline.LogEvent(newTestEvent("one"))
+	assert.NoError(t, pipeline.Flush(context.Background()))
+	assert.Equal(t, 1, fakeAnalyticsCollector.LogEventCallCount())
+	assert.Equal(t, 1, fakeImpressionCollector.LogImpressionsCallCount())
+	assert.Equal(t, []CollectorStats{
+		{Collector: "*mocks.FakeAnalyticsCollector", Sent: 1},
+		{Collector: "*mocks.FakeImpressionCollector", Sent: 2},
+	}, pipeline.Stats())
+}
@@ -0,0 +1,585 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/errors"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/interfaces"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/logging"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/metrics"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
+)
+
+const (
+	defaultBatchSize       = 100
+	defaultFlushInterval   = time.Second
+	defaultMaxRetries      = 3
+	defaultMaxRetryBackoff = 10 * time.Second
+	defaultQueueSize       = 1000
+	defaultRetryBackoff    = 100 * time.Millisecond
+)
+
+// DropPolicy decides what happens to events when a collector's queue is full:
+type DropPolicy string
+
+// DropPolicyNewest drops the incoming event (the default):
+const DropPolicyNewest DropPolicy = "newest"
+
+// DropPolicyOldest drops the oldest queued event to make room for the incoming one:
+const DropPolicyOldest DropPolicy = "oldest"
+
+// DropPolicyBlock blocks the caller until there is room in the queue (nothing is dropped unless the pipeline is closed first):
+const DropPolicyBlock DropPolicy = "block"
+
+// PipelineConfig controls how events are queued, batched and retried (zero values get sensible defaults):
+type PipelineConfig struct {
+	BatchSize       int             // The most events to submit to a collector at once (default 100)
+	DropPolicy      DropPolicy      // What to do with events when a collector's queue is full (default DropPolicyNewest)
+	FlushInterval   time.Duration   // How long an event can wait for its batch to fill up (default 1s)
+	MaxRetries      int             // How many times to retry events which a collector failed to submit (default 3, negative for no retries)
+	MaxRetryBackoff time.Duration   // The longest to wait between retries (default 10s)
+	QueueSize       int             // How many events can be queued for each collector (default 1000)
+	RetryBackoff    time.Duration   // How long to wait before the first retry (doubling for each retry after that, default 100ms)
+	Sanitiser       SanitiserPolicy // What personal data collectors get to see (unless they have their own policy, default everything)
+}
+
+// CollectorStats counts what the pipeline has done with events for one collector:
+type CollectorStats struct {
+	Collector string `json:"collector"` // The type of collector
+	Dropped   uint64 `json:"dropped"`   // Events which were dropped because the queue was full (or the pipeline was closed before they were submitted)
+	Failed    uint64 `json:"failed"`    // Events which the collector still failed to submit after retrying
+	Queued    int    `json:"queued"`    // Events currently waiting in the queue
+	Retried   uint64 `json:"retried"`   // Event retries
+	Sent      uint64 `json:"sent"`      // Events which the collector submitted successfully
+}
+
+// Pipeline sits between a client and its analytics (and impression) collectors:
+// - each collector gets its own bounded queue and background worker (so a slow or failing collector doesn't hold up the others)
+// - events are submitted in batches (BatchAnalyticsCollectors get the whole batch at once, others get one event at a time through AdaptAnalyticsCollector)
+// - events which fail are retried with exponential backoff
+// - Close flushes what is queued, then stops the workers (events logged after that are ignored, and anything left over is counted as dropped)
+type Pipeline struct {
+	closed            bool
+	config            PipelineConfig
+	done              chan struct{} // Closed to stop the workers (and anything waiting for room in a queue)
+	eventWorkers      []*pipelineWorker[*models.AnalyticsEvent]
+	impressionWorkers []*pipelineWorker[*models.Impression]
+	logger            logging.Logger
+	logging           sync.WaitGroup // Calls which are still handing items to the workers
+	metrics           interfaces.Metrics
+	mutex             sync.RWMutex
+	running           sync.WaitGroup // Workers which haven't stopped yet
+	sanitiser         *Sanitiser
+	workers           []pipelineStage
+}
+
+// pipelineStage is what the pipeline needs from every worker (whatever it is submitting):
+type pipelineStage interface {
+	discardQueued()
+	flushes() chan<- chan struct{}
+	stats() CollectorStats
+}
+
+// retryingCollector is a collector which retries failures itself (which the pipeline can turn off):
+type retryingCollector interface {
+	withoutRetries() interfaces.AnalyticsCollector
+}
+
+// pipelineWorker queues, batches and submits items (events or impressions) for one collector:
+type pipelineWorker[T any] struct {
+	collectorType string
+	dropped       atomic.Uint64
+	failed        atomic.Uint64
+	flushRequests chan chan struct{}
+	pipeline      *Pipeline
+	prepareItem   func(item T) T // Prepares each item for the collector (eg sanitising it) before it is queued
+	queue         chan T
+	retried       atomic.Uint64
+	sent          atomic.Uint64
+	submitItems   func(items []T) ([]T, error) // Hands items to the collector, returning any which it failed to submit
+}
+
+// NewPipeline returns a Pipeline with the given config (and no collectors):
+func NewPipeline(config PipelineConfig) *Pipeline {
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaultBatchSize
+	}
+	if config.DropPolicy == "" {
+		config.DropPolicy = DropPolicyNewest
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = defaultFlushInterval
+	}
+	if config.MaxRetries == 0 {
+		config.MaxRetries = defaultMaxRetries
+	}
+	if config.MaxRetryBackoff <= 0 {
+		config.MaxRetryBackoff = defaultMaxRetryBackoff
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = defaultQueueSize
+	}
+	if config.RetryBackoff <= 0 {
+		config.RetryBackoff = defaultRetryBackoff
+	}
+
+	return &Pipeline{
+		config:    config,
+		done:      make(chan struct{}),
+		logger:    logging.NoopLogger{},
+		metrics:   metrics.NoopMetrics{},
+		sanitiser: NewSanitiser(config.Sanitiser),
+	}
+}
+
+// WithLogger configures the logger used to report problems with collectors:
+func (p *Pipeline) WithLogger(logger logging.Logger) *Pipeline {
+	p.logger = logger
+	return p
+}
+
+// WithMetrics configures a metrics implementation (to count collector failures and dropped events):
+func (p *Pipeline) WithMetrics(metrics interfaces.Metrics) *Pipeline {
+	p.metrics = metrics
+	return p
+}
+
+// AddCollector starts queueing events for another collector:
+func (p *Pipeline) AddCollector(collector interfaces.AnalyticsCollector) {
+	collectorType := CollectorType(collector)
+
+	// Collectors can have their own sanitiser policy (instead of the pipeline's):
+	sanitiser := p.sanitiser
+	if sanitisedCollector, ok := collector.(*SanitisedCollector); ok {
+		collector, sanitiser = sanitisedCollector.collector, sanitisedCollector.sanitiser
+	}
+
+	// Collectors which retry on their own leave it to us (unless we don't retry), so requests aren't retried twice over:
+	if retryingCollector, ok := collector.(retryingCollector); ok && p.config.MaxRetries > 0 {
+		collector = retryingCollector.withoutRetries()
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.closed {
+		return
+	}
+
+	worker := newPipelineWorker(p, collectorType, sanitiser.Event, func(events []*models.AnalyticsEvent) ([]*models.AnalyticsEvent, error) {
+		return p.submitEvents(collector, collectorType, events)
+	})
+	p.eventWorkers = append(p.eventWorkers, worker)
+	p.workers = append(p.workers, worker)
+}
+
+// AddImpressionCollector starts queueing impressions for another collector:
+func (p *Pipeline) AddImpressionCollector(collector interfaces.ImpressionCollector) {
+	collectorType := CollectorType(collector)
+
+	// Collectors can have their own sanitiser policy (instead of the pipeline's):
+	sanitiser := p.sanitiser
+	if sanitisedCollector, ok := collector.(*SanitisedImpressionCollector); ok {
+		collector, sanitiser = sanitisedCollector.collector, sanitisedCollector.sanitiser
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.closed {
+		return
+	}
+
+	worker := newPipelineWorker(p, collectorType, sanitiser.Impression, func(impressions []*models.Impression) ([]*models.Impression, error) {
+		if err := collector.LogImpressions(impressions); err != nil {
+			p.metrics.AnalyticsCollectorFailure(collectorType)
+			return impressions, err
+		}
+		return nil, nil
+	})
+	p.impressionWorkers = append(p.impressionWorkers, worker)
+	p.workers = append(p.workers, worker)
+}
+
+// Close stops accepting events, flushes the ones which are already queued (until the context is done), then stops the workers:
+// - it returns an error if the context was done before everything was flushed (and the workers had stopped)
+func (p *Pipeline) Close(ctx context.Context) error {
+	p.mutex.Lock()
+	if p.closed {
+		p.mutex.Unlock()
+		return nil
+	}
+	p.closed = true
+	p.mutex.Unlock()
+
+	// Flush whatever was queued, then tell the workers to stop (even if we ran out of time):
+	err := p.Flush(ctx)
+	close(p.done)
+
+	// Wait for them to stop, then count anything which was still queued as dropped:
+	stopped := make(chan struct{})
+	go func() {
+		p.logging.Wait()
+		p.running.Wait()
+		for _, worker := range p.workers {
+			worker.discardQueued()
+		}
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	return err
+}
+
+// Flush blocks until every event queued so far has been submitted (or given up on), or the context is done:
+func (p *Pipeline) Flush(ctx context.Context) error {
+	p.mutex.RLock()
+	workers := p.workers
+	p.mutex.RUnlock()
+
+	// Ask every worker to flush (so they all do it at the same time):
+	flushed := make([]chan struct{}, 0, len(workers))
+	for _, worker := range workers {
+		done := make(chan struct{})
+		select {
+		case worker.flushes() <- done:
+			flushed = append(flushed, done)
+		case <-p.done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	// Then wait for them to finish:
+	for _, done := range flushed {
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+// LogEvent queues an event for every collector (what happens if a queue is full depends on the DropPolicy):
+func (p *Pipeline) LogEvent(event *models.AnalyticsEvent) {
+	workers, ok := startLogging(p, func() []*pipelineWorker[*models.AnalyticsEvent] { return p.eventWorkers })
+	if !ok {
+		return
+	}
+	defer p.logging.Done()
+
+	for _, worker := range workers {
+		worker.enqueue(event)
+	}
+}
+
+// LogEventSync submits an event to every collector straight away (without retrying), returning an error if any of them fail (or the pipeline is closed):
+func (p *Pipeline) LogEventSync(event *models.AnalyticsEvent) error {
+	workers, ok := startLogging(p, func() []*pipelineWorker[*models.AnalyticsEvent] { return p.eventWorkers })
+	if !ok {
+		return errors.NewErrNotReady("the analytics pipeline is closed")
+	}
+	defer p.logging.Done()
+
+	// One failing collector doesn't stop the others:
+	var errs []error
+	for _, worker := range workers {
+		if failed, err := worker.submit([]*models.AnalyticsEvent{worker.prepareItem(event)}); err != nil {
+			worker.fail(len(failed))
+			errs = append(errs, fmt.Errorf("%s: %w", worker.collectorType, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.NewErrAnalyticsCollectors(errs...)
+	}
+	return nil
+}
+
+// LogImpression queues an impression for every impression collector (what happens if a queue is full depends on the DropPolicy):
+func (p *Pipeline) LogImpression(impression *models.Impression) {
+	workers, ok := startLogging(p, func() []*pipelineWorker[*models.Impression] { return p.impressionWorkers })
+	if !ok {
+		return
+	}
+	defer p.logging.Done()
+
+	for _, worker := range workers {
+		worker.enqueue(impression)
+	}
+}
+
+// Stats returns counters for each collector (in the order they were added):
+func (p *Pipeline) Stats() []CollectorStats {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	stats := make([]CollectorStats, 0, len(p.workers))
+	for _, worker := range p.workers {
+		stats = append(stats, worker.stats())
+	}
+	return stats
+}
+
+// startLogging returns the workers to hand an item to (unless the pipeline is closed), counting the caller in until it calls p.logging.Done():
+// - the caller doesn't hold the lock while it hands items over, so a full queue (with DropPolicyBlock) can't hold up Close or AddCollector
+func startLogging[T any](p *Pipeline, workers func() []*pipelineWorker[T]) ([]*pipelineWorker[T], bool) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	if p.closed {
+		return nil, false
+	}
+
+	p.logging.Add(1)
+	return workers(), true
+}
+
+// submitEvents hands events to an analytics collector, returning any which it failed to submit (along with the last error):
+func (p *Pipeline) submitEvents(collector interfaces.AnalyticsCollector, collectorType string, events []*models.AnalyticsEvent) ([]*models.AnalyticsEvent, error) {
+
+	// Batch collectors take all of the events at once:
+	// (only the events they couldn't submit are retried, so the rest aren't duplicated):
+	if batchCollector, ok := collector.(interfaces.BatchAnalyticsCollector); ok {
+		failed, err := batchCollector.LogEvents(events)
+		if err != nil {
+			p.metrics.AnalyticsCollectorFailure(collectorType)
+			if len(failed) == 0 {
+				failed = events
+			}
+			return failed, err
+		}
+		return nil, nil
+	}
+
+	// Everything else gets one event at a time (with the context, if the collector can take it):
+	contextCollector := AdaptAnalyticsCollector(collector)
+	var failed []*models.AnalyticsEvent
+	var lastErr error
+	for _, event := range events {
+		if err := contextCollector.LogContextEvent(event); err != nil {
+			p.metrics.AnalyticsCollectorFailure(collectorType)
+			failed = append(failed, event)
+			lastErr = err
+		}
+	}
+	return failed, lastErr
+}
+
+// newPipelineWorker starts a worker which queues, batches and submits items for one collector:
+func newPipelineWorker[T any](pipeline *Pipeline, collectorType string, prepareItem func(item T) T, submitItems func(items []T) ([]T, error)) *pipelineWorker[T] {
+	worker := &pipelineWorker[T]{
+		collectorType: collectorType,
+		flushRequests: make(chan chan struct{}),
+		pipeline:      pipeline,
+		prepareItem:   prepareItem,
+		queue:         make(chan T, pipeline.config.QueueSize),
+		submitItems:   submitItems,
+	}
+	pipeline.running.Add(1)
+	go worker.run()
+	return worker
+}
+
+// flushes is where the worker receives flush requests (it closes the channel it is sent once it has flushed):
+func (w *pipelineWorker[T]) flushes() chan<- chan struct{} {
+	return w.flushRequests
+}
+
+// stats returns the counters for this worker:
+func (w *pipelineWorker[T]) stats() CollectorStats {
+	return CollectorStats{
+		Collector: w.collectorType,
+		Dropped:   w.dropped.Load(),
+		Failed:    w.failed.Load(),
+		Queued:    len(w.queue),
+		Retried:   w.retried.Load(),
+		Sent:      w.sent.Load(),
+	}
+}
+
+// enqueue adds an item to the queue, dropping (or blocking) according to the DropPolicy if it is full:
+func (w *pipelineWorker[T]) enqueue(item T) {
+	item = w.prepareItem(item)
+
+	switch w.pipeline.config.DropPolicy {
+
+	case DropPolicyBlock:
+		select {
+		case w.queue <- item:
+		case <-w.pipeline.done:
+			w.drop(1)
+		}
+
+	case DropPolicyOldest:
+		for {
+			select {
+			case w.queue <- item:
+				return
+			default:
+			}
+
+			// Make room by dropping the oldest event:
+			select {
+			case <-w.queue:
+				w.drop(1)
+			default:
+			}
+		}
+
+	default:
+		select {
+		case w.queue <- item:
+		default:
+			w.drop(1)
+		}
+	}
+}
+
+// discardQueued drops whatever is left in the queue (once the worker has stopped):
+func (w *pipelineWorker[T]) discardQueued() {
+	for {
+		select {
+		case <-w.queue:
+			w.drop(1)
+		default:
+			return
+		}
+	}
+}
+
+// drop counts items which never made it to the collector:
+func (w *pipelineWorker[T]) drop(count int) {
+	w.dropped.Add(uint64(count))
+	for i := 0; i < count; i++ {
+		w.pipeline.metrics.AnalyticsEventDropped(w.collectorType)
+	}
+}
+
+// fail counts items which the collector failed to submit (and won't be retried):
+func (w *pipelineWorker[T]) fail(count int) {
+	w.failed.Add(uint64(count))
+}
+
+// run batches up items from the queue, and submits them when the batch is full (or has waited long enough), until the pipeline is closed:
+func (w *pipelineWorker[T]) run() {
+	defer w.pipeline.running.Done()
+
+	var batch []T
+	var timer *time.Timer
+	var timeout <-chan time.Time
+
+	// Submit whatever we have:
+	send := func() {
+		if timer != nil {
+			timer.Stop()
+			timer, timeout = nil, nil
+		}
+		if len(batch) > 0 {
+			w.send(batch)
+			batch = nil
+		}
+	}
+
+	// Add an item to the batch (sending it if it's full):
+	add := func(item T) {
+		batch = append(batch, item)
+		switch {
+		case len(batch) >= w.pipeline.config.BatchSize:
+			send()
+		case timer == nil:
+			timer = time.NewTimer(w.pipeline.config.FlushInterval)
+			timeout = timer.C
+		}
+	}
+
+	// Stop as soon as the pipeline is closed (counting whatever we were holding on to as dropped):
+	stop := func() {
+		if timer != nil {
+			timer.Stop()
+		}
+		w.drop(len(batch))
+	}
+
+	for {
+		select {
+		case <-w.pipeline.done:
+			stop()
+			return
+		default:
+		}
+
+		select {
+		case <-w.pipeline.done:
+			stop()
+			return
+
+		case item := <-w.queue:
+			add(item)
+
+		case <-timeout:
+			send()
+
+		case done := <-w.flushRequests:
+
+			// Send everything which was queued before we were asked to flush:
+			for drained := false; !drained; {
+				select {
+				case item := <-w.queue:
+					add(item)
+				default:
+					drained = true
+				}
+			}
+			send()
+			close(done)
+		}
+	}
+}
+
+// send submits a batch of items to the collector, retrying any which fail (with exponential backoff):
+func (w *pipelineWorker[T]) send(batch []T) {
+	backoff := w.pipeline.config.RetryBackoff
+
+	for retries := 0; ; retries++ {
+		remaining, err := w.submit(batch)
+		if err == nil {
+			return
+		}
+
+		// Give up once we've run out of retries:
+		if retries >= w.pipeline.config.MaxRetries {
+			w.fail(len(remaining))
+			w.pipeline.logger.WithError(err).WithField("analytics_collector", w.collectorType).WithField("events", len(remaining)).Warn("Gave up submitting analytics events")
+			return
+		}
+
+		// Wait a while, then try again with whatever didn't make it:
+		w.retried.Add(uint64(len(remaining)))
+		w.pipeline.logger.WithError(err).WithField("analytics_collector", w.collectorType).WithField("backoff", backoff).Debug("Error submitting analytics events (will retry)")
+		select {
+		case <-time.After(backoff):
+		case <-w.pipeline.done:
+			w.fail(len(remaining))
+			w.pipeline.logger.WithError(err).WithField("analytics_collector", w.collectorType).WithField("events", len(remaining)).Warn("Gave up submitting analytics events (the pipeline was closed)")
+			return
+		}
+		backoff = min(backoff*2, w.pipeline.config.MaxRetryBackoff)
+		batch = remaining
+	}
+}
+
+// submit hands items to the collector, counting the ones which made it:
+func (w *pipelineWorker[T]) submit(items []T) ([]T, error) {
+	failed, err := w.submitItems(items)
+	w.sent.Add(uint64(len(items) - len(failed)))
+	return failed, err
+}
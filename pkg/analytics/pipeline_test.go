@@ -0,0 +1,264 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	fherrors "github.com/featurehub-io/featurehub-go-sdk/pkg/errors"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/mocks"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestEvent returns an analytics event for the given action:
+func newTestEvent(action string) *models.AnalyticsEvent {
+	return &models.AnalyticsEvent{
+		Action:    action,
+		Features:  map[string]*models.FeatureState{"feature1": {Key: "feature1", Value: true}},
+		Other:     map[string]string{"testing": "true"},
+		Timestamp: time.Now(),
+	}
+}
+
+func TestPipeline(t *testing.T) {
+
+	// A pipeline which only sends full batches (unless flushed):
+	fakeMetrics := new(mocks.FakeMetrics)
+	pipeline := NewPipeline(PipelineConfig{BatchSize: 3, FlushInterval: time.Hour, RetryBackoff: time.Millisecond}).WithMetrics(fakeMetrics)
+
+	// A batch collector, and a regular collector which fails the first time it sees each event:
+	batchCollector := new(mocks.FakeBatchAnalyticsCollector)
+	var batchesMutex sync.Mutex
+	var batches [][]string
+	batchCollector.LogEventsCalls(func(events []*models.AnalyticsEvent) ([]*models.AnalyticsEvent, error) {
+		batchesMutex.Lock()
+		defer batchesMutex.Unlock()
+		var actions []string
+		for _, event := range events {
+			actions = append(actions, event.Action)
+		}
+		batches = append(batches, actions)
+		return nil, nil
+	})
+	flakyCollector := new(mocks.FakeAnalyticsCollector)
+	var attemptsMutex sync.Mutex
+	attempts := make(map[string]int)
+	flakyCollector.LogEventCalls(func(action string, other map[string]string, features map[string]*models.FeatureState) error {
+		attemptsMutex.Lock()
+		defer attemptsMutex.Unlock()
+		attempts[action]++
+		if attempts[action] == 1 {
+			return errors.New("flaky")
+		}
+		return nil
+	})
+	pipeline.AddCollector(batchCollector)
+	pipeline.AddCollector(flakyCollector)
+
+	// Log a full batch, and part of another one:
+	for _, action := range []string{"one", "two", "three", "four"} {
+		pipeline.LogEvent(newTestEvent(action))
+	}
+	assert.Eventually(t, func() bool { return batchCollector.LogEventsCallCount() == 1 }, time.Second, 10*time.Millisecond)
+
+	// Flushing sends the rest (and waits for the retries):
+	assert.NoError(t, pipeline.Flush(context.Background()))
+	assert.Equal(t, [][]string{{"one", "two", "three"}, {"four"}}, batches)
+	assert.Equal(t, 0, batchCollector.LogEventCallCount())
+	assert.Equal(t, 8, flakyCollector.LogEventCallCount())
+	assert.Equal(t, 4, fakeMetrics.AnalyticsCollectorFailureCallCount())
+	assert.Equal(t, "*mocks.FakeAnalyticsCollector", fakeMetrics.AnalyticsCollectorFailureArgsForCall(0))
+	assert.Equal(t, []CollectorStats{
+		{Collector: "*mocks.FakeBatchAnalyticsCollector", Sent: 4},
+		{Collector: "*mocks.FakeAnalyticsCollector", Retried: 4, Sent: 4},
+	}, pipeline.Stats())
+
+	// Events which keep failing are given up on (without holding up the other collectors):
+	flakyCollector.LogEventReturns(errors.New("broken"))
+	pipeline.LogEvent(newTestEvent("five"))
+	assert.NoError(t, pipeline.Flush(context.Background()))
+	assert.Equal(t, []string{"five"}, batches[2])
+	assert.Equal(t, uint64(1), pipeline.Stats()[1].Failed)
+	assert.Equal(t, uint64(7), pipeline.Stats()[1].Retried)
+
+	// The sync method reports every collector which failed (but still submits to the others):
+	err := pipeline.LogEventSync(newTestEvent("six"))
+	assert.IsType(t, &fherrors.ErrAnalyticsCollectors{}, err)
+	assert.EqualError(t, err, "Analytics collector failures: *mocks.FakeAnalyticsCollector: broken")
+	assert.Equal(t, []string{"six"}, batches[3])
+	assert.Equal(t, uint64(6), pipeline.Stats()[0].Sent)
+
+	// Events are sent after the flush interval, even if the batch isn't full:
+	pipeline = NewPipeline(PipelineConfig{FlushInterval: 10 * time.Millisecond})
+	fakeCollector := new(mocks.FakeAnalyticsCollector)
+	pipeline.AddCollector(fakeCollector)
+	pipeline.LogEvent(newTestEvent("seven"))
+	assert.Eventually(t, func() bool { return fakeCollector.LogEventCallCount() == 1 }, time.Second, 10*time.Millisecond)
+	action, other, features := fakeCollector.LogEventArgsForCall(0)
+	assert.Equal(t, "seven", action)
+	assert.Equal(t, "true", other["testing"])
+	assert.Equal(t, true, features["feature1"].Value)
+
+	// Only the events which a batch collector failed to submit are retried:
+	pipeline = NewPipeline(PipelineConfig{BatchSize: 3, FlushInterval: time.Hour, RetryBackoff: time.Millisecond})
+	partialCollector := new(mocks.FakeBatchAnalyticsCollector)
+	partialCollector.LogEventsCalls(func(events []*models.AnalyticsEvent) ([]*models.AnalyticsEvent, error) {
+		if len(events) == 3 {
+			return events[2:], errors.New("partial")
+		}
+		return nil, nil
+	})
+	pipeline.AddCollector(partialCollector)
+	for _, action := range []string{"eight", "nine", "ten"} {
+		pipeline.LogEvent(newTestEvent(action))
+	}
+	assert.NoError(t, pipeline.Flush(context.Background()))
+	assert.Equal(t, 2, partialCollector.LogEventsCallCount())
+	retriedEvents := partialCollector.LogEventsArgsForCall(1)
+	assert.Len(t, retriedEvents, 1)
+	assert.Equal(t, "ten", retriedEvents[0].Action)
+	assert.Equal(t, []CollectorStats{{Collector: "*mocks.FakeBatchAnalyticsCollector", Retried: 1, Sent: 3}}, pipeline.Stats())
+
+	// A batch collector which returns an error without any failed events failed to submit all of them:
+	pipeline = NewPipeline(PipelineConfig{MaxRetries: 1, RetryBackoff: time.Millisecond})
+	brokenCollector := new(mocks.FakeBatchAnalyticsCollector)
+	brokenCollector.LogEventsReturns(nil, errors.New("broken"))
+	pipeline.AddCollector(brokenCollector)
+	pipeline.LogEvent(newTestEvent("eleven"))
+	pipeline.LogEvent(newTestEvent("twelve"))
+	assert.NoError(t, pipeline.Flush(context.Background()))
+	assert.Equal(t, 2, brokenCollector.LogEventsCallCount())
+	assert.Len(t, brokenCollector.LogEventsArgsForCall(1), 2)
+	assert.Equal(t, []CollectorStats{{Collector: "*mocks.FakeBatchAnalyticsCollector", Failed: 2, Retried: 2}}, pipeline.Stats())
+}
+
+func TestPipelineDropPolicies(t *testing.T) {
+
+	// A collector which blocks until we let it go:
+	release := make(chan struct{})
+	newBlockingCollector := func() *mocks.FakeAnalyticsCollector {
+		blockingCollector := new(mocks.FakeAnalyticsCollector)
+		blockingCollector.LogEventCalls(func(string, map[string]string, map[string]*models.FeatureState) error {
+			<-release
+			return nil
+		})
+		return blockingCollector
+	}
+
+	// Fill each pipeline's queue up (the first event gets stuck in the collector, the next two fill the queue):
+	fakeMetrics := new(mocks.FakeMetrics)
+	newestPipeline := NewPipeline(PipelineConfig{BatchSize: 1, QueueSize: 2}).WithMetrics(fakeMetrics)
+	newestCollector := newBlockingCollector()
+	newestPipeline.AddCollector(newestCollector)
+	oldestPipeline := NewPipeline(PipelineConfig{BatchSize: 1, DropPolicy: DropPolicyOldest, QueueSize: 2})
+	oldestCollector := newBlockingCollector()
+	oldestPipeline.AddCollector(oldestCollector)
+	for _, pipeline := range []*Pipeline{newestPipeline, oldestPipeline} {
+		pipeline.LogEvent(newTestEvent("stuck"))
+		assert.Eventually(t, func() bool { return pipeline.Stats()[0].Queued == 0 }, time.Second, 10*time.Millisecond)
+		pipeline.LogEvent(newTestEvent("one"))
+		pipeline.LogEvent(newTestEvent("two"))
+		pipeline.LogEvent(newTestEvent("three"))
+		assert.Equal(t, 2, pipeline.Stats()[0].Queued)
+		assert.Equal(t, uint64(1), pipeline.Stats()[0].Dropped)
+	}
+	assert.Equal(t, 1, fakeMetrics.AnalyticsEventDroppedCallCount())
+	assert.Equal(t, "*mocks.FakeAnalyticsCollector", fakeMetrics.AnalyticsEventDroppedArgsForCall(0))
+
+	// Flushing gives up when the context is done:
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, newestPipeline.Flush(ctx), context.DeadlineExceeded)
+
+	// Let the collectors go, and see which events made it:
+	close(release)
+	assert.NoError(t, newestPipeline.Flush(context.Background()))
+	assert.NoError(t, oldestPipeline.Flush(context.Background()))
+	actions := func(collector *mocks.FakeAnalyticsCollector) []string {
+		var actions []string
+		for i := 0; i < collector.LogEventCallCount(); i++ {
+			action, _, _ := collector.LogEventArgsForCall(i)
+			actions = append(actions, action)
+		}
+		return actions
+	}
+	assert.Equal(t, []string{"stuck", "one", "two"}, actions(newestCollector))
+	assert.Equal(t, []string{"stuck", "two", "three"}, actions(oldestCollector))
+
+	// Blocking pipelines don't drop anything:
+	blockingPipeline := NewPipeline(PipelineConfig{BatchSize: 1, DropPolicy: DropPolicyBlock, QueueSize: 1})
+	blockingPipeline.AddCollector(new(mocks.FakeAnalyticsCollector))
+	for i := 0; i < 10; i++ {
+		blockingPipeline.LogEvent(newTestEvent("blocking"))
+	}
+	assert.NoError(t, blockingPipeline.Flush(context.Background()))
+	assert.Equal(t, []CollectorStats{{Collector: "*mocks.FakeAnalyticsCollector", Sent: 10}}, blockingPipeline.Stats())
+}
+
+func TestPipelineClose(t *testing.T) {
+
+	// A pipeline which would hold on to events for a long time:
+	pipeline := NewPipeline(PipelineConfig{FlushInterval: time.Hour, RetryBackoff: time.Hour})
+	fakeCollector := new(mocks.FakeAnalyticsCollector)
+	pipeline.AddCollector(fakeCollector)
+	fakeImpressionCollector := new(mocks.FakeImpressionCollector)
+	pipeline.AddImpressionCollector(fakeImpressionCollector)
+	pipeline.LogEvent(newTestEvent("queued"))
+	pipeline.LogImpression(&models.Impression{Key: "feature1"})
+
+	// Closing flushes what was queued (and stops the workers):
+	assert.NoError(t, pipeline.Close(context.Background()))
+	assert.Equal(t, 1, fakeCollector.LogEventCallCount())
+	assert.Equal(t, 1, fakeImpressionCollector.LogImpressionsCallCount())
+
+	// Anything logged afterwards is ignored, and closing again (or flushing) is harmless:
+	pipeline.LogEvent(newTestEvent("ignored"))
+	pipeline.LogImpression(&models.Impression{Key: "feature1"})
+	pipeline.AddCollector(new(mocks.FakeAnalyticsCollector))
+	assert.NoError(t, pipeline.Flush(context.Background()))
+	assert.NoError(t, pipeline.Close(context.Background()))
+	assert.Equal(t, 1, fakeCollector.LogEventCallCount())
+	assert.Len(t, pipeline.Stats(), 2)
+
+	// Workers waiting to retry give up when the pipeline is closed (even if the flush ran out of time):
+	pipeline = NewPipeline(PipelineConfig{RetryBackoff: time.Hour})
+	failingCollector := new(mocks.FakeAnalyticsCollector)
+	failingCollector.LogEventReturns(errors.New("broken"))
+	pipeline.AddCollector(failingCollector)
+	pipeline.LogEvent(newTestEvent("failing"))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, pipeline.Close(ctx), context.DeadlineExceeded)
+	assert.Eventually(t, func() bool { return pipeline.Stats()[0].Failed == 1 }, time.Second, 10*time.Millisecond)
+
+	// The sync method doesn't reach collectors once the pipeline is closed:
+	assert.IsType(t, &fherrors.ErrNotReady{}, pipeline.LogEventSync(newTestEvent("closed")))
+	assert.Equal(t, 1, failingCollector.LogEventCallCount())
+
+	// Callers blocked on a full queue don't hold up adding collectors or closing, and whatever is left over is counted as dropped:
+	pipeline = NewPipeline(PipelineConfig{BatchSize: 1, DropPolicy: DropPolicyBlock, QueueSize: 1, RetryBackoff: time.Hour})
+	failingCollector = new(mocks.FakeAnalyticsCollector)
+	failingCollector.LogEventReturns(errors.New("broken"))
+	pipeline.AddCollector(failingCollector)
+	pipeline.LogEvent(newTestEvent("retrying"))
+	assert.Eventually(t, func() bool { return pipeline.Stats()[0].Retried == 1 }, time.Second, 10*time.Millisecond)
+	pipeline.LogEvent(newTestEvent("queued"))
+	blocked := make(chan struct{})
+	go func() {
+		defer close(blocked)
+		pipeline.LogEvent(newTestEvent("blocked"))
+	}()
+	time.Sleep(20 * time.Millisecond)
+	pipeline.AddCollector(new(mocks.FakeAnalyticsCollector))
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, pipeline.Close(ctx), context.DeadlineExceeded)
+	<-blocked
+	assert.Eventually(t, func() bool {
+		stats := pipeline.Stats()[0]
+		return stats.Failed == 1 && stats.Dropped == 2 && stats.Queued == 0
+	}, time.Second, 10*time.Millisecond)
+}
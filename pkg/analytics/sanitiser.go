@@ -0,0 +1,251 @@
+package analytics
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"reflect"
+	"time"
+
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/interfaces"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
+)
+
+const (
+	ipv4TruncatedBits = 24
+	ipv6TruncatedBits = 48
+)
+
+// SanitiserPolicy controls which personal data collectors get to see (the zero value passes everything on as it is):
+// - context keys are the standard attribute names ("userkey", "session", "device", "platform", "country" and "version") or the names of custom attributes
+type SanitiserPolicy struct {
+	AllowContextKeys []string // Only pass on these context attributes (if any are given)
+	AllowOtherKeys   []string // Only pass on these "other" attributes (if any are given)
+	DenyContextKeys  []string // Never pass on these context attributes
+	DenyOtherKeys    []string // Never pass on these "other" attributes
+	HashOtherKeys    []string // "other" attributes to pseudonymise along with the userkey and session (eg "cid")
+	HashSalt         string   // Pseudonymise the userkey and session with an HMAC-SHA256 keyed with this secret (if it is given)
+	IPKeys           []string // Custom context attributes and "other" attributes which hold IP addresses, to truncate (IPv4 to /24, IPv6 to /48, anything else is left out)
+}
+
+// Sanitiser applies a SanitiserPolicy to analytics events and impressions:
+type Sanitiser struct {
+	allowContextKeys map[string]struct{}
+	allowOtherKeys   map[string]struct{}
+	denyContextKeys  map[string]struct{}
+	denyOtherKeys    map[string]struct{}
+	hashOtherKeys    map[string]struct{}
+	hashSalt         []byte
+	ipKeys           map[string]struct{}
+	passThrough      bool
+}
+
+// SanitisedCollector is an analytics collector with its own SanitiserPolicy (which the pipeline uses instead of its default one):
+type SanitisedCollector struct {
+	collector interfaces.AnalyticsCollector
+	sanitiser *Sanitiser
+}
+
+// SanitisedImpressionCollector is an impression collector with its own SanitiserPolicy (which the pipeline uses instead of its default one):
+type SanitisedImpressionCollector struct {
+	collector interfaces.ImpressionCollector
+	sanitiser *Sanitiser
+}
+
+// NewSanitiser returns a Sanitiser for the given policy:
+func NewSanitiser(policy SanitiserPolicy) *Sanitiser {
+	return &Sanitiser{
+		allowContextKeys: keySet(policy.AllowContextKeys),
+		allowOtherKeys:   keySet(policy.AllowOtherKeys),
+		denyContextKeys:  keySet(policy.DenyContextKeys),
+		denyOtherKeys:    keySet(policy.DenyOtherKeys),
+		hashOtherKeys:    keySet(policy.HashOtherKeys),
+		hashSalt:         []byte(policy.HashSalt),
+		ipKeys:           keySet(policy.IPKeys),
+		passThrough: len(policy.AllowContextKeys) == 0 && len(policy.AllowOtherKeys) == 0 && len(policy.DenyContextKeys) == 0 &&
+			len(policy.DenyOtherKeys) == 0 && len(policy.HashSalt) == 0 && len(policy.IPKeys) == 0,
+	}
+}
+
+// NewSanitisedCollector gives an analytics collector its own SanitiserPolicy:
+func NewSanitisedCollector(collector interfaces.AnalyticsCollector, policy SanitiserPolicy) *SanitisedCollector {
+	return &SanitisedCollector{
+		collector: collector,
+		sanitiser: NewSanitiser(policy),
+	}
+}
+
+// NewSanitisedImpressionCollector gives an impression collector its own SanitiserPolicy:
+func NewSanitisedImpressionCollector(collector interfaces.ImpressionCollector, policy SanitiserPolicy) *SanitisedImpressionCollector {
+	return &SanitisedImpressionCollector{
+		collector: collector,
+		sanitiser: NewSanitiser(policy),
+	}
+}
+
+// Event returns a sanitised copy of an analytics event (the features are shared with the original):
+func (s *Sanitiser) Event(event *models.AnalyticsEvent) *models.AnalyticsEvent {
+	if s.passThrough {
+		return event
+	}
+
+	sanitisedEvent := *event
+	sanitisedEvent.Context = s.context(event.Context)
+
+	// Filter (and pseudonymise or truncate) the other attributes:
+	if event.Other != nil {
+		sanitisedEvent.Other = make(map[string]string, len(event.Other))
+		for key, value := range event.Other {
+			if !allowed(key, s.allowOtherKeys, s.denyOtherKeys) {
+				continue
+			}
+			if _, ok := s.hashOtherKeys[key]; ok {
+				value = s.hash(value)
+			}
+			if _, ok := s.ipKeys[key]; ok {
+				if value = truncateIP(value); len(value) == 0 {
+					continue
+				}
+			}
+			sanitisedEvent.Other[key] = value
+		}
+	}
+
+	return &sanitisedEvent
+}
+
+// Impression returns a sanitised copy of an impression:
+func (s *Sanitiser) Impression(impression *models.Impression) *models.Impression {
+	if s.passThrough {
+		return impression
+	}
+
+	sanitisedImpression := *impression
+	sanitisedImpression.Session = s.identifier("session", impression.Session)
+	sanitisedImpression.Userkey = s.identifier("userkey", impression.Userkey)
+	return &sanitisedImpression
+}
+
+// context returns a sanitised copy of a context:
+func (s *Sanitiser) context(context *models.Context) *models.Context {
+	if context == nil {
+		return nil
+	}
+
+	sanitisedContext := &models.Context{
+		Session:  s.identifier("session", context.Session),
+		Userkey:  s.identifier("userkey", context.Userkey),
+		Country:  models.ContextCountry(s.attribute("country", string(context.Country))),
+		Device:   models.ContextDevice(s.attribute("device", string(context.Device))),
+		Platform: models.ContextPlatform(s.attribute("platform", string(context.Platform))),
+		Version:  s.attribute("version", context.Version),
+	}
+
+	if context.Custom != nil {
+		sanitisedContext.Custom = make(map[string]interface{}, len(context.Custom))
+		for key, value := range context.Custom {
+			if !allowed(key, s.allowContextKeys, s.denyContextKeys) {
+				continue
+			}
+			if _, ok := s.ipKeys[key]; ok {
+				stringValue, _ := value.(string)
+				if stringValue = truncateIP(stringValue); len(stringValue) == 0 {
+					continue
+				}
+				value = stringValue
+			}
+			sanitisedContext.Custom[key] = value
+		}
+	}
+
+	return sanitisedContext
+}
+
+// attribute returns a standard context attribute (or nothing if it isn't allowed):
+func (s *Sanitiser) attribute(key, value string) string {
+	if !allowed(key, s.allowContextKeys, s.denyContextKeys) {
+		return ""
+	}
+	return value
+}
+
+// identifier returns a userkey or session (pseudonymised if we have a salt, or nothing if it isn't allowed):
+func (s *Sanitiser) identifier(key, value string) string {
+	return s.hash(s.attribute(key, value))
+}
+
+// hash pseudonymises a value (if we have a salt):
+func (s *Sanitiser) hash(value string) string {
+	if len(s.hashSalt) == 0 || len(value) == 0 {
+		return value
+	}
+	mac := hmac.New(sha256.New, s.hashSalt)
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// LogEvent sanitises an event, then hands it to the underlying collector (for when it is used outside of a pipeline):
+func (sc *SanitisedCollector) LogEvent(action string, other map[string]string, featureStateAtCurrentTime map[string]*models.FeatureState) error {
+	return sc.LogContextEvent(&models.AnalyticsEvent{Action: action, Features: featureStateAtCurrentTime, Other: other, Timestamp: time.Now()})
+}
+
+// LogContextEvent sanitises an event, then hands it to the underlying collector (for when it is used outside of a pipeline):
+func (sc *SanitisedCollector) LogContextEvent(event *models.AnalyticsEvent) error {
+	return AdaptAnalyticsCollector(sc.collector).LogContextEvent(sc.sanitiser.Event(event))
+}
+
+// LogImpressions sanitises impressions, then hands them to the underlying collector (for when it is used outside of a pipeline):
+func (sc *SanitisedImpressionCollector) LogImpressions(impressions []*models.Impression) error {
+	sanitisedImpressions := make([]*models.Impression, len(impressions))
+	for i, impression := range impressions {
+		sanitisedImpressions[i] = sc.sanitiser.Impression(impression)
+	}
+	return sc.collector.LogImpressions(sanitisedImpressions)
+}
+
+// CollectorType describes a collector (for stats, logs and metrics), by the type of whatever a SanitisedCollector wraps (eg "sanitised(*analytics.WebhookAnalyticsCollector)"):
+func CollectorType(collector interface{}) string {
+	switch sanitisedCollector := collector.(type) {
+	case *SanitisedCollector:
+		return fmt.Sprintf("sanitised(%s)", CollectorType(sanitisedCollector.collector))
+	case *SanitisedImpressionCollector:
+		return fmt.Sprintf("sanitised(%s)", CollectorType(sanitisedCollector.collector))
+	default:
+		return reflect.TypeOf(collector).String()
+	}
+}
+
+// allowed tells us whether a key makes it through allow and deny lists:
+func allowed(key string, allowKeys, denyKeys map[string]struct{}) bool {
+	if _, ok := denyKeys[key]; ok {
+		return false
+	}
+	if len(allowKeys) == 0 {
+		return true
+	}
+	_, ok := allowKeys[key]
+	return ok
+}
+
+// keySet turns a list of keys into a set:
+func keySet(keys []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		set[key] = struct{}{}
+	}
+	return set
+}
+
+// truncateIP zeroes the host part of an IP address (returning nothing if it isn't one):
+func truncateIP(value string) string {
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return ""
+	}
+	if ipv4 := ip.To4(); ipv4 != nil {
+		return ipv4.Mask(net.CIDRMask(ipv4TruncatedBits, 32)).String()
+	}
+	return ip.Mask(net.CIDRMask(ipv6TruncatedBits, 128)).String()
+}
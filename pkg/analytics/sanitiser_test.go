@@ -0,0 +1,111 @@
+package analytics
+
+import (
+	"context"
+	"testing"
+
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/mocks"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// newPersonalEvent returns an analytics event full of personal data:
+func newPersonalEvent() *models.AnalyticsEvent {
+	event := newTestEvent("personal")
+	event.Context = &models.Context{
+		Country: models.ContextCountryNewZealand,
+		Custom:  map[string]interface{}{"email": "bob@example.com", "ip": "203.0.113.77", "tier": "gold"},
+		Device:  models.ContextDeviceBrowser,
+		Session: "session1",
+		Userkey: "bob",
+	}
+	event.Other = map[string]string{"cid": "client1", "forwarded": "2001:db8:1234:5678::1", "name": "Bob", "page": "home"}
+	return event
+}
+
+func TestSanitiser(t *testing.T) {
+
+	// The zero value leaves events alone:
+	event := newPersonalEvent()
+	assert.Same(t, event, NewSanitiser(SanitiserPolicy{}).Event(event))
+
+	// Deny lists, hashing and IP truncation:
+	sanitiser := NewSanitiser(SanitiserPolicy{
+		DenyContextKeys: []string{"email", "session"},
+		DenyOtherKeys:   []string{"name"},
+		HashOtherKeys:   []string{"cid"},
+		HashSalt:        "salt",
+		IPKeys:          []string{"ip", "forwarded", "page"},
+	})
+	sanitisedEvent := sanitiser.Event(event)
+	assert.Equal(t, sanitiser.hash("bob"), sanitisedEvent.Context.Userkey)
+	assert.Len(t, sanitisedEvent.Context.Userkey, 64)
+	assert.NotEqual(t, NewSanitiser(SanitiserPolicy{HashSalt: "pepper"}).hash("bob"), sanitisedEvent.Context.Userkey)
+	assert.Empty(t, sanitisedEvent.Context.Session)
+	assert.Equal(t, models.ContextCountryNewZealand, sanitisedEvent.Context.Country)
+	assert.Equal(t, map[string]interface{}{"ip": "203.0.113.0", "tier": "gold"}, sanitisedEvent.Context.Custom)
+	assert.Equal(t, map[string]string{"cid": sanitiser.hash("client1"), "forwarded": "2001:db8:1234::"}, sanitisedEvent.Other)
+	assert.Equal(t, event.Features, sanitisedEvent.Features)
+
+	// The original event is untouched:
+	assert.Equal(t, newPersonalEvent().Context, event.Context)
+	assert.Equal(t, newPersonalEvent().Other, event.Other)
+
+	// Allow lists:
+	sanitiser = NewSanitiser(SanitiserPolicy{AllowContextKeys: []string{"country", "tier"}, AllowOtherKeys: []string{"page"}})
+	sanitisedEvent = sanitiser.Event(event)
+	assert.Equal(t, &models.Context{Country: models.ContextCountryNewZealand, Custom: map[string]interface{}{"tier": "gold"}}, sanitisedEvent.Context)
+	assert.Equal(t, map[string]string{"page": "home"}, sanitisedEvent.Other)
+
+	// Impressions:
+	impression := &models.Impression{Key: "feature1", Session: "session1", Userkey: "bob"}
+	sanitisedImpression := NewSanitiser(SanitiserPolicy{DenyContextKeys: []string{"session"}, HashSalt: "salt"}).Impression(impression)
+	assert.Empty(t, sanitisedImpression.Session)
+	assert.Equal(t, NewSanitiser(SanitiserPolicy{HashSalt: "salt"}).hash("bob"), sanitisedImpression.Userkey)
+	assert.Equal(t, "bob", impression.Userkey)
+}
+
+func TestPipelineSanitiser(t *testing.T) {
+
+	// A pipeline with a default policy, and collectors with their own:
+	pipeline := NewPipeline(PipelineConfig{Sanitiser: SanitiserPolicy{DenyOtherKeys: []string{"name"}}})
+	defaultCollector := new(mocks.FakeContextAnalyticsCollector)
+	strictCollector := new(mocks.FakeBatchAnalyticsCollector)
+	laxCollector := new(mocks.FakeAnalyticsCollector)
+	pipeline.AddCollector(defaultCollector)
+	pipeline.AddCollector(NewSanitisedCollector(strictCollector, SanitiserPolicy{AllowOtherKeys: []string{"page"}, DenyContextKeys: []string{"userkey"}}))
+	pipeline.AddCollector(NewSanitisedCollector(laxCollector, SanitiserPolicy{}))
+	pipeline.LogEvent(newPersonalEvent())
+	assert.NoError(t, pipeline.Flush(context.Background()))
+
+	// Each collector sees what its policy allows:
+	assert.NotContains(t, defaultCollector.LogContextEventArgsForCall(0).Other, "name")
+	assert.Equal(t, "bob", defaultCollector.LogContextEventArgsForCall(0).Context.Userkey)
+	strictEvent := strictCollector.LogEventsArgsForCall(0)[0]
+	assert.Equal(t, map[string]string{"page": "home"}, strictEvent.Other)
+	assert.Empty(t, strictEvent.Context.Userkey)
+	_, other, _ := laxCollector.LogEventArgsForCall(0)
+	assert.Equal(t, "Bob", other["name"])
+
+	// Stats show the underlying collectors (and that they are sanitised):
+	assert.Equal(t, "*mocks.FakeContextAnalyticsCollector", pipeline.Stats()[0].Collector)
+	assert.Equal(t, "sanitised(*mocks.FakeBatchAnalyticsCollector)", pipeline.Stats()[1].Collector)
+	assert.Equal(t, "sanitised(*mocks.FakeAnalyticsCollector)", CollectorType(NewSanitisedCollector(laxCollector, SanitiserPolicy{})))
+
+	// The sync method sanitises too:
+	assert.NoError(t, pipeline.LogEventSync(newPersonalEvent()))
+	assert.NotContains(t, defaultCollector.LogContextEventArgsForCall(1).Other, "name")
+
+	// Impression collectors can have their own policy too:
+	fakeImpressionCollector := new(mocks.FakeImpressionCollector)
+	pipeline.AddImpressionCollector(NewSanitisedImpressionCollector(fakeImpressionCollector, SanitiserPolicy{HashSalt: "salt"}))
+	pipeline.LogImpression(&models.Impression{Key: "feature1", Userkey: "bob"})
+	assert.NoError(t, pipeline.Flush(context.Background()))
+	assert.Equal(t, NewSanitiser(SanitiserPolicy{HashSalt: "salt"}).hash("bob"), fakeImpressionCollector.LogImpressionsArgsForCall(0)[0].Userkey)
+	assert.Equal(t, "sanitised(*mocks.FakeImpressionCollector)", pipeline.Stats()[3].Collector)
+
+	// Sanitised collectors work outside of a pipeline as well:
+	assert.NoError(t, NewSanitisedCollector(laxCollector, SanitiserPolicy{DenyOtherKeys: []string{"name"}}).LogEvent("direct", map[string]string{"name": "Bob"}, nil))
+	_, other, _ = laxCollector.LogEventArgsForCall(2)
+	assert.Empty(t, other)
+}
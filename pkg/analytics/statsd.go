@@ -0,0 +1,271 @@
+package analytics
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/errors"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
+)
+
+const (
+	defaultStatsDMaxOtherTags  = 10
+	defaultStatsDMaxPacketSize = 1432
+	defaultStatsDMaxTagValues  = 100
+	defaultStatsDPrefix        = "featurehub"
+	statsdActionTagName        = "action"
+	statsdEventMetric          = ".event"
+	statsdFeatureMetric        = ".feature"
+	statsdFeatureTagName       = "feature"
+	statsdOverflowTagValue     = "__other__"
+	statsdValueTagName         = "value"
+)
+
+// StatsDFormat is the flavour of StatsD to emit:
+type StatsDFormat string
+
+// StatsD formats:
+const (
+	StatsDFormatDogStatsD StatsDFormat = "dogstatsd" // DogStatsD (with tags)
+	StatsDFormatStatsD    StatsDFormat = "statsd"    // Plain StatsD (tags become part of the metric names)
+)
+
+// StatsDAnalyticsCollector implements the AnalyticsCollector interface by emitting StatsD metrics over UDP:
+// - a counter for each event ("{prefix}.event", tagged with the action and "other" attributes)
+// - a gauge for each feature ("{prefix}.feature", tagged with the key): booleans are 1 or 0, numbers are their value, and anything else is 1 (tagged with the value)
+// - each tag only gets so many distinct values (any more are reported as "__other__"), and only so many "other" attributes become tags
+// - with plain StatsD there are no tags, so the action, feature key and string values become part of the metric names instead (and "other" attributes are left out)
+type StatsDAnalyticsCollector struct {
+	conn          net.Conn
+	format        StatsDFormat
+	maxOtherTags  int
+	maxPacketSize int
+	maxTagValues  int
+	mutex         sync.Mutex
+	prefix        string
+	tagValues     map[string]map[string]struct{}
+}
+
+// NewStatsDAnalyticsCollector returns a StatsDAnalyticsCollector which sends DogStatsD metrics to the given address (eg "localhost:8125"):
+func NewStatsDAnalyticsCollector(address string) (*StatsDAnalyticsCollector, error) {
+	conn, err := net.Dial("udp", address)
+	if err != nil {
+		return nil, errors.NewErrBadConfigProblems(errors.ConfigProblem{Field: "address", Message: fmt.Sprintf("unable to use StatsD address %q: %s", address, err)})
+	}
+
+	return &StatsDAnalyticsCollector{
+		conn:          conn,
+		format:        StatsDFormatDogStatsD,
+		maxOtherTags:  defaultStatsDMaxOtherTags,
+		maxPacketSize: defaultStatsDMaxPacketSize,
+		maxTagValues:  defaultStatsDMaxTagValues,
+		prefix:        defaultStatsDPrefix,
+		tagValues:     make(map[string]map[string]struct{}),
+	}, nil
+}
+
+// WithFormat chooses between DogStatsD (the default) and plain StatsD:
+func (ac *StatsDAnalyticsCollector) WithFormat(format StatsDFormat) *StatsDAnalyticsCollector {
+	ac.format = format
+	return ac
+}
+
+// WithMaxOtherTags limits how many "other" attributes become tags (default 10, the first ones alphabetically, 0 for none):
+func (ac *StatsDAnalyticsCollector) WithMaxOtherTags(maxOtherTags int) *StatsDAnalyticsCollector {
+	ac.maxOtherTags = max(maxOtherTags, 0)
+	return ac
+}
+
+// WithMaxPacketSize limits the size of each UDP packet (default 1432 bytes, which fits in most networks' MTU):
+func (ac *StatsDAnalyticsCollector) WithMaxPacketSize(maxPacketSize int) *StatsDAnalyticsCollector {
+	if maxPacketSize > 0 {
+		ac.maxPacketSize = maxPacketSize
+	}
+	return ac
+}
+
+// WithMaxTagValues limits how many distinct values each tag can have (default 100, further values are reported as "__other__"):
+func (ac *StatsDAnalyticsCollector) WithMaxTagValues(maxTagValues int) *StatsDAnalyticsCollector {
+	if maxTagValues > 0 {
+		ac.maxTagValues = maxTagValues
+	}
+	return ac
+}
+
+// WithPrefix changes the prefix of every metric name (default "featurehub"):
+func (ac *StatsDAnalyticsCollector) WithPrefix(prefix string) *StatsDAnalyticsCollector {
+	ac.prefix = statsdName(prefix)
+	return ac
+}
+
+// Close closes the UDP connection:
+func (ac *StatsDAnalyticsCollector) Close() error {
+	return ac.conn.Close()
+}
+
+// LogEvent emits metrics for the given action and metadata:
+func (ac *StatsDAnalyticsCollector) LogEvent(action string, other map[string]string, featureStateAtCurrentTime map[string]*models.FeatureState) error {
+	_, err := ac.LogEvents([]*models.AnalyticsEvent{
+		{
+			Action:    action,
+			Features:  featureStateAtCurrentTime,
+			Other:     other,
+			Timestamp: time.Now(),
+		},
+	})
+	return err
+}
+
+// LogEvents emits metrics for the given analytics events (packing as many lines into each packet as will fit):
+// - if a packet can't be sent, the events from that packet onwards are returned (along with the error)
+func (ac *StatsDAnalyticsCollector) LogEvents(events []*models.AnalyticsEvent) ([]*models.AnalyticsEvent, error) {
+	ac.mutex.Lock()
+	defer ac.mutex.Unlock()
+
+	var packet bytes.Buffer
+	var packetStart int // The first event with lines in the packet
+	for i, event := range events {
+		for _, line := range ac.lines(event) {
+
+			// Send what we have if this line won't fit:
+			if packet.Len() > 0 && packet.Len()+1+len(line) > ac.maxPacketSize {
+				if _, err := ac.conn.Write(packet.Bytes()); err != nil {
+					return events[packetStart:], err
+				}
+				packet.Reset()
+				packetStart = i
+			}
+			if packet.Len() > 0 {
+				packet.WriteByte('\n')
+			}
+			packet.WriteString(line)
+		}
+	}
+
+	if packet.Len() > 0 {
+		if _, err := ac.conn.Write(packet.Bytes()); err != nil {
+			return events[packetStart:], err
+		}
+	}
+	return nil, nil
+}
+
+// lines returns the StatsD lines for an event (ac.mutex must be held):
+func (ac *StatsDAnalyticsCollector) lines(event *models.AnalyticsEvent) []string {
+	action := ac.tagValue(statsdActionTagName, statsdName(event.Action))
+
+	// Features are sorted by key (so the output is predictable):
+	features := event.ExperiencedFeatures()
+	featureKeys := make([]string, 0, len(features))
+	for key := range features {
+		featureKeys = append(featureKeys, key)
+	}
+	sort.Strings(featureKeys)
+
+	// Plain StatsD puts everything in the metric names:
+	if ac.format == StatsDFormatStatsD {
+		lines := []string{fmt.Sprintf("%s%s.%s:1|c", ac.prefix, statsdEventMetric, action)}
+		for _, key := range featureKeys {
+			featureKey := statsdName(features[key].Key)
+			gauge, stringValue, tagged := statsdGauge(features[key].Value)
+			metricName := ac.prefix + statsdFeatureMetric + "." + featureKey
+			if tagged {
+				metricName += "." + ac.tagValue(statsdValueTagName+"."+featureKey, statsdName(stringValue))
+			}
+			// A signed gauge is a relative change in plain StatsD, so negative values are set by zeroing the gauge first
+			// (in the same line, so the two are never split between packets):
+			if strings.HasPrefix(gauge, "-") {
+				lines = append(lines, fmt.Sprintf("%s:0|g\n%s:%s|g", metricName, metricName, gauge))
+				continue
+			}
+			lines = append(lines, fmt.Sprintf("%s:%s|g", metricName, gauge))
+		}
+		return lines
+	}
+
+	// DogStatsD tags the event counter with the action and (some of) the other attributes:
+	eventTags := []string{statsdActionTagName + ":" + action}
+	otherKeys := make([]string, 0, len(event.Other))
+	for key := range event.Other {
+		otherKeys = append(otherKeys, key)
+	}
+	sort.Strings(otherKeys)
+	for _, key := range otherKeys[:min(len(otherKeys), ac.maxOtherTags)] {
+		tagName := statsdName(key)
+		eventTags = append(eventTags, tagName+":"+ac.tagValue("other."+tagName, statsdName(event.Other[key])))
+	}
+	lines := []string{fmt.Sprintf("%s%s:1|c|#%s", ac.prefix, statsdEventMetric, strings.Join(eventTags, ","))}
+
+	// And a gauge for each feature:
+	for _, key := range featureKeys {
+		featureKey := statsdName(features[key].Key)
+		featureTags := []string{statsdActionTagName + ":" + action, statsdFeatureTagName + ":" + featureKey}
+		gauge, stringValue, tagged := statsdGauge(features[key].Value)
+		if tagged {
+			featureTags = append(featureTags, statsdValueTagName+":"+ac.tagValue(statsdValueTagName+"."+featureKey, statsdName(stringValue)))
+		}
+		lines = append(lines, fmt.Sprintf("%s%s:%s|g|#%s", ac.prefix, statsdFeatureMetric, gauge, strings.Join(featureTags, ",")))
+	}
+	return lines
+}
+
+// tagValue returns the value to use for a tag, keeping within the cardinality limit (ac.mutex must be held):
+func (ac *StatsDAnalyticsCollector) tagValue(tagName, value string) string {
+	values, ok := ac.tagValues[tagName]
+	if !ok {
+		values = make(map[string]struct{})
+		ac.tagValues[tagName] = values
+	}
+
+	if _, ok := values[value]; ok {
+		return value
+	}
+	if len(values) >= ac.maxTagValues {
+		return statsdOverflowTagValue
+	}
+	values[value] = struct{}{}
+	return value
+}
+
+// statsdGauge returns the gauge value for a feature value (for values which can't be a gauge, it is 1 and the value is returned as a string to tag it with):
+func statsdGauge(value interface{}) (string, string, bool) {
+	switch typedValue := value.(type) {
+	case bool:
+		if typedValue {
+			return "1", "", false
+		}
+		return "0", "", false
+	case float64:
+		return strconv.FormatFloat(typedValue, 'f', -1, 64), "", false
+	case int64:
+		return strconv.FormatInt(typedValue, 10), "", false
+	case int:
+		return strconv.Itoa(typedValue), "", false
+	case string:
+		return "1", typedValue, true
+	case nil:
+		return "1", "null", true
+	default:
+		return "1", fmt.Sprintf("%v", typedValue), true
+	}
+}
+
+// statsdName replaces characters which have a meaning in StatsD lines (or would spoil a metric name or tag):
+func statsdName(name string) string {
+	if name == "" {
+		return "_"
+	}
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ':', '|', '@', '#', ',', ' ', '\n', '\r', '\t':
+			return '_'
+		}
+		return r
+	}, name)
+}
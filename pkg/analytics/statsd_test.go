@@ -0,0 +1,96 @@
+package analytics
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/errors"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatsDAnalyticsCollector(t *testing.T) {
+
+	// Bad addresses are rejected:
+	_, err := NewStatsDAnalyticsCollector("not an address")
+	assert.IsType(t, &errors.ErrBadConfig{}, err)
+
+	// A local StatsD server:
+	listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer listener.Close()
+	receive := func() []string {
+		buffer := make([]byte, 65536)
+		assert.NoError(t, listener.SetReadDeadline(time.Now().Add(time.Second)))
+		n, _, err := listener.ReadFrom(buffer)
+		assert.NoError(t, err)
+		return strings.Split(string(buffer[:n]), "\n")
+	}
+
+	analyticsCollector, err := NewStatsDAnalyticsCollector(listener.LocalAddr().String())
+	assert.NoError(t, err)
+	defer analyticsCollector.Close()
+
+	// DogStatsD: a tagged counter for the event, and a tagged gauge for each feature:
+	testFeatures := map[string]*models.FeatureState{
+		"one":   {Key: "FEATURE_TANYA", Value: true},
+		"two":   {Key: "SUBMIT_COLOR_BUTTON", Value: "dark orange"},
+		"three": {Key: "NUMBER_OF_THINGS", Value: float64(3.5)},
+	}
+	assert.NoError(t, analyticsCollector.LogEvent("todo-add", map[string]string{"page": "home", "testing": "true"}, testFeatures))
+	assert.Equal(t, []string{
+		"featurehub.event:1|c|#action:todo-add,page:home,testing:true",
+		"featurehub.feature:1|g|#action:todo-add,feature:FEATURE_TANYA",
+		"featurehub.feature:3.5|g|#action:todo-add,feature:NUMBER_OF_THINGS",
+		"featurehub.feature:1|g|#action:todo-add,feature:SUBMIT_COLOR_BUTTON,value:dark_orange",
+	}, receive())
+
+	// Tag values are limited, and so are the other attributes which become tags:
+	analyticsCollector.WithMaxTagValues(2).WithMaxOtherTags(1).WithPrefix("fh")
+	for _, colour := range []string{"red", "blue"} {
+		assert.NoError(t, analyticsCollector.LogEvent("todo-add", map[string]string{"a": colour, "b": "ignored"}, map[string]*models.FeatureState{"two": {Key: "SUBMIT_COLOR_BUTTON", Value: colour}}))
+		receive()
+	}
+	assert.NoError(t, analyticsCollector.LogEvent("todo-add", map[string]string{"a": "green", "b": "ignored"}, map[string]*models.FeatureState{"two": {Key: "SUBMIT_COLOR_BUTTON", Value: "green"}}))
+	assert.Equal(t, []string{
+		"fh.event:1|c|#action:todo-add,a:__other__",
+		"fh.feature:1|g|#action:todo-add,feature:SUBMIT_COLOR_BUTTON,value:__other__",
+	}, receive())
+
+	// Plain StatsD puts it all in the metric names:
+	analyticsCollector.WithFormat(StatsDFormatStatsD)
+	assert.NoError(t, analyticsCollector.LogEvent("todo:add", nil, map[string]*models.FeatureState{
+		"one":   {Key: "FEATURE_TANYA", Value: false},
+		"three": {Key: "COLOUR", Value: "red"},
+	}))
+	assert.Equal(t, []string{
+		"fh.event.todo_add:1|c",
+		"fh.feature.FEATURE_TANYA:0|g",
+		"fh.feature.COLOUR.red:1|g",
+	}, receive())
+
+	// Negative gauges are zeroed first (otherwise plain StatsD would treat them as a decrement):
+	assert.NoError(t, analyticsCollector.LogEvent("todo:add", nil, map[string]*models.FeatureState{
+		"four": {Key: "TEMPERATURE", Value: float64(-2.5)},
+	}))
+	assert.Equal(t, []string{
+		"fh.event.todo_add:1|c",
+		"fh.feature.TEMPERATURE:0|g",
+		"fh.feature.TEMPERATURE:-2.5|g",
+	}, receive())
+
+	// Batches are packed into as few packets as will fit:
+	analyticsCollector.WithMaxPacketSize(60).WithMaxTagValues(100)
+	var events []*models.AnalyticsEvent
+	for i := 0; i < 6; i++ {
+		events = append(events, &models.AnalyticsEvent{Action: fmt.Sprintf("a%d", i)})
+	}
+	failed, err := analyticsCollector.LogEvents(events)
+	assert.NoError(t, err)
+	assert.Empty(t, failed)
+	assert.Equal(t, []string{"fh.event.a0:1|c", "fh.event.a1:1|c", "fh.event.a2:1|c"}, receive())
+	assert.Equal(t, []string{"fh.event.a3:1|c", "fh.event.a4:1|c", "fh.event.a5:1|c"}, receive())
+}
@@ -0,0 +1,281 @@
+package analytics
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/errors"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/interfaces"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
+)
+
+const (
+	defaultWebhookMaxBatchSize      = 100
+	defaultWebhookMaxRetries        = 2
+	defaultWebhookRetryBackoff      = 100 * time.Millisecond
+	webhookSignatureHeader          = "X-FeatureHub-Signature"
+	webhookSignaturePrefix          = "sha256="
+	webhookSignatureTimestampHeader = "X-FeatureHub-Timestamp"
+)
+
+// WebhookAnalyticsCollector implements the AnalyticsCollector interface by POSTing events as JSON to a URL (eg an ingestion service):
+// - events are sent in batches (as a document with an "events" array, even for single events)
+// - requests can be gzipped, and signed with an HMAC (SHA-256) of the timestamp and body
+// - transient failures (network errors, 408, 429 and 5xx responses) are retried with exponential backoff
+type WebhookAnalyticsCollector struct {
+	contextAttributes map[string]interface{}
+	gzip              bool
+	headers           http.Header
+	httpClient        *http.Client
+	maxBatchSize      int
+	maxRetries        int
+	retryBackoff      time.Duration
+	signingSecret     []byte
+	url               string
+}
+
+// WebhookDocument is the JSON document POSTed by the WebhookAnalyticsCollector:
+type WebhookDocument struct {
+	Events []*WebhookEvent `json:"events"`
+}
+
+// WebhookEvent is one analytics event within a WebhookDocument:
+type WebhookEvent struct {
+	Action    string                 `json:"action"`            // The action being logged
+	Context   map[string]interface{} `json:"context,omitempty"` // Context attributes (configured ones, and those of the context the event was logged with)
+	Features  []*WebhookFeature      `json:"features"`          // A snapshot of the features at the time of the event, as the context experienced them (sorted by key)
+	Other     map[string]string      `json:"other,omitempty"`   // Any other attributes provided with the event
+	Timestamp time.Time              `json:"timestamp"`         // When the event was logged
+}
+
+// WebhookFeature is the state of one feature within a WebhookEvent:
+type WebhookFeature struct {
+	Key     string      `json:"key"`     // Name of the feature
+	Value   interface{} `json:"value"`   // The value of the feature
+	Version int64       `json:"version"` // The version of the feature
+}
+
+// NewWebhookAnalyticsCollector returns a WebhookAnalyticsCollector which sends events to the given URL:
+func NewWebhookAnalyticsCollector(webhookURL string) (*WebhookAnalyticsCollector, error) {
+	parsedURL, err := url.Parse(webhookURL)
+	if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") || parsedURL.Host == "" {
+		return nil, errors.NewErrBadConfigProblems(errors.ConfigProblem{Field: "url", Message: fmt.Sprintf("url must be an absolute http(s) URL (not %q)", webhookURL)})
+	}
+
+	return &WebhookAnalyticsCollector{
+		headers:      make(http.Header),
+		httpClient:   http.DefaultClient,
+		maxBatchSize: defaultWebhookMaxBatchSize,
+		maxRetries:   defaultWebhookMaxRetries,
+		retryBackoff: defaultWebhookRetryBackoff,
+		url:          webhookURL,
+	}, nil
+}
+
+// WithContextAttributes adds attributes to the "context" of every event (eg the name of the service or environment), alongside those of the context the event was logged with:
+func (ac *WebhookAnalyticsCollector) WithContextAttributes(contextAttributes map[string]interface{}) *WebhookAnalyticsCollector {
+	ac.contextAttributes = contextAttributes
+	return ac
+}
+
+// WithGzip compresses request bodies (with "Content-Encoding: gzip"):
+func (ac *WebhookAnalyticsCollector) WithGzip(gzip bool) *WebhookAnalyticsCollector {
+	ac.gzip = gzip
+	return ac
+}
+
+// WithHeader adds a header to every request (eg for authentication):
+func (ac *WebhookAnalyticsCollector) WithHeader(name, value string) *WebhookAnalyticsCollector {
+	ac.headers.Add(name, value)
+	return ac
+}
+
+// WithHTTPClient configures the HTTP client used to send events (eg one with a proxy or custom CAs):
+func (ac *WebhookAnalyticsCollector) WithHTTPClient(httpClient *http.Client) *WebhookAnalyticsCollector {
+	ac.httpClient = httpClient
+	return ac
+}
+
+// WithMaxBatchSize limits how many events are sent in each request (default 100):
+func (ac *WebhookAnalyticsCollector) WithMaxBatchSize(maxBatchSize int) *WebhookAnalyticsCollector {
+	if maxBatchSize > 0 {
+		ac.maxBatchSize = maxBatchSize
+	}
+	return ac
+}
+
+// WithRetries configures how many times transient failures are retried (default 2), and the backoff before the first retry (default 100ms, doubling each time):
+// - an analytics pipeline which retries events itself (the default) turns these retries off, so requests aren't retried twice over
+func (ac *WebhookAnalyticsCollector) WithRetries(maxRetries int, retryBackoff time.Duration) *WebhookAnalyticsCollector {
+	ac.maxRetries = max(maxRetries, 0)
+	ac.retryBackoff = retryBackoff
+	return ac
+}
+
+// WithSigningSecret signs every request with an HMAC (SHA-256) of "{timestamp}.{body}":
+// - the signature is sent in the "X-FeatureHub-Signature" header (as "sha256={hex}"), and the timestamp (unix seconds) in "X-FeatureHub-Timestamp"
+// - the body is signed as it was sent (ie after gzipping)
+func (ac *WebhookAnalyticsCollector) WithSigningSecret(signingSecret string) *WebhookAnalyticsCollector {
+	ac.signingSecret = []byte(signingSecret)
+	return ac
+}
+
+// LogEvent sends the given action and metadata to the webhook:
+func (ac *WebhookAnalyticsCollector) LogEvent(action string, other map[string]string, featureStateAtCurrentTime map[string]*models.FeatureState) error {
+	_, err := ac.LogEvents([]*models.AnalyticsEvent{
+		{
+			Action:    action,
+			Features:  featureStateAtCurrentTime,
+			Other:     other,
+			Timestamp: time.Now(),
+		},
+	})
+	return err
+}
+
+// LogEvents sends the given analytics events to the webhook (in batches):
+// - if a batch fails, it stops there and returns the events which weren't delivered (that batch and the rest)
+func (ac *WebhookAnalyticsCollector) LogEvents(events []*models.AnalyticsEvent) ([]*models.AnalyticsEvent, error) {
+	for start := 0; start < len(events); start += ac.maxBatchSize {
+		end := min(start+ac.maxBatchSize, len(events))
+		document := &WebhookDocument{Events: make([]*WebhookEvent, 0, end-start)}
+		for _, event := range events[start:end] {
+			document.Events = append(document.Events, ac.newWebhookEvent(event))
+		}
+		if err := ac.send(document); err != nil {
+			return events[start:], err
+		}
+	}
+	return nil, nil
+}
+
+// withoutRetries returns a copy of the collector which doesn't retry anything (for a pipeline which does its own retrying):
+func (ac *WebhookAnalyticsCollector) withoutRetries() interfaces.AnalyticsCollector {
+	collector := *ac
+	collector.maxRetries = 0
+	return &collector
+}
+
+// newWebhookEvent turns an analytics event into a WebhookEvent:
+func (ac *WebhookAnalyticsCollector) newWebhookEvent(event *models.AnalyticsEvent) *WebhookEvent {
+	features := event.ExperiencedFeatures()
+	webhookEvent := &WebhookEvent{
+		Action:    event.Action,
+		Context:   ac.contextAttributes,
+		Features:  make([]*WebhookFeature, 0, len(features)),
+		Other:     event.Other,
+		Timestamp: event.Timestamp,
+	}
+
+	// The event's own context attributes take precedence over the configured ones:
+	if event.Context != nil {
+		webhookEvent.Context = make(map[string]interface{}, len(ac.contextAttributes))
+		for name, value := range ac.contextAttributes {
+			webhookEvent.Context[name] = value
+		}
+		for name, value := range event.Context.Attributes() {
+			webhookEvent.Context[name] = value
+		}
+	}
+
+	for _, featureState := range features {
+		webhookEvent.Features = append(webhookEvent.Features, &WebhookFeature{
+			Key:     featureState.Key,
+			Value:   featureState.Value,
+			Version: featureState.Version,
+		})
+	}
+	sort.Slice(webhookEvent.Features, func(i, j int) bool { return webhookEvent.Features[i].Key < webhookEvent.Features[j].Key })
+
+	return webhookEvent
+}
+
+// send POSTs a document to the webhook (retrying transient failures):
+func (ac *WebhookAnalyticsCollector) send(document *WebhookDocument) error {
+	body, err := json.Marshal(document)
+	if err != nil {
+		return err
+	}
+
+	// Compress the body if we've been asked to:
+	if ac.gzip {
+		var compressed bytes.Buffer
+		gzipWriter := gzip.NewWriter(&compressed)
+		if _, err := gzipWriter.Write(body); err != nil {
+			return err
+		}
+		if err := gzipWriter.Close(); err != nil {
+			return err
+		}
+		body = compressed.Bytes()
+	}
+
+	backoff := ac.retryBackoff
+	for attempt := 0; ; attempt++ {
+		retryable, err := ac.post(body)
+		if err == nil || !retryable || attempt >= ac.maxRetries {
+			return err
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// post makes one request to the webhook, and tells us whether any failure is worth retrying:
+func (ac *WebhookAnalyticsCollector) post(body []byte) (bool, error) {
+	request, err := http.NewRequest(http.MethodPost, ac.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+
+	// Headers (ours take precedence over any configured ones):
+	for name, values := range ac.headers {
+		request.Header[name] = values
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if ac.gzip {
+		request.Header.Set("Content-Encoding", "gzip")
+	}
+
+	// Sign the request (with a fresh timestamp for each attempt):
+	if len(ac.signingSecret) > 0 {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		request.Header.Set(webhookSignatureTimestampHeader, timestamp)
+		request.Header.Set(webhookSignatureHeader, webhookSignaturePrefix+SignWebhookRequest(ac.signingSecret, timestamp, body))
+	}
+
+	// Send the request (network errors are worth retrying):
+	response, err := ac.httpClient.Do(request)
+	if err != nil {
+		return true, err
+	}
+	defer response.Body.Close()
+	_, _ = io.Copy(io.Discard, response.Body)
+
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		retryable := response.StatusCode == http.StatusRequestTimeout || response.StatusCode == http.StatusTooManyRequests || response.StatusCode >= http.StatusInternalServerError
+		return retryable, errors.NewErrFromAPI(fmt.Sprintf("Webhook responded with %s", response.Status))
+	}
+
+	return false, nil
+}
+
+// SignWebhookRequest returns the (hex encoded) signature for a webhook request, which receivers can use to verify requests:
+func SignWebhookRequest(signingSecret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, signingSecret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
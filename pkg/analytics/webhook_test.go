@@ -0,0 +1,163 @@
+package analytics
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/errors"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookAnalyticsCollector(t *testing.T) {
+
+	// Bad URLs are rejected:
+	_, err := NewWebhookAnalyticsCollector("not a url")
+	assert.IsType(t, &errors.ErrBadConfig{}, err)
+
+	// A local ingestion service which records what it's sent (and fails when we ask it to):
+	var requestsMutex sync.Mutex
+	var documents []*WebhookDocument
+	var headers []http.Header
+	var failures []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestsMutex.Lock()
+		defer requestsMutex.Unlock()
+		if len(failures) > 0 {
+			w.WriteHeader(failures[0])
+			failures = failures[1:]
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		headers = append(headers, r.Header)
+
+		// Check the signature (on the body as it was sent):
+		if signature := r.Header.Get("X-FeatureHub-Signature"); signature != "" {
+			assert.Equal(t, "sha256="+SignWebhookRequest([]byte("secret"), r.Header.Get("X-FeatureHub-Timestamp"), body), signature)
+		}
+
+		// Unzip the body if we need to:
+		var reader io.Reader = bytes.NewReader(body)
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			reader, err = gzip.NewReader(reader)
+			assert.NoError(t, err)
+		}
+		document := new(WebhookDocument)
+		assert.NoError(t, json.NewDecoder(reader).Decode(document))
+		documents = append(documents, document)
+	}))
+	defer server.Close()
+
+	analyticsCollector, err := NewWebhookAnalyticsCollector(server.URL + "/ingest")
+	assert.NoError(t, err)
+	analyticsCollector.
+		WithContextAttributes(map[string]interface{}{"service": "todo"}).
+		WithHeader("Authorization", "Bearer token").
+		WithHTTPClient(server.Client()).
+		WithRetries(2, time.Millisecond)
+
+	// One event, as a document with the features sorted by key:
+	testFeatures := map[string]*models.FeatureState{
+		"two": {Key: "SUBMIT_COLOR_BUTTON", Value: "orange", Version: 3},
+		"one": {Key: "FEATURE_TANYA", Value: true, Version: 1},
+	}
+	assert.NoError(t, analyticsCollector.LogEvent("todo-add", map[string]string{"testing": "true"}, testFeatures))
+	assert.Len(t, documents, 1)
+	assert.Equal(t, "Bearer token", headers[0].Get("Authorization"))
+	assert.Equal(t, "application/json", headers[0].Get("Content-Type"))
+	assert.Empty(t, headers[0].Get("X-FeatureHub-Signature"))
+	assert.Len(t, documents[0].Events, 1)
+	event := documents[0].Events[0]
+	assert.Equal(t, "todo-add", event.Action)
+	assert.Equal(t, map[string]interface{}{"service": "todo"}, event.Context)
+	assert.Equal(t, map[string]string{"testing": "true"}, event.Other)
+	assert.WithinDuration(t, time.Now(), event.Timestamp, time.Minute)
+	assert.Equal(t, []*WebhookFeature{
+		{Key: "FEATURE_TANYA", Value: true, Version: 1},
+		{Key: "SUBMIT_COLOR_BUTTON", Value: "orange", Version: 3},
+	}, event.Features)
+
+	// Events logged with a context include its attributes, and the values it experienced:
+	documents = nil
+	contextEvent := newTestEvent("context")
+	contextEvent.Context = &models.Context{Userkey: "user1"}
+	contextEvent.Evaluated = models.EvaluatedFeatures{"feature1": {Key: "feature1", Value: false, Version: 2}}
+	failed, err := analyticsCollector.LogEvents([]*models.AnalyticsEvent{contextEvent})
+	assert.NoError(t, err)
+	assert.Empty(t, failed)
+	assert.Equal(t, map[string]interface{}{"service": "todo", "userkey": "user1"}, documents[0].Events[0].Context)
+	assert.Equal(t, []*WebhookFeature{{Key: "feature1", Value: false, Version: 2}}, documents[0].Events[0].Features)
+
+	// Batches are split up to fit the max batch size:
+	documents = nil
+	analyticsCollector.WithMaxBatchSize(2)
+	var events []*models.AnalyticsEvent
+	for i := 0; i < 5; i++ {
+		events = append(events, newTestEvent(fmt.Sprintf("event%d", i)))
+	}
+	failed, err = analyticsCollector.LogEvents(events)
+	assert.NoError(t, err)
+	assert.Empty(t, failed)
+	assert.Len(t, documents, 3)
+	assert.Len(t, documents[0].Events, 2)
+	assert.Equal(t, "event4", documents[2].Events[0].Action)
+
+	// Gzipped and signed:
+	documents, headers = nil, nil
+	analyticsCollector.WithGzip(true).WithSigningSecret("secret")
+	assert.NoError(t, analyticsCollector.LogEvent("signed", nil, testFeatures))
+	assert.Len(t, documents, 1)
+	assert.Equal(t, "gzip", headers[0].Get("Content-Encoding"))
+	assert.NotEmpty(t, headers[0].Get("X-FeatureHub-Signature"))
+	assert.Equal(t, "signed", documents[0].Events[0].Action)
+
+	// Transient failures are retried:
+	documents = nil
+	failures = []int{http.StatusServiceUnavailable, http.StatusTooManyRequests}
+	assert.NoError(t, analyticsCollector.LogEvent("retried", nil, testFeatures))
+	assert.Len(t, documents, 1)
+
+	// But only so many times:
+	failures = []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway}
+	err = analyticsCollector.LogEvent("failed", nil, testFeatures)
+	assert.IsType(t, &errors.ErrFromAPI{}, err)
+	assert.Contains(t, err.Error(), "Webhook responded with 502 Bad Gateway")
+	assert.Len(t, failures, 1)
+
+	// And other failures aren't retried at all:
+	failures = []int{http.StatusBadRequest, http.StatusBadRequest}
+	assert.Error(t, analyticsCollector.LogEvent("rejected", nil, testFeatures))
+	assert.Len(t, failures, 1)
+
+	// Only the events which weren't delivered are returned (so retrying them doesn't duplicate the rest):
+	documents = nil
+	analyticsCollector.WithGzip(false).WithMaxBatchSize(2).WithRetries(0, time.Millisecond)
+	failures = []int{http.StatusOK, http.StatusBadGateway}
+	failed, err = analyticsCollector.LogEvents(events)
+	assert.Error(t, err)
+	assert.Len(t, failed, 3)
+	assert.Equal(t, "event2", failed[0].Action)
+
+	// A pipeline does its own retrying, so the collector's retries are turned off inside one:
+	documents = nil
+	failures = []int{http.StatusBadGateway}
+	pipeline := NewPipeline(PipelineConfig{MaxRetries: 1, RetryBackoff: time.Millisecond})
+	pipeline.AddCollector(analyticsCollector.WithMaxBatchSize(100).WithRetries(5, time.Millisecond))
+	assert.Error(t, pipeline.LogEventSync(newTestEvent("unretried")))
+	failures = []int{http.StatusBadGateway}
+	pipeline.LogEvent(newTestEvent("retried-by-pipeline"))
+	assert.NoError(t, pipeline.Flush(context.Background()))
+	assert.Len(t, documents, 1)
+	assert.Equal(t, []CollectorStats{{Collector: "*analytics.WebhookAnalyticsCollector", Failed: 1, Retried: 1, Sent: 1}}, pipeline.Stats())
+}
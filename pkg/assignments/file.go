@@ -0,0 +1,186 @@
+package assignments
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
+)
+
+const (
+	defaultFlushInterval = 5 * time.Second
+)
+
+// FileStore implements the AssignmentStore interface by keeping assignments in memory, and saving them to a JSON file (so they survive restarts):
+// - changes are saved in the background (every 5s by default), and by Flush and Close, so Set never waits for the file to be written
+// - the whole file is written atomically (by renaming a temporary file), so a crash loses at most the changes since the last save
+// - it is intended for a single process (use your own AssignmentStore to share assignments between processes)
+type FileStore struct {
+	closeOnce   sync.Once
+	dirty       bool // Whether there are changes which haven't been saved (guarded by the memory store's mutex)
+	done        chan struct{}
+	memoryStore *MemoryStore
+	path        string
+	saveMutex   sync.Mutex // Only one save at a time
+	stopped     chan struct{}
+}
+
+// NewFileStore returns a FileStore which saves assignments to the given path (loading any which are already there):
+// - Close it when you're done with it, to save any remaining changes
+func NewFileStore(path string) (*FileStore, error) {
+	return NewFileStoreWithFlushInterval(path, defaultFlushInterval)
+}
+
+// NewFileStoreWithFlushInterval returns a FileStore which saves changes to the given path at the given interval:
+func NewFileStoreWithFlushInterval(path string, flushInterval time.Duration) (*FileStore, error) {
+	fileStore := &FileStore{
+		done:        make(chan struct{}),
+		memoryStore: NewMemoryStore(),
+		path:        path,
+		stopped:     make(chan struct{}),
+	}
+
+	// Load any existing assignments (oldest first, so the most recent are kept if there are too many):
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		var assignments []*models.Assignment
+		if err := json.Unmarshal(data, &assignments); err != nil {
+			return nil, err
+		}
+		sort.SliceStable(assignments, func(i, j int) bool {
+			return assignments[i].AssignedAt.Before(assignments[j].AssignedAt)
+		})
+		for _, assignment := range assignments {
+			fileStore.memoryStore.set(assignment)
+		}
+	}
+
+	if flushInterval <= 0 {
+		flushInterval = defaultFlushInterval
+	}
+	go fileStore.flushPeriodically(flushInterval)
+
+	return fileStore, nil
+}
+
+// Close stops saving in the background, then saves any remaining changes:
+func (s *FileStore) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.done)
+		<-s.stopped
+	})
+	return s.Flush()
+}
+
+// Flush saves any changes which haven't been saved yet:
+func (s *FileStore) Flush() error {
+	s.saveMutex.Lock()
+	defer s.saveMutex.Unlock()
+
+	// Take a snapshot of the assignments (if anything has changed):
+	s.memoryStore.mutex.Lock()
+	if !s.dirty {
+		s.memoryStore.mutex.Unlock()
+		return nil
+	}
+	assignments := s.memoryStore.all()
+	s.dirty = false
+	s.memoryStore.mutex.Unlock()
+
+	// Try again next time if we couldn't save them:
+	if err := s.save(assignments); err != nil {
+		s.memoryStore.mutex.Lock()
+		s.dirty = true
+		s.memoryStore.mutex.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Get returns the assignment of a userkey for a feature (or nil if there isn't one):
+func (s *FileStore) Get(userkey, featureKey string) (*models.Assignment, error) {
+	return s.memoryStore.Get(userkey, featureKey)
+}
+
+// Reset forgets every assignment for a feature (saving the rest later):
+func (s *FileStore) Reset(featureKey string) error {
+	s.memoryStore.mutex.Lock()
+	defer s.memoryStore.mutex.Unlock()
+
+	s.memoryStore.reset(featureKey)
+	s.dirty = true
+	return nil
+}
+
+// Set remembers an assignment (replacing any previous one for the same userkey and feature), saving it later:
+func (s *FileStore) Set(assignment *models.Assignment) error {
+	s.memoryStore.mutex.Lock()
+	defer s.memoryStore.mutex.Unlock()
+
+	s.memoryStore.set(assignment)
+	s.dirty = true
+	return nil
+}
+
+// WithMaxAssignments limits how many assignments are remembered (default 100000, the least recently used are forgotten first):
+func (s *FileStore) WithMaxAssignments(maxAssignments int) *FileStore {
+	s.memoryStore.WithMaxAssignments(maxAssignments)
+	s.memoryStore.mutex.Lock()
+	s.dirty = true
+	s.memoryStore.mutex.Unlock()
+	return s
+}
+
+// flushPeriodically saves changes at an interval until the store is closed (failed saves are retried next time):
+func (s *FileStore) flushPeriodically(flushInterval time.Duration) {
+	defer close(s.stopped)
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			_ = s.Flush()
+		}
+	}
+}
+
+// save writes the given assignments to the file, sorted so the file is stable:
+func (s *FileStore) save(assignments []*models.Assignment) error {
+	sort.Slice(assignments, func(i, j int) bool {
+		if assignments[i].FeatureKey != assignments[j].FeatureKey {
+			return assignments[i].FeatureKey < assignments[j].FeatureKey
+		}
+		return assignments[i].Userkey < assignments[j].Userkey
+	})
+
+	data, err := json.MarshalIndent(assignments, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first, so a crash can't leave us with half a file:
+	tempFile, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempFile.Name())
+	if _, err := tempFile.Write(data); err != nil {
+		tempFile.Close()
+		return err
+	}
+	if err := tempFile.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tempFile.Name(), s.path)
+}
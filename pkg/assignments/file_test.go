@@ -0,0 +1,85 @@
+package assignments
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "assignments.json")
+
+	// A missing file is fine:
+	fileStore, err := NewFileStore(path)
+	assert.NoError(t, err)
+	assignment, err := fileStore.Get("user1", "feature1")
+	assert.NoError(t, err)
+	assert.Nil(t, assignment)
+
+	// Assignments are saved when the store is flushed (not as they are made):
+	assert.NoError(t, fileStore.Set(&models.Assignment{FeatureKey: "feature1", StrategyID: "s1", Userkey: "user1"}))
+	assert.NoError(t, fileStore.Set(&models.Assignment{FeatureKey: "feature2", Userkey: "user1"}))
+	assert.NoFileExists(t, path)
+	assert.NoError(t, fileStore.Flush())
+	assert.FileExists(t, path)
+	assert.NoError(t, fileStore.Close())
+
+	// And loaded by the next store to use the file:
+	fileStore, err = NewFileStore(path)
+	assert.NoError(t, err)
+	assignment, err = fileStore.Get("user1", "feature1")
+	assert.NoError(t, err)
+	assert.Equal(t, "s1", assignment.StrategyID)
+	assignment, _ = fileStore.Get("user1", "feature2")
+	assert.Empty(t, assignment.StrategyID)
+	assert.Equal(t, "feature2", assignment.FeatureKey)
+
+	// Resets are saved too (when the store is closed):
+	assert.NoError(t, fileStore.Reset("feature1"))
+	assert.NoError(t, fileStore.Close())
+	assert.NoError(t, fileStore.Close())
+	fileStore, err = NewFileStore(path)
+	assert.NoError(t, err)
+	assignment, _ = fileStore.Get("user1", "feature1")
+	assert.Nil(t, assignment)
+	assignment, _ = fileStore.Get("user1", "feature2")
+	assert.NotNil(t, assignment)
+	assert.NoError(t, fileStore.Close())
+
+	// Changes are also saved in the background:
+	fileStore, err = NewFileStoreWithFlushInterval(path, 10*time.Millisecond)
+	assert.NoError(t, err)
+	assert.NoError(t, fileStore.Set(&models.Assignment{AssignedAt: time.Now(), FeatureKey: "feature3", StrategyID: "s3", Userkey: "user2"}))
+	assert.Eventually(t, func() bool {
+		data, err := os.ReadFile(path)
+		return err == nil && strings.Contains(string(data), "feature3")
+	}, time.Second, 10*time.Millisecond)
+	assert.NoError(t, fileStore.Close())
+
+	// Only the most recently used assignments are kept if there are too many:
+	fileStore, err = NewFileStore(path)
+	assert.NoError(t, err)
+	assert.NoError(t, fileStore.WithMaxAssignments(1).Close())
+	fileStore, err = NewFileStore(path)
+	assert.NoError(t, err)
+	assignment, _ = fileStore.Get("user2", "feature3")
+	assert.NotNil(t, assignment)
+	assignment, _ = fileStore.Get("user1", "feature2")
+	assert.Nil(t, assignment)
+	assert.NoError(t, fileStore.Close())
+
+	// No temporary files are left behind:
+	entries, err := os.ReadDir(filepath.Dir(path))
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	// Files which aren't assignments are an error:
+	assert.NoError(t, os.WriteFile(path, []byte("not json"), 0600))
+	_, err = NewFileStore(path)
+	assert.Error(t, err)
+}
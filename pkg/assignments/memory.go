@@ -0,0 +1,117 @@
+package assignments
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
+)
+
+const (
+	defaultMaxAssignments = 100000
+)
+
+// MemoryStore implements the AssignmentStore interface by keeping assignments in memory (so they only last as long as the process):
+// - it remembers a limited number of assignments, forgetting the least recently used first (those userkeys are bucketed afresh next time)
+type MemoryStore struct {
+	assignments    map[string]map[string]*list.Element // By feature key, then userkey (each element holds an assignment in the recency list)
+	maxAssignments int                                 // The most assignments to remember
+	mutex          sync.Mutex
+	recency        *list.List // Assignments, most recently used first
+}
+
+// NewMemoryStore returns an empty MemoryStore (which remembers up to 100000 assignments):
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		assignments:    make(map[string]map[string]*list.Element),
+		maxAssignments: defaultMaxAssignments,
+		recency:        list.New(),
+	}
+}
+
+// WithMaxAssignments limits how many assignments are remembered (default 100000, the least recently used are forgotten first):
+func (s *MemoryStore) WithMaxAssignments(maxAssignments int) *MemoryStore {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if maxAssignments > 0 {
+		s.maxAssignments = maxAssignments
+		s.evict()
+	}
+	return s
+}
+
+// Get returns the assignment of a userkey for a feature (or nil if there isn't one):
+func (s *MemoryStore) Get(userkey, featureKey string) (*models.Assignment, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	element, ok := s.assignments[featureKey][userkey]
+	if !ok {
+		return nil, nil
+	}
+	s.recency.MoveToFront(element)
+	return element.Value.(*models.Assignment), nil
+}
+
+// Reset forgets every assignment for a feature:
+func (s *MemoryStore) Reset(featureKey string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.reset(featureKey)
+	return nil
+}
+
+// Set remembers an assignment (replacing any previous one for the same userkey and feature):
+func (s *MemoryStore) Set(assignment *models.Assignment) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.set(assignment)
+	return nil
+}
+
+// all returns every assignment (mutex must be held):
+func (s *MemoryStore) all() []*models.Assignment {
+	assignments := make([]*models.Assignment, 0, s.recency.Len())
+	for element := s.recency.Front(); element != nil; element = element.Next() {
+		assignments = append(assignments, element.Value.(*models.Assignment))
+	}
+	return assignments
+}
+
+// evict forgets the least recently used assignments until we're within our limit (mutex must be held):
+func (s *MemoryStore) evict() {
+	for s.recency.Len() > s.maxAssignments {
+		oldest := s.recency.Back()
+		assignment := s.recency.Remove(oldest).(*models.Assignment)
+		delete(s.assignments[assignment.FeatureKey], assignment.Userkey)
+		if len(s.assignments[assignment.FeatureKey]) == 0 {
+			delete(s.assignments, assignment.FeatureKey)
+		}
+	}
+}
+
+// reset forgets every assignment for a feature (mutex must be held):
+func (s *MemoryStore) reset(featureKey string) {
+	for _, element := range s.assignments[featureKey] {
+		s.recency.Remove(element)
+	}
+	delete(s.assignments, featureKey)
+}
+
+// set remembers an assignment as the most recently used, forgetting the least recently used if we have too many (mutex must be held):
+func (s *MemoryStore) set(assignment *models.Assignment) {
+	if element, ok := s.assignments[assignment.FeatureKey][assignment.Userkey]; ok {
+		element.Value = assignment
+		s.recency.MoveToFront(element)
+		return
+	}
+
+	if _, ok := s.assignments[assignment.FeatureKey]; !ok {
+		s.assignments[assignment.FeatureKey] = make(map[string]*list.Element)
+	}
+	s.assignments[assignment.FeatureKey][assignment.Userkey] = s.recency.PushFront(assignment)
+	s.evict()
+}
@@ -0,0 +1,52 @@
+package assignments
+
+import (
+	"testing"
+
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStore(t *testing.T) {
+	memoryStore := NewMemoryStore()
+
+	// Nothing is assigned to begin with:
+	assignment, err := memoryStore.Get("user1", "feature1")
+	assert.NoError(t, err)
+	assert.Nil(t, assignment)
+
+	// Assignments are per userkey and feature:
+	assert.NoError(t, memoryStore.Set(&models.Assignment{FeatureKey: "feature1", StrategyID: "s1", Userkey: "user1"}))
+	assert.NoError(t, memoryStore.Set(&models.Assignment{FeatureKey: "feature2", StrategyID: "s2", Userkey: "user1"}))
+	assignment, err = memoryStore.Get("user1", "feature1")
+	assert.NoError(t, err)
+	assert.Equal(t, "s1", assignment.StrategyID)
+	assignment, _ = memoryStore.Get("user2", "feature1")
+	assert.Nil(t, assignment)
+
+	// Setting again replaces the assignment:
+	assert.NoError(t, memoryStore.Set(&models.Assignment{FeatureKey: "feature1", StrategyID: "s3", Userkey: "user1"}))
+	assignment, _ = memoryStore.Get("user1", "feature1")
+	assert.Equal(t, "s3", assignment.StrategyID)
+
+	// Resetting a feature only forgets its assignments:
+	assert.NoError(t, memoryStore.Reset("feature1"))
+	assignment, _ = memoryStore.Get("user1", "feature1")
+	assert.Nil(t, assignment)
+	assignment, _ = memoryStore.Get("user1", "feature2")
+	assert.Equal(t, "s2", assignment.StrategyID)
+
+	// Only a limited number of assignments are remembered (the least recently used are forgotten first):
+	memoryStore = NewMemoryStore().WithMaxAssignments(2)
+	assert.NoError(t, memoryStore.Set(&models.Assignment{FeatureKey: "feature1", StrategyID: "s1", Userkey: "user1"}))
+	assert.NoError(t, memoryStore.Set(&models.Assignment{FeatureKey: "feature1", StrategyID: "s1", Userkey: "user2"}))
+	_, _ = memoryStore.Get("user1", "feature1")
+	assert.NoError(t, memoryStore.Set(&models.Assignment{FeatureKey: "feature1", StrategyID: "s1", Userkey: "user3"}))
+	assignment, _ = memoryStore.Get("user1", "feature1")
+	assert.NotNil(t, assignment)
+	assignment, _ = memoryStore.Get("user2", "feature1")
+	assert.Nil(t, assignment)
+	assignment, _ = memoryStore.Get("user3", "feature1")
+	assert.NotNil(t, assignment)
+	assert.Equal(t, 2, memoryStore.recency.Len())
+}
@@ -0,0 +1,29 @@
+package errors
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ErrAnalyticsCollectors is returned when one or more analytics collectors fail to submit an event:
+type ErrAnalyticsCollectors struct {
+	errs []error
+}
+
+// NewErrAnalyticsCollectors returns a ErrAnalyticsCollectors wrapping the error from each collector which failed:
+func NewErrAnalyticsCollectors(errs ...error) *ErrAnalyticsCollectors {
+	return &ErrAnalyticsCollectors{errs: errs}
+}
+
+// Unwrap returns the error from each collector which failed (so errors.Is and errors.As can see them):
+func (e *ErrAnalyticsCollectors) Unwrap() []error {
+	return e.errs
+}
+
+func (e *ErrAnalyticsCollectors) Error() string {
+	messages := make([]string, len(e.errs))
+	for i, err := range e.errs {
+		messages[i] = err.Error()
+	}
+	return fmt.Sprintf("Analytics collector failures: %s", strings.Join(messages, "; "))
+}
@@ -1,10 +1,20 @@
 package errors
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 // ErrBadConfig is returned when bad config is detected:
 type ErrBadConfig struct {
-	message string
+	message  string
+	problems []ConfigProblem
+}
+
+// ConfigProblem describes what is wrong with one config field:
+type ConfigProblem struct {
+	Field   string // The name of the field (or environment variable, or file key)
+	Message string // What is wrong with it
 }
 
 // NewErrBadConfig returns a ErrBadConfig with a user-provided message:
@@ -12,6 +22,20 @@ func NewErrBadConfig(message string) *ErrBadConfig {
 	return &ErrBadConfig{message: message}
 }
 
+// NewErrBadConfigProblems returns a ErrBadConfig which describes everything that is wrong with the config:
+func NewErrBadConfigProblems(problems ...ConfigProblem) *ErrBadConfig {
+	messages := make([]string, len(problems))
+	for i, problem := range problems {
+		messages[i] = problem.Message
+	}
+	return &ErrBadConfig{message: strings.Join(messages, "; "), problems: problems}
+}
+
+// Problems returns what is wrong with each config field (if we know):
+func (e *ErrBadConfig) Problems() []ConfigProblem {
+	return e.problems
+}
+
 func (e *ErrBadConfig) Error() string {
 	if e.message != "" {
 		return fmt.Sprintf("Invalid config: %s", e.message)
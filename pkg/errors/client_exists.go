@@ -0,0 +1,20 @@
+package errors
+
+import "fmt"
+
+// ErrClientExists is returned when the user adds a client to a registry under a name which is already taken:
+type ErrClientExists struct {
+	message string
+}
+
+// NewErrClientExists returns a ErrClientExists with a user-provided message:
+func NewErrClientExists(message string) *ErrClientExists {
+	return &ErrClientExists{message: message}
+}
+
+func (e *ErrClientExists) Error() string {
+	if e.message != "" {
+		return fmt.Sprintf("Client already exists: %s", e.message)
+	}
+	return "Client already exists"
+}
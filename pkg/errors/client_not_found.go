@@ -0,0 +1,20 @@
+package errors
+
+import "fmt"
+
+// ErrClientNotFound is returned when the user asks a registry for a client it doesn't have:
+type ErrClientNotFound struct {
+	message string
+}
+
+// NewErrClientNotFound returns a ErrClientNotFound with a user-provided message:
+func NewErrClientNotFound(message string) *ErrClientNotFound {
+	return &ErrClientNotFound{message: message}
+}
+
+func (e *ErrClientNotFound) Error() string {
+	if e.message != "" {
+		return fmt.Sprintf("Client not found: %s", e.message)
+	}
+	return "Client not found"
+}
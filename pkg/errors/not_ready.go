@@ -0,0 +1,20 @@
+package errors
+
+import "fmt"
+
+// ErrNotReady is returned when a client will never become ready (eg it failed, or was closed before it received any data):
+type ErrNotReady struct {
+	message string
+}
+
+// NewErrNotReady returns a ErrNotReady with a user-provided message:
+func NewErrNotReady(message string) *ErrNotReady {
+	return &ErrNotReady{message: message}
+}
+
+func (e *ErrNotReady) Error() string {
+	if e.message != "" {
+		return fmt.Sprintf("Client not ready: %s", e.message)
+	}
+	return "Client not ready"
+}
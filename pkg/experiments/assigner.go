@@ -0,0 +1,137 @@
+package experiments
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
+)
+
+const (
+	// ExposureAction is the analytics action used to log that a context has been exposed to an experiment:
+	ExposureAction = "experiment-exposure"
+
+	defaultMaxExposures = 100000
+	holdoutSalt         = "holdout"
+	layerSalt           = "layer"
+)
+
+// Config controls how contexts are assigned to experiments (zero values get sensible defaults):
+// - percentages are 0 to 100, and contexts are bucketed by their userkey / session (like percentage strategies)
+type Config struct {
+	HoldoutPercentage float64            // The percentage of contexts held out of every experiment (they get the default value)
+	Holdouts          map[string]float64 // Holdout percentages for particular experiments (by feature key), instead of HoldoutPercentage
+	Layers            []Layer            // Mutual-exclusion layers (a context takes part in no more than one experiment in each layer)
+	MaxExposures      int                // The most exposures to remember (the least recently seen are forgotten first), so each is logged at most once while it is remembered (default 100000)
+}
+
+// Layer is a group of mutually exclusive experiments (each gets an equal share of contexts):
+type Layer struct {
+	Experiments []string // The feature keys of the experiments in this layer
+	Name        string   // The name of the layer (which also salts its bucketing)
+}
+
+// Assignment describes which group a context is in for an experiment:
+type Assignment struct {
+	Bucket   int    // The context's percentage bucket (0 to 999999, the same one percentage strategies use)
+	Excluded bool   // The context is in another experiment in the same layer
+	Holdout  bool   // The context is in the holdout group
+	Layer    string // The layer the experiment is in (if any)
+}
+
+// Assigner assigns contexts to experiments, and remembers which exposures have already been logged:
+type Assigner struct {
+	config          Config
+	exposureRecency *list.List // Exposure keys (least recently seen at the front)
+	exposures       map[string]*list.Element
+	layers          map[string]*Layer
+	mutex           sync.Mutex
+}
+
+// NewAssigner returns an Assigner with the given config:
+func NewAssigner(config Config) *Assigner {
+	if config.MaxExposures <= 0 {
+		config.MaxExposures = defaultMaxExposures
+	}
+
+	// Index the layers by experiment:
+	layers := make(map[string]*Layer)
+	for i, layer := range config.Layers {
+		for _, experiment := range layer.Experiments {
+			layers[experiment] = &config.Layers[i]
+		}
+	}
+
+	return &Assigner{
+		config:          config,
+		exposureRecency: list.New(),
+		exposures:       make(map[string]*list.Element),
+		layers:          layers,
+	}
+}
+
+// InExperiment tells us whether the context is taking part in the experiment (neither held out nor excluded by its layer):
+func (a Assignment) InExperiment() bool {
+	return !a.Excluded && !a.Holdout
+}
+
+// Assign works out which group the given hash key (userkey or session) is in for an experiment:
+func (a *Assigner) Assign(hashKey, experiment string) Assignment {
+	assignment := Assignment{
+		Bucket: int(models.PercentageBucket(hashKey)),
+	}
+
+	// The holdout group is the same for every experiment sharing a holdout percentage (so it can be used as a universal control group):
+	holdoutPercentage, ok := a.config.Holdouts[experiment]
+	if !ok {
+		holdoutPercentage = a.config.HoldoutPercentage
+	}
+	if holdoutPercentage > 0 {
+		assignment.Holdout = bucket(hashKey, holdoutSalt) < holdoutPercentage*10000
+	}
+
+	// Layers split their contexts between their experiments (salted by the layer name, so layers are independent of each other):
+	if layer, ok := a.layers[experiment]; ok {
+		assignment.Layer = layer.Name
+		slot := int(bucket(hashKey, layerSalt, layer.Name) * float64(len(layer.Experiments)) / 1000000)
+		slot = min(slot, len(layer.Experiments)-1)
+		assignment.Excluded = layer.Experiments[slot] != experiment
+	}
+
+	return assignment
+}
+
+// Expose tells us whether this is the first exposure of the given hash key to a variant of an experiment (so it should be logged):
+// - exposures are remembered per variant, so a context which moves to another variant (eg because the strategies changed) is exposed again
+// - exposures are only remembered while they are among the MaxExposures most recently seen, so a forgotten one is logged again
+// - the exposure is remembered before it is logged, so an event which is then dropped (eg by a full queue) isn't retried
+func (a *Assigner) Expose(hashKey, experiment, variant string) bool {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	exposureKey := fmt.Sprintf("%s\x00%s\x00%s", hashKey, experiment, variant)
+	if element, ok := a.exposures[exposureKey]; ok {
+		a.exposureRecency.MoveToBack(element)
+		return false
+	}
+
+	// Make room if we need to (by forgetting the least recently seen exposure):
+	if len(a.exposures) >= a.config.MaxExposures {
+		oldest := a.exposureRecency.Front()
+		a.exposureRecency.Remove(oldest)
+		delete(a.exposures, oldest.Value.(string))
+	}
+
+	a.exposures[exposureKey] = a.exposureRecency.PushBack(exposureKey)
+	return true
+}
+
+// bucket puts a hash key in a percentage bucket (0 to 1000000) which is independent of the one strategies use:
+func bucket(hashKey string, salts ...string) float64 {
+	var salted string
+	for _, salt := range salts {
+		salted += salt + "\x00"
+	}
+	return models.PercentageBucket(salted + hashKey)
+}
@@ -0,0 +1,76 @@
+package experiments
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssigner(t *testing.T) {
+
+	// Without any config everybody takes part, in the same bucket percentage strategies use:
+	assignment := NewAssigner(Config{}).Assign("user1", "experiment1")
+	assert.True(t, assignment.InExperiment())
+	assert.Equal(t, int(models.PercentageBucket("user1")), assignment.Bucket)
+	assert.Empty(t, assignment.Layer)
+
+	// Holdouts (for every experiment, or particular ones), and layers:
+	assigner := NewAssigner(Config{
+		HoldoutPercentage: 10,
+		Holdouts:          map[string]float64{"experiment4": 0, "experiment5": 100},
+		Layers:            []Layer{{Name: "checkout", Experiments: []string{"experiment1", "experiment2", "experiment3"}}},
+	})
+	var heldOut, inLayer int
+	for i := 0; i < 10000; i++ {
+		hashKey := fmt.Sprintf("user%d", i)
+
+		// The holdout group is the same for each experiment sharing a percentage:
+		assignment1 := assigner.Assign(hashKey, "experiment1")
+		assert.Equal(t, assignment1.Holdout, assigner.Assign(hashKey, "experiment6").Holdout)
+		assert.False(t, assigner.Assign(hashKey, "experiment4").Holdout)
+		assert.True(t, assigner.Assign(hashKey, "experiment5").Holdout)
+		if assignment1.Holdout {
+			heldOut++
+		}
+
+		// Each context is in exactly one of the experiments in a layer:
+		inExperiments := 0
+		for _, experiment := range []string{"experiment1", "experiment2", "experiment3"} {
+			assignment := assigner.Assign(hashKey, experiment)
+			assert.Equal(t, "checkout", assignment.Layer)
+			if !assignment.Excluded {
+				inExperiments++
+			}
+		}
+		assert.Equal(t, 1, inExperiments)
+		if !assignment1.Excluded {
+			inLayer++
+		}
+
+		// Assignments are consistent:
+		assert.Equal(t, assignment1, assigner.Assign(hashKey, "experiment1"))
+	}
+	assert.InDelta(t, 1000, heldOut, 150)
+	assert.InDelta(t, 3333, inLayer, 250)
+
+	// Exposures are only logged once (per hash key, experiment and variant):
+	assigner = NewAssigner(Config{})
+	assert.True(t, assigner.Expose("user1", "experiment1", "blue"))
+	assert.False(t, assigner.Expose("user1", "experiment1", "blue"))
+	assert.True(t, assigner.Expose("user2", "experiment1", "blue"))
+	assert.True(t, assigner.Expose("user1", "experiment2", "blue"))
+	assert.True(t, assigner.Expose("user1", "experiment1", "green"))
+
+	// We only remember so many exposures (forgetting the least recently seen first):
+	assigner = NewAssigner(Config{MaxExposures: 2})
+	for _, hashKey := range []string{"user1", "user2", "user3"} {
+		assert.True(t, assigner.Expose(hashKey, "experiment1", "blue"))
+	}
+	assert.False(t, assigner.Expose("user2", "experiment1", "blue"))
+	assert.False(t, assigner.Expose("user3", "experiment1", "blue"))
+	assert.True(t, assigner.Expose("user1", "experiment1", "blue"))
+	assert.False(t, assigner.Expose("user3", "experiment1", "blue"))
+	assert.True(t, assigner.Expose("user2", "experiment1", "blue"))
+}
@@ -0,0 +1,280 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/logging"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
+	streamingclient "github.com/featurehub-io/featurehub-go-sdk/pkg/streaming-client"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	customContextPrefix = "custom."
+	formatJSON          = "json"
+)
+
+// DebugClient is what the DebugHandler needs from a client (interfaces.Client and its mocks provide it):
+// - clients which can also report their analytics collectors or notifier counts (like a StreamingClient) will have them shown
+type DebugClient interface {
+	Features() map[string]*models.FeatureState
+	Status() models.ClientStatus
+}
+
+// analyticsCollectorLister is implemented by clients which can list the types of their analytics collectors:
+type analyticsCollectorLister interface {
+	AnalyticsCollectorTypes() []string
+}
+
+// notifierCounter is implemented by clients which can count their notifiers:
+type notifierCounter interface {
+	NotifierCounts() map[string]int
+}
+
+// previewer is implemented by clients which can evaluate features for a context (honouring any assignments) without logging impressions:
+type previewer interface {
+	WithContext(clientContext *models.Context) *streamingclient.ClientWithContext
+}
+
+// DebugHandler renders what a client currently believes about its features (mount it somewhere like "/debug/featurehub"):
+// - responds with JSON if the request has "?format=json" or accepts "application/json", otherwise HTML
+// - context attributes can be provided as query parameters (userkey, session, device, platform, country, version and custom.{name})
+type DebugHandler struct {
+	client DebugClient
+	logger logging.Logger
+}
+
+// DebugEvaluation is how a feature evaluated against the context in the request:
+type DebugEvaluation struct {
+	StrategyID string      `json:"strategyId,omitempty"` // ID of the matched strategy (empty if the default value applied)
+	Value      interface{} `json:"value"`                // The value which applies to the context
+	Version    int64       `json:"version,omitempty"`    // Version of the feature which was evaluated
+}
+
+// DebugState is everything the DebugHandler knows about a client:
+type DebugState struct {
+	AnalyticsCollectors []string                        `json:"analyticsCollectors"`
+	Context             *models.Context                 `json:"context"`
+	Evaluated           map[string]*DebugEvaluation     `json:"evaluated"`
+	Features            map[string]*models.FeatureState `json:"features"`
+	Keys                []string                        `json:"keys"`
+	Notifiers           map[string]int                  `json:"notifiers"`
+	Status              models.ClientStatus             `json:"status"`
+}
+
+// NewDebugHandler returns a DebugHandler for the given client:
+func NewDebugHandler(client DebugClient) *DebugHandler {
+	return &DebugHandler{
+		client: client,
+		logger: logging.NewLogrusLogger(logrus.StandardLogger()),
+	}
+}
+
+// WithLogger sets the logger which reports problems rendering the debug page:
+func (h *DebugHandler) WithLogger(logger logging.Logger) *DebugHandler {
+	h.logger = logger
+	return h
+}
+
+// ServeHTTP renders the current state of the client:
+// - the response is rendered to a buffer first, so a problem rendering it results in a 500 (rather than half a page)
+func (h *DebugHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	// Gather the current state:
+	state := h.state(r.URL.Query())
+
+	// Respond with JSON if that's what was asked for, otherwise render HTML:
+	var body bytes.Buffer
+	var err error
+	contentType := "text/html; charset=utf-8"
+	if wantsJSON(r) {
+		contentType = "application/json"
+		encoder := json.NewEncoder(&body)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(state)
+	} else {
+		err = debugTemplate.Execute(&body, state)
+	}
+
+	if err != nil {
+		h.logger.WithError(err).Error("Unable to render the FeatureHub debug page")
+		http.Error(w, "unable to render the FeatureHub debug page", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Write(body.Bytes())
+}
+
+// state gathers everything we know about the client, and evaluates all features against the context in the query:
+func (h *DebugHandler) state(query url.Values) *DebugState {
+	clientContext := contextFromQuery(query)
+
+	// Take one snapshot of the features, and list the keys from that (so they always agree):
+	features := h.client.Features()
+	keys := make([]string, 0, len(features))
+	for key := range features {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	state := &DebugState{
+		Context:   clientContext,
+		Evaluated: h.evaluate(clientContext, features),
+		Features:  features,
+		Keys:      keys,
+		Status:    h.client.Status(),
+	}
+
+	// Some clients can tell us more:
+	if lister, ok := h.client.(analyticsCollectorLister); ok {
+		state.AnalyticsCollectors = lister.AnalyticsCollectorTypes()
+	}
+	if counter, ok := h.client.(notifierCounter); ok {
+		state.Notifiers = counter.NotifierCounts()
+	}
+
+	return state
+}
+
+// evaluate previews the value of every feature for the context (so looking at them doesn't count as an impression):
+func (h *DebugHandler) evaluate(clientContext *models.Context, features map[string]*models.FeatureState) map[string]*DebugEvaluation {
+	var evaluatedFeatures models.EvaluatedFeatures
+	if p, ok := h.client.(previewer); ok {
+		evaluatedFeatures = p.WithContext(clientContext).PreviewAll()
+	} else {
+		evaluatedFeatures = make(models.EvaluatedFeatures, len(features))
+		for key, fs := range features {
+			evaluatedFeatures[key] = fs.EvaluateWithLogger(clientContext, h.logger)
+		}
+	}
+
+	evaluations := make(map[string]*DebugEvaluation, len(evaluatedFeatures))
+	for key, evaluatedFeature := range evaluatedFeatures {
+		evaluations[key] = &DebugEvaluation{
+			StrategyID: evaluatedFeature.StrategyID,
+			Value:      evaluatedFeature.Value,
+			Version:    evaluatedFeature.Version,
+		}
+	}
+	return evaluations
+}
+
+// contextFromQuery builds a client context from query parameters:
+// - custom attribute values of "true" or "false" become booleans, numeric values become numbers, everything else is a string
+func contextFromQuery(query url.Values) *models.Context {
+	clientContext := &models.Context{
+		Userkey:  query.Get("userkey"),
+		Session:  query.Get("session"),
+		Device:   models.ContextDevice(query.Get("device")),
+		Platform: models.ContextPlatform(query.Get("platform")),
+		Country:  models.ContextCountry(query.Get("country")),
+		Version:  query.Get("version"),
+		Custom:   make(map[string]interface{}),
+	}
+
+	for name := range query {
+		if !strings.HasPrefix(name, customContextPrefix) {
+			continue
+		}
+		value := query.Get(name)
+		customName := strings.TrimPrefix(name, customContextPrefix)
+
+		switch {
+		case value == "true" || value == "false":
+			clientContext.Custom[customName] = value == "true"
+		default:
+			if numberValue, err := strconv.ParseFloat(value, 64); err == nil {
+				clientContext.Custom[customName] = numberValue
+			} else {
+				clientContext.Custom[customName] = value
+			}
+		}
+	}
+
+	return clientContext
+}
+
+// wantsJSON decides whether to respond with JSON:
+func wantsJSON(r *http.Request) bool {
+	if r.URL.Query().Get("format") == formatJSON {
+		return true
+	}
+	return strings.Contains(r.Header.Get("Accept"), "application/json")
+}
+
+// sortedCustomNames lists the names of custom context attributes (so the template renders them in a stable order):
+func sortedCustomNames(custom map[string]interface{}) []string {
+	names := make([]string, 0, len(custom))
+	for name := range custom {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+var debugTemplate = template.Must(template.New("debug").Funcs(template.FuncMap{"customNames": sortedCustomNames}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<title>FeatureHub</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { border: 1px solid #ccc; padding: 0.25em 0.5em; text-align: left; vertical-align: top; }
+</style>
+</head>
+<body>
+<h1>FeatureHub</h1>
+
+<h2>Status</h2>
+<table>
+<tr><th>Environment</th><td>{{.Status.EnvironmentID}}</td></tr>
+<tr><th>Running</th><td>{{.Status.Running}}</td></tr>
+<tr><th>Connected</th><td>{{.Status.Connected}}</td></tr>
+<tr><th>Stale</th><td>{{.Status.Stale}}</td></tr>
+<tr><th>Readiness</th><td>{{.Status.Readiness}}</td></tr>
+<tr><th>Server</th><td>{{.Status.ServerAddress}}</td></tr>
+<tr><th>Has data</th><td>{{.Status.HasData}}</td></tr>
+<tr><th>Last event</th><td>{{.Status.LastEventAt}}{{with .Status.LastEventID}} ({{.}}){{end}}</td></tr>
+<tr><th>Features</th><td>{{.Status.FeatureCount}}</td></tr>
+<tr><th>Analytics collectors</th><td>{{range .AnalyticsCollectors}}{{.}}<br>{{else}}none{{end}}</td></tr>
+</table>
+
+<h2>Features</h2>
+<table>
+<tr><th>Key</th><th>Type</th><th>Version</th><th>Default value</th><th>Strategies</th><th>Notifiers</th><th>Evaluated value</th><th>Matched strategy</th></tr>
+{{range $key := .Keys}}{{$feature := index $.Features $key}}{{$evaluated := index $.Evaluated $key}}<tr>
+<td>{{$key}}</td>
+<td>{{$feature.Type}}</td>
+<td>{{$feature.Version}}</td>
+<td>{{$feature.Value}}</td>
+<td>{{len $feature.Strategies}}</td>
+<td>{{index $.Notifiers $key}}</td>
+<td>{{if $evaluated}}{{$evaluated.Value}}{{end}}</td>
+<td>{{if $evaluated}}{{$evaluated.StrategyID}}{{end}}</td>
+</tr>
+{{end}}</table>
+
+<h2>Evaluate</h2>
+<form method="get">
+<table>
+<tr><th>Userkey</th><td><input name="userkey" value="{{.Context.Userkey}}"></td></tr>
+<tr><th>Session</th><td><input name="session" value="{{.Context.Session}}"></td></tr>
+<tr><th>Device</th><td><input name="device" value="{{.Context.Device}}"></td></tr>
+<tr><th>Platform</th><td><input name="platform" value="{{.Context.Platform}}"></td></tr>
+<tr><th>Country</th><td><input name="country" value="{{.Context.Country}}"></td></tr>
+<tr><th>Version</th><td><input name="version" value="{{.Context.Version}}"></td></tr>
+{{range $name := customNames .Context.Custom}}<tr><th>custom.{{$name}}</th><td><input name="custom.{{$name}}" value="{{index $.Context.Custom $name}}"></td></tr>
+{{end}}</table>
+<input type="submit" value="Evaluate"> <a href="?format=json">JSON</a>
+</form>
+</body>
+</html>
+`))
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/analytics"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/logging"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/mocks"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
+	streamingclient "github.com/featurehub-io/featurehub-go-sdk/pkg/streaming-client"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+const testFeaturesJSON = `[{"id":"1","key":"booleanfeature","type":"BOOLEAN","value":true,"version":2,"strategies":[{"id":"s1","name":"linux","value":false,"attributes":[{"id":"a1","conditional":"EQUALS","fieldName":"platform","values":["linux"],"type":"STRING"}]}]},{"id":"2","key":"stringfeature","type":"STRING","value":"this is a string","version":1}]`
+
+// newTestServer returns a fake FeatureHub server which streams the given features to anyone who connects:
+func newTestServer(featuresJSON string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, "event: features\ndata: %s\n\n", featuresJSON)
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+}
+
+// newTestClient returns a started StreamingClient connected to the given fake server:
+func newTestClient(t *testing.T, server *httptest.Server) *streamingclient.StreamingClient {
+	config := streamingclient.NewConfig(server.URL, "default/environment-id/my-secret-api-key").WithLogLevel(logrus.ErrorLevel).WithWaitForData(true)
+	client, err := streamingclient.NewStreamingClient(config)
+	assert.NoError(t, err)
+	client.Start()
+	return client
+}
+
+func TestDebugHandler(t *testing.T) {
+
+	// Connect a client to a fake server:
+	server := newTestServer(testFeaturesJSON)
+	defer server.Close()
+	defer server.CloseClientConnections()
+	client := newTestClient(t, server)
+	client.AddNotifierBoolean("booleanfeature", func(bool) {})
+	client.AddNotifierBoolean("booleanfeature", func(bool) {})
+	client.AddAnalyticsCollector(analytics.NewLoggingAnalyticsCollector(logrus.New()))
+	client.AddAnalyticsCollector(analytics.NewSanitisedCollector(new(mocks.FakeAnalyticsCollector), analytics.SanitiserPolicy{}))
+	fakeImpressionCollector := new(mocks.FakeImpressionCollector)
+	client.AddImpressionCollector(fakeImpressionCollector)
+	debugHandler := NewDebugHandler(client)
+
+	// Ask for JSON (with a context which matches the strategy):
+	recorder := httptest.NewRecorder()
+	debugHandler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/debug/featurehub?format=json&platform=linux&custom.beta=true&custom.age=42&custom.name=prawn", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+
+	// Check what we got back:
+	state := &DebugState{}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), state))
+	assert.True(t, state.Status.Connected)
+	assert.True(t, state.Status.HasData)
+	assert.Equal(t, "environment-id", state.Status.EnvironmentID)
+	assert.Equal(t, []string{"booleanfeature", "stringfeature"}, state.Keys)
+	assert.Equal(t, int64(2), state.Features["booleanfeature"].Version)
+	assert.Len(t, state.Features["booleanfeature"].Strategies, 1)
+	assert.Equal(t, 2, state.Notifiers["booleanfeature"])
+	assert.Equal(t, []string{"*analytics.LoggingAnalyticsCollector", "sanitised(*mocks.FakeAnalyticsCollector)"}, state.AnalyticsCollectors)
+	assert.Equal(t, models.ContextPlatformLinux, state.Context.Platform)
+	assert.Equal(t, true, state.Context.Custom["beta"])
+	assert.Equal(t, float64(42), state.Context.Custom["age"])
+	assert.Equal(t, "prawn", state.Context.Custom["name"])
+	assert.Equal(t, false, state.Evaluated["booleanfeature"].Value)
+	assert.Equal(t, "s1", state.Evaluated["booleanfeature"].StrategyID)
+	assert.Equal(t, int64(2), state.Evaluated["booleanfeature"].Version)
+	assert.Equal(t, "this is a string", state.Evaluated["stringfeature"].Value)
+	assert.Empty(t, state.Evaluated["stringfeature"].StrategyID)
+
+	// Looking at the values doesn't count as an impression:
+	assert.NoError(t, client.FlushAnalytics(context.Background()))
+	assert.Equal(t, 0, fakeImpressionCollector.LogImpressionsCallCount())
+
+	// The Accept header also gets us JSON:
+	recorder = httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, "/debug/featurehub", nil)
+	request.Header.Set("Accept", "application/json")
+	debugHandler.ServeHTTP(recorder, request)
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+
+	// Otherwise we get HTML:
+	recorder = httptest.NewRecorder()
+	debugHandler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/debug/featurehub?platform=linux", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Header().Get("Content-Type"), "text/html")
+	assert.Contains(t, recorder.Body.String(), "<td>booleanfeature</td>")
+	assert.Contains(t, recorder.Body.String(), "<td>environment-id</td>")
+	assert.Contains(t, recorder.Body.String(), `<input name="platform" value="linux">`)
+	assert.Contains(t, recorder.Body.String(), "<td>s1</td>")
+}
+
+func TestDebugHandlerWithFakeClient(t *testing.T) {
+
+	// Any client will do:
+	fakeClient := new(mocks.FakeClient)
+	fakeClient.FeaturesReturns(map[string]*models.FeatureState{
+		"booleanfeature": {ID: "1", Key: "booleanfeature", Type: models.TypeBoolean, Value: true, Version: 3},
+	})
+	fakeClient.StatusReturns(models.ClientStatus{EnvironmentID: "environment-id", HasData: true})
+	debugHandler := NewDebugHandler(fakeClient).WithLogger(logging.NoopLogger{})
+
+	// Ask for JSON:
+	recorder := httptest.NewRecorder()
+	debugHandler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/debug/featurehub?format=json", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	// Check what we got back (the keys come from the same snapshot as the features):
+	state := &DebugState{}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), state))
+	assert.Equal(t, "environment-id", state.Status.EnvironmentID)
+	assert.Equal(t, []string{"booleanfeature"}, state.Keys)
+	assert.Equal(t, true, state.Evaluated["booleanfeature"].Value)
+	assert.Equal(t, int64(3), state.Evaluated["booleanfeature"].Version)
+	assert.Empty(t, state.AnalyticsCollectors)
+	assert.Empty(t, state.Notifiers)
+	assert.Equal(t, 1, fakeClient.FeaturesCallCount())
+
+	// A feature which can't be rendered gets us a 500 (rather than half a response):
+	fakeClient.FeaturesReturns(map[string]*models.FeatureState{
+		"brokenfeature": {ID: "2", Key: "brokenfeature", Type: models.TypeJSON, Value: make(chan int)},
+	})
+	recorder = httptest.NewRecorder()
+	debugHandler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/debug/featurehub?format=json", nil))
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	assert.NotContains(t, recorder.Body.String(), "brokenfeature")
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/interfaces"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
+)
+
+const defaultConnectedWithin = time.Minute
+
+// HealthCheck is the result of one of the checks made by a health handler:
+type HealthCheck struct {
+	Healthy bool   `json:"healthy"`
+	Message string `json:"message,omitempty"`
+	Name    string `json:"name"`
+}
+
+// HealthState is what the health handlers respond with (along with a 200 if healthy, or a 503 if not):
+type HealthState struct {
+	Checks  []HealthCheck       `json:"checks"`
+	Healthy bool                `json:"healthy"`
+	Status  models.ClientStatus `json:"status"`
+}
+
+// LivenessHandler reports whether a client is still handling events from the server (mount it somewhere like "/healthz"):
+// - a client which hasn't been started (or has been closed, eg because the server asked us to go away) isn't live
+type LivenessHandler struct {
+	client interfaces.Client
+}
+
+// NewLivenessHandler returns a LivenessHandler for the given client:
+func NewLivenessHandler(client interfaces.Client) *LivenessHandler {
+	return &LivenessHandler{
+		client: client,
+	}
+}
+
+// ServeHTTP reports whether the client is live:
+func (h *LivenessHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	status := h.client.Status()
+
+	writeHealthState(w, status, []HealthCheck{
+		newHealthCheck("running", status.Running, "Not handling events from the FeatureHub server"),
+	})
+}
+
+// ReadinessHandler reports whether a client is ready to serve features (mount it somewhere like "/readyz"):
+// - the client has received data (and hasn't failed)
+// - the client isn't stale (see Config.StaleAfter)
+// - the client is connected, or heard from the server recently (within a minute by default, see WithConnectedWithin)
+type ReadinessHandler struct {
+	client          interfaces.Client
+	connectedWithin time.Duration
+}
+
+// NewReadinessHandler returns a ReadinessHandler for the given client:
+func NewReadinessHandler(client interfaces.Client) *ReadinessHandler {
+	return &ReadinessHandler{
+		client:          client,
+		connectedWithin: defaultConnectedWithin,
+	}
+}
+
+// WithConnectedWithin sets how long a client can go without hearing from the server while disconnected (and still be ready):
+func (h *ReadinessHandler) WithConnectedWithin(connectedWithin time.Duration) *ReadinessHandler {
+	h.connectedWithin = connectedWithin
+	return h
+}
+
+// ServeHTTP reports whether the client is ready:
+func (h *ReadinessHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	status := h.client.Status()
+
+	// Allow for a client which has only just lost its connection (it will try to reconnect by itself):
+	connected := status.Connected || (!status.LastEventAt.IsZero() && time.Since(status.LastEventAt) <= h.connectedWithin)
+
+	writeHealthState(w, status, []HealthCheck{
+		newHealthCheck("hasData", status.HasData && status.Readiness != models.ReadinessFailed, fmt.Sprintf("No data from the FeatureHub server (%s)", status.Readiness)),
+		newHealthCheck("notStale", !status.Stale, "Connection to the FeatureHub server is stale"),
+		newHealthCheck("connected", connected, fmt.Sprintf("Not connected to the FeatureHub server for over %s", h.connectedWithin)),
+	})
+}
+
+// newHealthCheck returns a HealthCheck (only including the message if the check failed):
+func newHealthCheck(name string, healthy bool, message string) HealthCheck {
+	healthCheck := HealthCheck{
+		Healthy: healthy,
+		Name:    name,
+	}
+	if !healthy {
+		healthCheck.Message = message
+	}
+	return healthCheck
+}
+
+// writeHealthState responds with the result of some health checks (as JSON):
+func writeHealthState(w http.ResponseWriter, status models.ClientStatus, checks []HealthCheck) {
+	state := &HealthState{
+		Checks:  checks,
+		Healthy: true,
+		Status:  status,
+	}
+	for _, check := range checks {
+		state.Healthy = state.Healthy && check.Healthy
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Type", "application/json")
+	if state.Healthy {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(state)
+}
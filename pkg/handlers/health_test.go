@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/mocks"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// serveHealth makes a request to a health handler, and decodes the response:
+func serveHealth(t *testing.T, handler http.Handler) (int, *HealthState) {
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+	assert.Equal(t, "no-store", recorder.Header().Get("Cache-Control"))
+
+	state := &HealthState{}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), state))
+	return recorder.Code, state
+}
+
+func TestHealthHandlers(t *testing.T) {
+
+	// Connect a client to a fake server:
+	server := newTestServer(testFeaturesJSON)
+	defer server.Close()
+	defer server.CloseClientConnections()
+	client := newTestClient(t, server)
+	livenessHandler := NewLivenessHandler(client)
+	readinessHandler := NewReadinessHandler(client)
+
+	// A client which is streaming features is live and ready:
+	code, state := serveHealth(t, livenessHandler)
+	assert.Equal(t, http.StatusOK, code)
+	assert.True(t, state.Healthy)
+	assert.Equal(t, []HealthCheck{{Healthy: true, Name: "running"}}, state.Checks)
+	assert.True(t, state.Status.Running)
+
+	code, state = serveHealth(t, readinessHandler)
+	assert.Equal(t, http.StatusOK, code)
+	assert.True(t, state.Healthy)
+	assert.Len(t, state.Checks, 3)
+	assert.Equal(t, models.ReadinessReady, state.Status.Readiness)
+	assert.Equal(t, 2, state.Status.FeatureCount)
+
+	// Once closed, the client keeps serving features (so is still ready for a while), but is no longer live:
+	client.Close()
+	code, state = serveHealth(t, livenessHandler)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.False(t, state.Healthy)
+	assert.Equal(t, []HealthCheck{{Message: "Not handling events from the FeatureHub server", Name: "running"}}, state.Checks)
+
+	code, _ = serveHealth(t, readinessHandler)
+	assert.Equal(t, http.StatusOK, code)
+	code, state = serveHealth(t, readinessHandler.WithConnectedWithin(0))
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, HealthCheck{Message: "Not connected to the FeatureHub server for over 0s", Name: "connected"}, state.Checks[2])
+
+	// A client which hasn't received any data isn't ready:
+	fakeClient := new(mocks.FakeClient)
+	fakeClient.StatusReturns(models.ClientStatus{Connected: true, Readiness: models.ReadinessNotReady, Running: true})
+	code, state = serveHealth(t, NewReadinessHandler(fakeClient))
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, []HealthCheck{
+		{Message: "No data from the FeatureHub server (not_ready)", Name: "hasData"},
+		{Healthy: true, Name: "notStale"},
+		{Healthy: true, Name: "connected"},
+	}, state.Checks)
+
+	// Neither is a client which failed:
+	fakeClient.StatusReturns(models.ClientStatus{HasData: true, LastEventAt: time.Now(), Readiness: models.ReadinessFailed})
+	code, state = serveHealth(t, NewReadinessHandler(fakeClient))
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "No data from the FeatureHub server (failed)", state.Checks[0].Message)
+
+	// Or one which is stale:
+	fakeClient.StatusReturns(models.ClientStatus{Connected: true, HasData: true, Readiness: models.ReadinessStale, Stale: true})
+	code, state = serveHealth(t, NewReadinessHandler(fakeClient))
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, HealthCheck{Message: "Connection to the FeatureHub server is stale", Name: "notStale"}, state.Checks[1])
+
+	// A client which has only just lost its connection is still ready (but not once it has been gone for too long):
+	fakeClient.StatusReturns(models.ClientStatus{HasData: true, LastEventAt: time.Now().Add(-time.Minute / 2), Readiness: models.ReadinessReady})
+	code, _ = serveHealth(t, NewReadinessHandler(fakeClient))
+	assert.Equal(t, http.StatusOK, code)
+	code, state = serveHealth(t, NewReadinessHandler(fakeClient).WithConnectedWithin(10*time.Second))
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "Not connected to the FeatureHub server for over 10s", state.Checks[2].Message)
+}
@@ -8,3 +8,17 @@ import (
 type AnalyticsCollector interface {
 	LogEvent(action string, other map[string]string, featureStateAtCurrentTime map[string]*models.FeatureState) error
 }
+
+// BatchAnalyticsCollector is an AnalyticsCollector which can also submit a batch of events at once (the analytics pipeline prefers this):
+// - if only some of the events were submitted, LogEvents returns the ones which weren't (so only those are retried)
+// - an error without any failed events means that none of them were submitted
+type BatchAnalyticsCollector interface {
+	AnalyticsCollector
+	LogEvents(events []*models.AnalyticsEvent) (failed []*models.AnalyticsEvent, err error)
+}
+
+// ContextAnalyticsCollector is an AnalyticsCollector which also hears about the context each event was logged with, and the values that context experienced (the analytics pipeline prefers this to LogEvent):
+type ContextAnalyticsCollector interface {
+	AnalyticsCollector
+	LogContextEvent(event *models.AnalyticsEvent) error
+}
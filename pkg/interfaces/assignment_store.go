@@ -0,0 +1,12 @@
+package interfaces
+
+import (
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
+)
+
+// AssignmentStore remembers which percentage strategy each userkey was assigned for a feature (so changing percentages doesn't move users between variants):
+type AssignmentStore interface {
+	Get(userkey, featureKey string) (*models.Assignment, error) // Returns nil (without an error) if the userkey hasn't been assigned
+	Reset(featureKey string) error                              // Forgets every assignment for a feature (so userkeys are bucketed afresh)
+	Set(assignment *models.Assignment) error
+}
@@ -1,24 +1,39 @@
 package interfaces
 
 import (
+	"context"
+
 	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
 )
 
 // Client for FeatureHub:
 type Client interface {
 	AddAnalyticsCollector(newAnalyticsCollector AnalyticsCollector)                                      // Configure a new analytics collector, add it to the list:
+	AddImpressionCollector(newImpressionCollector ImpressionCollector)                                   // Configure a new impression collector (which hears about features being evaluated for a context)
+	AddNotifierAllFeatures(callbackFunc models.CallbackFuncFeature) (notifierUUID string)                // Configure a notifier for every feature (including deletions)
 	AddNotifierBoolean(featureKey string, callbackFunc models.CallbackFuncBoolean) (notifierUUID string) // Configure a notifier for a BOOLEAN value:
 	AddNotifierFeature(featureKey string, callbackFunc models.CallbackFuncFeature) (notifierUUID string) // Configure a notifier for a generic feature:
 	AddNotifierJSON(featureKey string, callbackFunc models.CallbackFuncJSON) (notifierUUID string)       // Configure a notifier for a JSON value:
 	AddNotifierNumber(featureKey string, callbackFunc models.CallbackFuncNumber) (notifierUUID string)   // Configure a notifier for a NUMBER value:
 	AddNotifierString(featureKey string, callbackFunc models.CallbackFuncString) (notifierUUID string)   // Configure a notifier for a STRING value:
+	Close()                                                                                              // Stop handling events and disconnect from the FeatureHub server (existing data will continue to be served)
 	DeleteNotifier(featureKey, notifierUUID string) error                                                // Remove a previously configured notifier (by key and UUID, because we support more than one notifier per key)
+	FailedReadinessListener(callbackFunc func(err error))                                                // Configure the SDK with a function to call if the client fails before it is ready
+	Features() map[string]*models.FeatureState                                                           // Retrieve a snapshot of all features (by key)
+	FlushAnalytics(ctx context.Context) error                                                            // Block until every analytics event logged so far has been submitted (or the context is done)
 	GetBoolean(featureKey string) (bool, error)                                                          // Retrieve a value (by key) for a BOOLEAN feature
 	GetFeature(featureKey string) (*models.FeatureState, error)                                          // Retrieve a feature (by key) (value is an interface{})
 	GetNumber(featureKey string) (float64, error)                                                        // Retrieve a value (by key) for a NUMBER feature
 	GetRawJSON(featureKey string) (string, error)                                                        // Retrieve a value (by key) for a JSON feature
 	GetString(featureKey string) (string, error)                                                         // Retrieve a value (by key) for a STRING feature
-	LogAnalyticsEvent(action string, other map[string]string)                                            // Send an analytics event (non-blocking, fire and forget)
+	Keys() []string                                                                                      // Retrieve the keys of all features (sorted)
+	LogAnalyticsEvent(action string, other map[string]string)                                            // Queue an analytics event (non-blocking, submitted in the background)
 	LogAnalyticsEventSync(action string, other map[string]string) error                                  // Send an analytics event, but wait for it to complete
+	Readiness() models.Readiness                                                                         // Retrieve the readiness state of the client (not_ready, ready, failed or stale)
 	ReadinessListener(callbackFunc func())                                                               // Configure the SDK with a function to call when we're ready (up and running with some data)
+	Ready() <-chan struct{}                                                                              // Retrieve a channel which is closed once the client has received data for the first time
+	Status() models.ClientStatus                                                                         // Retrieve the current status of the client (connection, data etc)
+	Stale() bool                                                                                         // Whether we've gone too long without hearing from the FeatureHub server
+	StaleListener(callbackFunc func(stale bool))                                                         // Configure the SDK with a function to call when we become stale (or stop being stale)
+	WaitUntilReady(ctx context.Context) error                                                            // Block until the client has received data for the first time (or fails, or the context is done)
 }
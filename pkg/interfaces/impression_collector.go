@@ -0,0 +1,10 @@
+package interfaces
+
+import (
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
+)
+
+// ImpressionCollector receives impressions (batches of them) whenever features are evaluated for a context:
+type ImpressionCollector interface {
+	LogImpressions(impressions []*models.Impression) error
+}
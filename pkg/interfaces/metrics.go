@@ -0,0 +1,18 @@
+package interfaces
+
+import (
+	"time"
+
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
+)
+
+// Metrics allows the SDK to report what it is doing:
+type Metrics interface {
+	AnalyticsCollectorFailure(collectorType string)                             // An analytics collector returned an error
+	AnalyticsEventDropped(collectorType string)                                 // An analytics event was dropped before it reached a collector (eg the queue was full)
+	Evaluation(featureKey, strategyID string, outcome models.EvaluationOutcome) // A feature was evaluated (strategyID is empty unless a strategy matched)
+	NotifierLatency(featureKey string, latency time.Duration)                   // A notifier callback took this long to run
+	ParseError(event string)                                                    // An SSE event payload couldn't be parsed
+	Reconnect()                                                                 // The SSE connection was interrupted (and will be re-established)
+	SSEEvent(event string)                                                      // An SSE event was received
+}
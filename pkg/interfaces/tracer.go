@@ -0,0 +1,13 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
+)
+
+// Tracer allows the SDK to trace feature evaluations and the lifecycle of its connection:
+type Tracer interface {
+	Connection(event models.ConnectionEvent, err error)                                                                             // The SSE connection changed state (err is only provided for failures)
+	Evaluation(ctx context.Context, featureKey string, evaluatedFeature *models.EvaluatedFeature, outcome models.EvaluationOutcome) // A feature was evaluated (evaluatedFeature is nil if the evaluation failed)
+}
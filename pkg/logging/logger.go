@@ -0,0 +1,28 @@
+package logging
+
+// Logger is what the SDK logs with (a subset of the logrus API, so it is easy to adapt to other logging libraries):
+type Logger interface {
+	WithError(err error) Logger
+	WithField(key string, value interface{}) Logger
+	WithFields(fields map[string]interface{}) Logger
+	Trace(args ...interface{})
+	Tracef(format string, args ...interface{})
+	Debug(args ...interface{})
+	Debugf(format string, args ...interface{})
+	Info(args ...interface{})
+	Infof(format string, args ...interface{})
+	Warn(args ...interface{})
+	Warnf(format string, args ...interface{})
+	Error(args ...interface{})
+	Errorf(format string, args ...interface{})
+	Fatal(args ...interface{})
+	Fatalf(format string, args ...interface{})
+}
+
+// OrNoop returns the given logger, or a NoopLogger if it is nil:
+func OrNoop(logger Logger) Logger {
+	if logger == nil {
+		return NoopLogger{}
+	}
+	return logger
+}
@@ -0,0 +1,74 @@
+package logging
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoggers(t *testing.T) {
+
+	// Make a logrus logger:
+	logrusLogger := logrus.New()
+	logrusLogger.SetLevel(logrus.DebugLevel)
+	logrusBuffer := new(bytes.Buffer)
+	logrusLogger.SetOutput(logrusBuffer)
+
+	// Make a slog logger:
+	slogBuffer := new(bytes.Buffer)
+	slogLogger := slog.New(slog.NewTextHandler(slogBuffer, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	for _, logger := range []Logger{NewLogrusLogger(logrusLogger), NewSlogLogger(slogLogger), NoopLogger{}} {
+
+		// Log some things:
+		logger.WithField("key", "feature1").WithFields(map[string]interface{}{"uuid": "123"}).Debugf("Triggered a notifier (%d)", 1)
+		logger.WithError(fmt.Errorf("connection reset by peer")).Warn("Error from API client")
+		logger.Trace("This is below the level")
+	}
+
+	// Check what went to logrus:
+	assert.Contains(t, logrusBuffer.String(), `msg="Triggered a notifier (1)" key=feature1 uuid=123`)
+	assert.Contains(t, logrusBuffer.String(), `level=warning msg="Error from API client" error="connection reset by peer"`)
+	assert.NotContains(t, logrusBuffer.String(), "This is below the level")
+
+	// Check what went to slog:
+	assert.Contains(t, slogBuffer.String(), `level=DEBUG msg="Triggered a notifier (1)" key=feature1 uuid=123`)
+	assert.Contains(t, slogBuffer.String(), `level=WARN msg="Error from API client" error="connection reset by peer"`)
+	assert.NotContains(t, slogBuffer.String(), "This is below the level")
+
+	// A nil logger is a NoopLogger:
+	assert.Equal(t, NoopLogger{}, OrNoop(nil))
+}
+
+func TestRedactingLogger(t *testing.T) {
+
+	// Make a logrus logger:
+	logrusLogger := logrus.New()
+	logBuffer := new(bytes.Buffer)
+	logrusLogger.SetOutput(logBuffer)
+	logrusLogger.SetLevel(logrus.DebugLevel)
+
+	// Wrap it:
+	logger := NewRedactingLogger(NewLogrusLogger(logrusLogger), "default/environment-id/my-secret-api-key", "my-secret-api-key", "")
+
+	// Log the secrets in every way we can:
+	logger.Info("Subscribing to http://localhost/features/default/environment-id/my-secret-api-key")
+	logger.Infof("Subscribing with %s (%q)", "my-secret-api-key", fmt.Errorf("bad key my-secret-api-key"))
+	logger.WithField("url", "http://localhost/features/default/environment-id/my-secret-api-key").Info("Field")
+	logger.WithFields(map[string]interface{}{"key": []string{"my-secret-api-key"}, "count": 3}).Info("Fields")
+	logger.WithError(fmt.Errorf("Get http://localhost/features/default/environment-id/my-secret-api-key: refused")).Error("Error")
+	logger.Debugf("Formatting still works: %05.1f %v", 3.14159, true)
+
+	// Check that they have all been redacted:
+	assert.NotContains(t, logBuffer.String(), "my-secret-api-key")
+	assert.Contains(t, logBuffer.String(), "http://localhost/features/[REDACTED]")
+	assert.Contains(t, logBuffer.String(), `Subscribing with [REDACTED] (\"bad key [REDACTED]\")`)
+	assert.Contains(t, logBuffer.String(), "url=\"http://localhost/features/[REDACTED]\"")
+	assert.Contains(t, logBuffer.String(), "count=3 key=\"[[REDACTED]]\"")
+	assert.Contains(t, logBuffer.String(), "error=\"Get http://localhost/features/[REDACTED]: refused\"")
+	assert.Contains(t, logBuffer.String(), "Formatting still works: 003.1 true")
+}
@@ -0,0 +1,92 @@
+package logging
+
+import (
+	"github.com/sirupsen/logrus"
+)
+
+// LogrusLogger adapts logrus to the Logger interface:
+type LogrusLogger struct {
+	entry *logrus.Entry
+}
+
+// NewLogrusLogger returns a Logger which logs to the given logrus logger:
+func NewLogrusLogger(logger *logrus.Logger) *LogrusLogger {
+	return &LogrusLogger{
+		entry: logrus.NewEntry(logger),
+	}
+}
+
+// WithError adds an error field:
+func (l *LogrusLogger) WithError(err error) Logger {
+	return &LogrusLogger{entry: l.entry.WithError(err)}
+}
+
+// WithField adds a field:
+func (l *LogrusLogger) WithField(key string, value interface{}) Logger {
+	return &LogrusLogger{entry: l.entry.WithField(key, value)}
+}
+
+// WithFields adds several fields:
+func (l *LogrusLogger) WithFields(fields map[string]interface{}) Logger {
+	return &LogrusLogger{entry: l.entry.WithFields(fields)}
+}
+
+// Trace logs a trace message:
+func (l *LogrusLogger) Trace(args ...interface{}) {
+	l.entry.Trace(args...)
+}
+
+// Tracef logs a formatted trace message:
+func (l *LogrusLogger) Tracef(format string, args ...interface{}) {
+	l.entry.Tracef(format, args...)
+}
+
+// Debug logs a debug message:
+func (l *LogrusLogger) Debug(args ...interface{}) {
+	l.entry.Debug(args...)
+}
+
+// Debugf logs a formatted debug message:
+func (l *LogrusLogger) Debugf(format string, args ...interface{}) {
+	l.entry.Debugf(format, args...)
+}
+
+// Info logs an info message:
+func (l *LogrusLogger) Info(args ...interface{}) {
+	l.entry.Info(args...)
+}
+
+// Infof logs a formatted info message:
+func (l *LogrusLogger) Infof(format string, args ...interface{}) {
+	l.entry.Infof(format, args...)
+}
+
+// Warn logs a warning message:
+func (l *LogrusLogger) Warn(args ...interface{}) {
+	l.entry.Warn(args...)
+}
+
+// Warnf logs a formatted warning message:
+func (l *LogrusLogger) Warnf(format string, args ...interface{}) {
+	l.entry.Warnf(format, args...)
+}
+
+// Error logs an error message:
+func (l *LogrusLogger) Error(args ...interface{}) {
+	l.entry.Error(args...)
+}
+
+// Errorf logs a formatted error message:
+func (l *LogrusLogger) Errorf(format string, args ...interface{}) {
+	l.entry.Errorf(format, args...)
+}
+
+// Fatal logs a fatal message, then exits:
+func (l *LogrusLogger) Fatal(args ...interface{}) {
+	l.entry.Fatal(args...)
+}
+
+// Fatalf logs a formatted fatal message, then exits:
+func (l *LogrusLogger) Fatalf(format string, args ...interface{}) {
+	l.entry.Fatalf(format, args...)
+}
@@ -0,0 +1,49 @@
+package logging
+
+// NoopLogger implements the Logger interface, but doesn't log anything:
+type NoopLogger struct{}
+
+// WithError does nothing:
+func (l NoopLogger) WithError(err error) Logger { return l }
+
+// WithField does nothing:
+func (l NoopLogger) WithField(key string, value interface{}) Logger { return l }
+
+// WithFields does nothing:
+func (l NoopLogger) WithFields(fields map[string]interface{}) Logger { return l }
+
+// Trace does nothing:
+func (l NoopLogger) Trace(args ...interface{}) {}
+
+// Tracef does nothing:
+func (l NoopLogger) Tracef(format string, args ...interface{}) {}
+
+// Debug does nothing:
+func (l NoopLogger) Debug(args ...interface{}) {}
+
+// Debugf does nothing:
+func (l NoopLogger) Debugf(format string, args ...interface{}) {}
+
+// Info does nothing:
+func (l NoopLogger) Info(args ...interface{}) {}
+
+// Infof does nothing:
+func (l NoopLogger) Infof(format string, args ...interface{}) {}
+
+// Warn does nothing:
+func (l NoopLogger) Warn(args ...interface{}) {}
+
+// Warnf does nothing:
+func (l NoopLogger) Warnf(format string, args ...interface{}) {}
+
+// Error does nothing:
+func (l NoopLogger) Error(args ...interface{}) {}
+
+// Errorf does nothing:
+func (l NoopLogger) Errorf(format string, args ...interface{}) {}
+
+// Fatal does nothing:
+func (l NoopLogger) Fatal(args ...interface{}) {}
+
+// Fatalf does nothing:
+func (l NoopLogger) Fatalf(format string, args ...interface{}) {}
@@ -0,0 +1,189 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Redacted is what secrets are replaced with:
+const Redacted = "[REDACTED]"
+
+// RedactingLogger wraps another Logger, replacing secrets (eg the SDK key) wherever they appear in messages, fields and errors:
+type RedactingLogger struct {
+	logger   Logger
+	replacer *strings.Replacer
+}
+
+// NewRedactingLogger returns a Logger which redacts the given secrets before passing anything on to the given Logger:
+func NewRedactingLogger(logger Logger, secrets ...string) *RedactingLogger {
+
+	// Longer secrets go first (so a secret which contains another is replaced as a whole):
+	sortedSecrets := make([]string, 0, len(secrets))
+	for _, secret := range secrets {
+		if len(secret) > 0 {
+			sortedSecrets = append(sortedSecrets, secret)
+		}
+	}
+	sort.Slice(sortedSecrets, func(i, j int) bool { return len(sortedSecrets[i]) > len(sortedSecrets[j]) })
+
+	// Make a replacer:
+	oldNew := make([]string, 0, len(sortedSecrets)*2)
+	for _, secret := range sortedSecrets {
+		oldNew = append(oldNew, secret, Redacted)
+	}
+
+	return &RedactingLogger{
+		logger:   OrNoop(logger),
+		replacer: strings.NewReplacer(oldNew...),
+	}
+}
+
+// WithError adds an error field (with any secrets redacted from its message):
+func (l *RedactingLogger) WithError(err error) Logger {
+	return &RedactingLogger{logger: l.logger.WithError(l.redactError(err)), replacer: l.replacer}
+}
+
+// WithField adds a field (with any secrets redacted from its value):
+func (l *RedactingLogger) WithField(key string, value interface{}) Logger {
+	return &RedactingLogger{logger: l.logger.WithField(key, l.redactValue(value)), replacer: l.replacer}
+}
+
+// WithFields adds several fields (with any secrets redacted from their values):
+func (l *RedactingLogger) WithFields(fields map[string]interface{}) Logger {
+	redactedFields := make(map[string]interface{}, len(fields))
+	for key, value := range fields {
+		redactedFields[key] = l.redactValue(value)
+	}
+	return &RedactingLogger{logger: l.logger.WithFields(redactedFields), replacer: l.replacer}
+}
+
+// Trace logs a trace message:
+func (l *RedactingLogger) Trace(args ...interface{}) {
+	l.logger.Trace(l.redactArgs(args)...)
+}
+
+// Tracef logs a formatted trace message:
+func (l *RedactingLogger) Tracef(format string, args ...interface{}) {
+	l.logger.Tracef(l.replacer.Replace(format), l.redactArgs(args)...)
+}
+
+// Debug logs a debug message:
+func (l *RedactingLogger) Debug(args ...interface{}) {
+	l.logger.Debug(l.redactArgs(args)...)
+}
+
+// Debugf logs a formatted debug message:
+func (l *RedactingLogger) Debugf(format string, args ...interface{}) {
+	l.logger.Debugf(l.replacer.Replace(format), l.redactArgs(args)...)
+}
+
+// Info logs an info message:
+func (l *RedactingLogger) Info(args ...interface{}) {
+	l.logger.Info(l.redactArgs(args)...)
+}
+
+// Infof logs a formatted info message:
+func (l *RedactingLogger) Infof(format string, args ...interface{}) {
+	l.logger.Infof(l.replacer.Replace(format), l.redactArgs(args)...)
+}
+
+// Warn logs a warning message:
+func (l *RedactingLogger) Warn(args ...interface{}) {
+	l.logger.Warn(l.redactArgs(args)...)
+}
+
+// Warnf logs a formatted warning message:
+func (l *RedactingLogger) Warnf(format string, args ...interface{}) {
+	l.logger.Warnf(l.replacer.Replace(format), l.redactArgs(args)...)
+}
+
+// Error logs an error message:
+func (l *RedactingLogger) Error(args ...interface{}) {
+	l.logger.Error(l.redactArgs(args)...)
+}
+
+// Errorf logs a formatted error message:
+func (l *RedactingLogger) Errorf(format string, args ...interface{}) {
+	l.logger.Errorf(l.replacer.Replace(format), l.redactArgs(args)...)
+}
+
+// Fatal logs a fatal message, then exits:
+func (l *RedactingLogger) Fatal(args ...interface{}) {
+	l.logger.Fatal(l.redactArgs(args)...)
+}
+
+// Fatalf logs a formatted fatal message, then exits:
+func (l *RedactingLogger) Fatalf(format string, args ...interface{}) {
+	l.logger.Fatalf(l.replacer.Replace(format), l.redactArgs(args)...)
+}
+
+// redactArgs redacts message arguments:
+// - strings (and types based on them) are redacted straight away
+// - everything else is wrapped so that it is only redacted if the message actually gets formatted (trace and debug messages usually don't)
+func (l *RedactingLogger) redactArgs(args []interface{}) []interface{} {
+	redactedArgs := make([]interface{}, len(args))
+	for i, arg := range args {
+		if arg != nil && reflect.TypeOf(arg).Kind() == reflect.String {
+			redactedArgs[i] = l.replacer.Replace(reflect.ValueOf(arg).String())
+			continue
+		}
+		redactedArgs[i] = &redactingFormatter{replacer: l.replacer, value: arg}
+	}
+	return redactedArgs
+}
+
+// redactError returns an error with any secrets redacted from its message:
+func (l *RedactingLogger) redactError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if message := err.Error(); l.replacer.Replace(message) != message {
+		return errors.New(l.replacer.Replace(message))
+	}
+	return err
+}
+
+// redactValue redacts a field value (values which don't contain any secrets keep their type):
+func (l *RedactingLogger) redactValue(value interface{}) interface{} {
+	switch typedValue := value.(type) {
+	case string:
+		return l.replacer.Replace(typedValue)
+	case error:
+		return l.redactError(typedValue)
+	case nil, bool, int, int64, float64:
+		return value
+	default:
+		if formatted := fmt.Sprint(value); l.replacer.Replace(formatted) != formatted {
+			return l.replacer.Replace(formatted)
+		}
+		return value
+	}
+}
+
+// redactingFormatter formats its value in the usual way, then redacts the result:
+type redactingFormatter struct {
+	replacer *strings.Replacer
+	value    interface{}
+}
+
+// Format implements fmt.Formatter (re-using the verb, flags, width and precision we were given):
+func (f *redactingFormatter) Format(state fmt.State, verb rune) {
+	format := "%"
+	for _, flag := range "+-# 0" {
+		if state.Flag(int(flag)) {
+			format += string(flag)
+		}
+	}
+	if width, ok := state.Width(); ok {
+		format += fmt.Sprintf("%d", width)
+	}
+	if precision, ok := state.Precision(); ok {
+		format += fmt.Sprintf(".%d", precision)
+	}
+	format += string(verb)
+
+	fmt.Fprint(state, f.replacer.Replace(fmt.Sprintf(format, f.value)))
+}
@@ -0,0 +1,123 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+// LevelTrace is the slog level used for trace messages (slog doesn't have one of its own):
+const LevelTrace = slog.LevelDebug - 4
+
+// SlogLogger adapts log/slog to the Logger interface:
+type SlogLogger struct {
+	logger *slog.Logger
+}
+
+// NewSlogLogger returns a Logger which logs to the given slog logger (or the default one if nil):
+func NewSlogLogger(logger *slog.Logger) *SlogLogger {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &SlogLogger{
+		logger: logger,
+	}
+}
+
+// WithError adds an error attribute:
+func (l *SlogLogger) WithError(err error) Logger {
+	return &SlogLogger{logger: l.logger.With("error", err)}
+}
+
+// WithField adds an attribute:
+func (l *SlogLogger) WithField(key string, value interface{}) Logger {
+	return &SlogLogger{logger: l.logger.With(key, value)}
+}
+
+// WithFields adds several attributes:
+func (l *SlogLogger) WithFields(fields map[string]interface{}) Logger {
+	args := make([]interface{}, 0, len(fields)*2)
+	for key, value := range fields {
+		args = append(args, key, value)
+	}
+	return &SlogLogger{logger: l.logger.With(args...)}
+}
+
+// Trace logs a trace message:
+func (l *SlogLogger) Trace(args ...interface{}) {
+	l.log(LevelTrace, args...)
+}
+
+// Tracef logs a formatted trace message:
+func (l *SlogLogger) Tracef(format string, args ...interface{}) {
+	l.logf(LevelTrace, format, args...)
+}
+
+// Debug logs a debug message:
+func (l *SlogLogger) Debug(args ...interface{}) {
+	l.log(slog.LevelDebug, args...)
+}
+
+// Debugf logs a formatted debug message:
+func (l *SlogLogger) Debugf(format string, args ...interface{}) {
+	l.logf(slog.LevelDebug, format, args...)
+}
+
+// Info logs an info message:
+func (l *SlogLogger) Info(args ...interface{}) {
+	l.log(slog.LevelInfo, args...)
+}
+
+// Infof logs a formatted info message:
+func (l *SlogLogger) Infof(format string, args ...interface{}) {
+	l.logf(slog.LevelInfo, format, args...)
+}
+
+// Warn logs a warning message:
+func (l *SlogLogger) Warn(args ...interface{}) {
+	l.log(slog.LevelWarn, args...)
+}
+
+// Warnf logs a formatted warning message:
+func (l *SlogLogger) Warnf(format string, args ...interface{}) {
+	l.logf(slog.LevelWarn, format, args...)
+}
+
+// Error logs an error message:
+func (l *SlogLogger) Error(args ...interface{}) {
+	l.log(slog.LevelError, args...)
+}
+
+// Errorf logs a formatted error message:
+func (l *SlogLogger) Errorf(format string, args ...interface{}) {
+	l.logf(slog.LevelError, format, args...)
+}
+
+// Fatal logs at error level, then exits (like logrus does):
+func (l *SlogLogger) Fatal(args ...interface{}) {
+	l.log(slog.LevelError, args...)
+	os.Exit(1)
+}
+
+// Fatalf logs at error level, then exits (like logrus does):
+func (l *SlogLogger) Fatalf(format string, args ...interface{}) {
+	l.logf(slog.LevelError, format, args...)
+	os.Exit(1)
+}
+
+// log formats and logs a message (unless the level isn't enabled):
+func (l *SlogLogger) log(level slog.Level, args ...interface{}) {
+	if !l.logger.Enabled(context.Background(), level) {
+		return
+	}
+	l.logger.Log(context.Background(), level, fmt.Sprint(args...))
+}
+
+// logf formats and logs a message (unless the level isn't enabled):
+func (l *SlogLogger) logf(level slog.Level, format string, args ...interface{}) {
+	if !l.logger.Enabled(context.Background(), level) {
+		return
+	}
+	l.logger.Log(context.Background(), level, fmt.Sprintf(format, args...))
+}
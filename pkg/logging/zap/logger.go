@@ -0,0 +1,97 @@
+package zap
+
+import (
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/logging"
+	uberzap "go.uber.org/zap"
+)
+
+// Logger adapts zap to the logging.Logger interface (zap doesn't have a trace level, so trace messages are logged at debug):
+type Logger struct {
+	logger *uberzap.SugaredLogger
+}
+
+// NewLogger returns a logging.Logger which logs to the given zap logger:
+func NewLogger(logger *uberzap.Logger) *Logger {
+	return &Logger{
+		logger: logger.Sugar(),
+	}
+}
+
+// WithError adds an error field:
+func (l *Logger) WithError(err error) logging.Logger {
+	return &Logger{logger: l.logger.With(uberzap.Error(err))}
+}
+
+// WithField adds a field:
+func (l *Logger) WithField(key string, value interface{}) logging.Logger {
+	return &Logger{logger: l.logger.With(key, value)}
+}
+
+// WithFields adds several fields:
+func (l *Logger) WithFields(fields map[string]interface{}) logging.Logger {
+	args := make([]interface{}, 0, len(fields)*2)
+	for key, value := range fields {
+		args = append(args, key, value)
+	}
+	return &Logger{logger: l.logger.With(args...)}
+}
+
+// Trace logs a trace message (at debug level):
+func (l *Logger) Trace(args ...interface{}) {
+	l.logger.Debug(args...)
+}
+
+// Tracef logs a formatted trace message (at debug level):
+func (l *Logger) Tracef(format string, args ...interface{}) {
+	l.logger.Debugf(format, args...)
+}
+
+// Debug logs a debug message:
+func (l *Logger) Debug(args ...interface{}) {
+	l.logger.Debug(args...)
+}
+
+// Debugf logs a formatted debug message:
+func (l *Logger) Debugf(format string, args ...interface{}) {
+	l.logger.Debugf(format, args...)
+}
+
+// Info logs an info message:
+func (l *Logger) Info(args ...interface{}) {
+	l.logger.Info(args...)
+}
+
+// Infof logs a formatted info message:
+func (l *Logger) Infof(format string, args ...interface{}) {
+	l.logger.Infof(format, args...)
+}
+
+// Warn logs a warning message:
+func (l *Logger) Warn(args ...interface{}) {
+	l.logger.Warn(args...)
+}
+
+// Warnf logs a formatted warning message:
+func (l *Logger) Warnf(format string, args ...interface{}) {
+	l.logger.Warnf(format, args...)
+}
+
+// Error logs an error message:
+func (l *Logger) Error(args ...interface{}) {
+	l.logger.Error(args...)
+}
+
+// Errorf logs a formatted error message:
+func (l *Logger) Errorf(format string, args ...interface{}) {
+	l.logger.Errorf(format, args...)
+}
+
+// Fatal logs a fatal message, then exits:
+func (l *Logger) Fatal(args ...interface{}) {
+	l.logger.Fatal(args...)
+}
+
+// Fatalf logs a formatted fatal message, then exits:
+func (l *Logger) Fatalf(format string, args ...interface{}) {
+	l.logger.Fatalf(format, args...)
+}
@@ -0,0 +1,34 @@
+package zap
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/logging"
+	"github.com/stretchr/testify/assert"
+	uberzap "go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLogger(t *testing.T) {
+
+	// Make a zap logger which records everything it logs:
+	core, observedLogs := observer.New(zapcore.DebugLevel)
+	logger := NewLogger(uberzap.New(core))
+	assert.Implements(t, new(logging.Logger), logger)
+
+	// Log some things:
+	logger.WithField("key", "feature1").WithFields(map[string]interface{}{"uuid": "123"}).Debugf("Triggered a notifier (%d)", 1)
+	logger.WithError(fmt.Errorf("connection reset by peer")).Warn("Error from API client")
+	logger.Trace("Trace messages are logged at debug")
+
+	// Check what was logged:
+	entries := observedLogs.AllUntimed()
+	assert.Len(t, entries, 3)
+	assert.Equal(t, "Triggered a notifier (1)", entries[0].Message)
+	assert.Equal(t, map[string]interface{}{"key": "feature1", "uuid": "123"}, entries[0].ContextMap())
+	assert.Equal(t, zapcore.WarnLevel, entries[1].Level)
+	assert.Equal(t, "connection reset by peer", entries[1].ContextMap()["error"])
+	assert.Equal(t, zapcore.DebugLevel, entries[2].Level)
+}
@@ -0,0 +1,114 @@
+package metrics
+
+import (
+	"expvar"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
+)
+
+const defaultExpvarName = "featurehub"
+
+// expvarMutex makes sure that we only publish each expvar once:
+var expvarMutex sync.Mutex
+
+// ExpvarMetrics implements the Metrics interface by publishing counters with the expvar package (served at /debug/vars):
+type ExpvarMetrics struct {
+	analyticsCollectorFailures *expvar.Map
+	analyticsEventsDropped     *expvar.Map
+	evaluations                *expvar.Map
+	lastEventAt                *expvar.Int
+	notifierCalls              *expvar.Map
+	notifierLatencySeconds     *expvar.Map
+	parseErrors                *expvar.Map
+	reconnects                 *expvar.Int
+	sseEvents                  *expvar.Map
+}
+
+// NewExpvarMetrics returns an ExpvarMetrics published under the given name (defaults to "featurehub"):
+// - expvar names are global, so calling this again with the same name shares the same counters
+func NewExpvarMetrics(name string) *ExpvarMetrics {
+	if len(name) == 0 {
+		name = defaultExpvarName
+	}
+
+	expvarMutex.Lock()
+	defer expvarMutex.Unlock()
+
+	// Re-use a previously published map, otherwise publish a new one:
+	root, ok := expvar.Get(name).(*expvar.Map)
+	if !ok {
+		root = expvar.NewMap(name)
+	}
+
+	return &ExpvarMetrics{
+		analyticsCollectorFailures: expvarMap(root, "analytics_collector_failures"),
+		analyticsEventsDropped:     expvarMap(root, "analytics_events_dropped"),
+		evaluations:                expvarMap(root, "evaluations"),
+		lastEventAt:                expvarInt(root, "last_event_timestamp_seconds"),
+		notifierCalls:              expvarMap(root, "notifier_calls"),
+		notifierLatencySeconds:     expvarMap(root, "notifier_latency_seconds"),
+		parseErrors:                expvarMap(root, "parse_errors"),
+		reconnects:                 expvarInt(root, "reconnects"),
+		sseEvents:                  expvarMap(root, "sse_events"),
+	}
+}
+
+// AnalyticsCollectorFailure counts analytics collector failures (by collector type):
+func (m *ExpvarMetrics) AnalyticsCollectorFailure(collectorType string) {
+	m.analyticsCollectorFailures.Add(collectorType, 1)
+}
+
+// AnalyticsEventDropped counts analytics events which were dropped (by collector type):
+func (m *ExpvarMetrics) AnalyticsEventDropped(collectorType string) {
+	m.analyticsEventsDropped.Add(collectorType, 1)
+}
+
+// Evaluation counts feature evaluations (by "key/strategy/outcome"):
+func (m *ExpvarMetrics) Evaluation(featureKey, strategyID string, outcome models.EvaluationOutcome) {
+	m.evaluations.Add(fmt.Sprintf("%s/%s/%s", featureKey, strategyID, outcome), 1)
+}
+
+// NotifierLatency counts notifier calls, and sums the time they took (by feature key):
+func (m *ExpvarMetrics) NotifierLatency(featureKey string, latency time.Duration) {
+	m.notifierCalls.Add(featureKey, 1)
+	m.notifierLatencySeconds.AddFloat(featureKey, latency.Seconds())
+}
+
+// ParseError counts SSE payloads which couldn't be parsed (by event type):
+func (m *ExpvarMetrics) ParseError(event string) {
+	m.parseErrors.Add(event, 1)
+}
+
+// Reconnect counts SSE connection interruptions:
+func (m *ExpvarMetrics) Reconnect() {
+	m.reconnects.Add(1)
+}
+
+// SSEEvent counts SSE events (by event type), and records when we last received one:
+func (m *ExpvarMetrics) SSEEvent(event string) {
+	m.sseEvents.Add(event, 1)
+	m.lastEventAt.Set(time.Now().Unix())
+}
+
+// expvarMap returns the named map within the given map (creating it if necessary):
+func expvarMap(root *expvar.Map, name string) *expvar.Map {
+	if existing, ok := root.Get(name).(*expvar.Map); ok {
+		return existing
+	}
+	newMap := new(expvar.Map).Init()
+	root.Set(name, newMap)
+	return newMap
+}
+
+// expvarInt returns the named int within the given map (creating it if necessary):
+func expvarInt(root *expvar.Map, name string) *expvar.Int {
+	if existing, ok := root.Get(name).(*expvar.Int); ok {
+		return existing
+	}
+	newInt := new(expvar.Int)
+	root.Set(name, newInt)
+	return newInt
+}
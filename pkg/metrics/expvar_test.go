@@ -0,0 +1,44 @@
+package metrics
+
+import (
+	"expvar"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/interfaces"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpvarMetrics(t *testing.T) {
+
+	// Make a new ExpvarMetrics (expvar is global, so each run needs its own name):
+	name := fmt.Sprintf("featurehub_test_%d", time.Now().UnixNano())
+	expvarMetrics := NewExpvarMetrics(name)
+	assert.Implements(t, new(interfaces.Metrics), expvarMetrics)
+
+	// Record some things:
+	expvarMetrics.AnalyticsCollectorFailure("*analytics.GoogleAnalyticsCollector")
+	expvarMetrics.AnalyticsEventDropped("*analytics.GoogleAnalyticsCollector")
+	expvarMetrics.Evaluation("feature1", "s1", models.EvaluationOutcomeStrategy)
+	expvarMetrics.NotifierLatency("feature1", 500*time.Millisecond)
+	expvarMetrics.ParseError("features")
+	expvarMetrics.Reconnect()
+	expvarMetrics.SSEEvent("features")
+
+	// Making another one with the same name shares the same counters (rather than panicking):
+	NewExpvarMetrics(name).Reconnect()
+
+	// Check what was published:
+	published := expvar.Get(name).(*expvar.Map)
+	assert.Equal(t, "1", published.Get("analytics_collector_failures").(*expvar.Map).Get("*analytics.GoogleAnalyticsCollector").String())
+	assert.Equal(t, "1", published.Get("analytics_events_dropped").(*expvar.Map).Get("*analytics.GoogleAnalyticsCollector").String())
+	assert.Equal(t, "1", published.Get("evaluations").(*expvar.Map).Get("feature1/s1/strategy").String())
+	assert.Equal(t, "1", published.Get("notifier_calls").(*expvar.Map).Get("feature1").String())
+	assert.Equal(t, "0.5", published.Get("notifier_latency_seconds").(*expvar.Map).Get("feature1").String())
+	assert.Equal(t, "1", published.Get("parse_errors").(*expvar.Map).Get("features").String())
+	assert.Equal(t, "2", published.Get("reconnects").String())
+	assert.Equal(t, "1", published.Get("sse_events").(*expvar.Map).Get("features").String())
+	assert.NotEqual(t, "0", published.Get("last_event_timestamp_seconds").String())
+}
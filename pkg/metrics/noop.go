@@ -0,0 +1,31 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
+)
+
+// NoopMetrics implements the Metrics interface, but doesn't record anything:
+type NoopMetrics struct{}
+
+// AnalyticsCollectorFailure does nothing:
+func (m NoopMetrics) AnalyticsCollectorFailure(collectorType string) {}
+
+// AnalyticsEventDropped does nothing:
+func (m NoopMetrics) AnalyticsEventDropped(collectorType string) {}
+
+// Evaluation does nothing:
+func (m NoopMetrics) Evaluation(featureKey, strategyID string, outcome models.EvaluationOutcome) {}
+
+// NotifierLatency does nothing:
+func (m NoopMetrics) NotifierLatency(featureKey string, latency time.Duration) {}
+
+// ParseError does nothing:
+func (m NoopMetrics) ParseError(event string) {}
+
+// Reconnect does nothing:
+func (m NoopMetrics) Reconnect() {}
+
+// SSEEvent does nothing:
+func (m NoopMetrics) SSEEvent(event string) {}
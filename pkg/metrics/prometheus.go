@@ -0,0 +1,255 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
+)
+
+const prometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// defaultLatencyBuckets are the upper bounds (in seconds) of the notifier latency histogram:
+var defaultLatencyBuckets = []float64{0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5}
+
+// PrometheusMetrics implements the Metrics interface, and renders everything in the Prometheus text format:
+// - it doesn't need the Prometheus client library (or a Prometheus server), just mount it as an http.Handler (eg "/metrics")
+type PrometheusMetrics struct {
+	analyticsCollectorFailures *counterVec
+	analyticsEventsDropped     *counterVec
+	evaluations                *counterVec
+	lastEventAt                time.Time
+	mutex                      sync.Mutex
+	notifierLatency            *histogramVec
+	parseErrors                *counterVec
+	reconnects                 float64
+	sseEvents                  *counterVec
+}
+
+// NewPrometheusMetrics returns a new PrometheusMetrics:
+func NewPrometheusMetrics() *PrometheusMetrics {
+	return &PrometheusMetrics{
+		analyticsCollectorFailures: newCounterVec("collector"),
+		analyticsEventsDropped:     newCounterVec("collector"),
+		evaluations:                newCounterVec("key", "strategy", "outcome"),
+		notifierLatency:            newHistogramVec(defaultLatencyBuckets, "key"),
+		parseErrors:                newCounterVec("event"),
+		sseEvents:                  newCounterVec("event"),
+	}
+}
+
+// AnalyticsCollectorFailure counts analytics collector failures (by collector type):
+func (m *PrometheusMetrics) AnalyticsCollectorFailure(collectorType string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.analyticsCollectorFailures.inc(collectorType)
+}
+
+// AnalyticsEventDropped counts analytics events which were dropped (by collector type):
+func (m *PrometheusMetrics) AnalyticsEventDropped(collectorType string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.analyticsEventsDropped.inc(collectorType)
+}
+
+// Evaluation counts feature evaluations (by key, strategy and outcome):
+func (m *PrometheusMetrics) Evaluation(featureKey, strategyID string, outcome models.EvaluationOutcome) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.evaluations.inc(featureKey, strategyID, string(outcome))
+}
+
+// NotifierLatency observes how long notifier callbacks take (by feature key):
+func (m *PrometheusMetrics) NotifierLatency(featureKey string, latency time.Duration) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.notifierLatency.observe(latency.Seconds(), featureKey)
+}
+
+// ParseError counts SSE payloads which couldn't be parsed (by event type):
+func (m *PrometheusMetrics) ParseError(event string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.parseErrors.inc(event)
+}
+
+// Reconnect counts SSE connection interruptions:
+func (m *PrometheusMetrics) Reconnect() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.reconnects++
+}
+
+// SSEEvent counts SSE events (by event type), and records when we last received one:
+func (m *PrometheusMetrics) SSEEvent(event string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.sseEvents.inc(event)
+	m.lastEventAt = time.Now()
+}
+
+// ServeHTTP renders the metrics for a Prometheus scrape:
+func (m *PrometheusMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", prometheusContentType)
+	m.WriteTo(w)
+}
+
+// WriteTo renders the metrics in the Prometheus text format:
+func (m *PrometheusMetrics) WriteTo(w io.Writer) (int64, error) {
+	m.mutex.Lock()
+	buffer := new(bytes.Buffer)
+
+	m.analyticsCollectorFailures.write(buffer, "featurehub_analytics_collector_failures_total", "Analytics collector errors (by collector type)")
+	m.analyticsEventsDropped.write(buffer, "featurehub_analytics_events_dropped_total", "Analytics events dropped before reaching a collector (by collector type)")
+	m.evaluations.write(buffer, "featurehub_evaluations_total", "Feature evaluations (by key, matched strategy and outcome)")
+	m.notifierLatency.write(buffer, "featurehub_notifier_latency_seconds", "How long notifier callbacks take to run (by feature key)")
+	m.parseErrors.write(buffer, "featurehub_sse_parse_errors_total", "SSE payloads which couldn't be parsed (by event type)")
+	m.sseEvents.write(buffer, "featurehub_sse_events_total", "SSE events received (by event type)")
+
+	fmt.Fprintf(buffer, "# HELP featurehub_sse_reconnects_total SSE connection interruptions\n# TYPE featurehub_sse_reconnects_total counter\nfeaturehub_sse_reconnects_total %s\n", formatFloat(m.reconnects))
+	if !m.lastEventAt.IsZero() {
+		fmt.Fprintf(buffer, "# HELP featurehub_sse_last_event_timestamp_seconds When the last SSE event was received\n# TYPE featurehub_sse_last_event_timestamp_seconds gauge\nfeaturehub_sse_last_event_timestamp_seconds %s\n", formatFloat(float64(m.lastEventAt.UnixNano())/1e9))
+	}
+
+	m.mutex.Unlock()
+	return buffer.WriteTo(w)
+}
+
+// counterVec is a set of counters, partitioned by label values:
+type counterVec struct {
+	labelNames []string
+	values     map[string]float64
+}
+
+func newCounterVec(labelNames ...string) *counterVec {
+	return &counterVec{
+		labelNames: labelNames,
+		values:     make(map[string]float64),
+	}
+}
+
+func (cv *counterVec) inc(labelValues ...string) {
+	cv.values[labelKey(labelValues)]++
+}
+
+func (cv *counterVec) write(w io.Writer, name, help string) {
+	if len(cv.values) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+	for _, key := range sortedKeys(cv.values) {
+		fmt.Fprintf(w, "%s%s %s\n", name, formatLabels(cv.labelNames, splitLabelKey(key)), formatFloat(cv.values[key]))
+	}
+}
+
+// histogramVec is a set of histograms, partitioned by label values:
+type histogramVec struct {
+	buckets    []float64
+	histograms map[string]*histogram
+	labelNames []string
+}
+
+type histogram struct {
+	bucketCounts []float64
+	count        float64
+	sum          float64
+}
+
+func newHistogramVec(buckets []float64, labelNames ...string) *histogramVec {
+	return &histogramVec{
+		buckets:    buckets,
+		histograms: make(map[string]*histogram),
+		labelNames: labelNames,
+	}
+}
+
+func (hv *histogramVec) observe(value float64, labelValues ...string) {
+	key := labelKey(labelValues)
+	h, ok := hv.histograms[key]
+	if !ok {
+		h = &histogram{bucketCounts: make([]float64, len(hv.buckets))}
+		hv.histograms[key] = h
+	}
+
+	for i, upperBound := range hv.buckets {
+		if value <= upperBound {
+			h.bucketCounts[i]++
+		}
+	}
+	h.count++
+	h.sum += value
+}
+
+func (hv *histogramVec) write(w io.Writer, name, help string) {
+	if len(hv.histograms) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", name, help, name)
+
+	keys := make([]string, 0, len(hv.histograms))
+	for key := range hv.histograms {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		h := hv.histograms[key]
+		labelValues := splitLabelKey(key)
+		bucketLabelNames := append(append([]string{}, hv.labelNames...), "le")
+		for i, upperBound := range hv.buckets {
+			fmt.Fprintf(w, "%s_bucket%s %s\n", name, formatLabels(bucketLabelNames, append(append([]string{}, labelValues...), formatFloat(upperBound))), formatFloat(h.bucketCounts[i]))
+		}
+		fmt.Fprintf(w, "%s_bucket%s %s\n", name, formatLabels(bucketLabelNames, append(append([]string{}, labelValues...), "+Inf")), formatFloat(h.count))
+		fmt.Fprintf(w, "%s_sum%s %s\n", name, formatLabels(hv.labelNames, labelValues), formatFloat(h.sum))
+		fmt.Fprintf(w, "%s_count%s %s\n", name, formatLabels(hv.labelNames, labelValues), formatFloat(h.count))
+	}
+}
+
+// labelSeparator can't appear in valid UTF-8, so it is safe to join label values with:
+const labelSeparator = "\xff"
+
+func labelKey(labelValues []string) string {
+	return strings.Join(labelValues, labelSeparator)
+}
+
+func splitLabelKey(key string) []string {
+	return strings.Split(key, labelSeparator)
+}
+
+func sortedKeys(values map[string]float64) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// formatLabels renders label pairs (eg `{event="features"}`), escaping the values:
+func formatLabels(labelNames, labelValues []string) string {
+	if len(labelNames) == 0 {
+		return ""
+	}
+	pairs := make([]string, len(labelNames))
+	for i, labelName := range labelNames {
+		pairs[i] = fmt.Sprintf(`%s="%s"`, labelName, labelValueReplacer.Replace(labelValues[i]))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatFloat(value float64) string {
+	if math.IsInf(value, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
@@ -0,0 +1,60 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/interfaces"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrometheusMetrics(t *testing.T) {
+
+	// Make a new PrometheusMetrics:
+	prometheusMetrics := NewPrometheusMetrics()
+	assert.Implements(t, new(interfaces.Metrics), prometheusMetrics)
+
+	// Record some things:
+	prometheusMetrics.AnalyticsCollectorFailure("*analytics.GoogleAnalyticsCollector")
+	prometheusMetrics.AnalyticsEventDropped("*analytics.GoogleAnalyticsCollector")
+	prometheusMetrics.Evaluation("feature1", "s1", models.EvaluationOutcomeStrategy)
+	prometheusMetrics.Evaluation("feature1", "s1", models.EvaluationOutcomeStrategy)
+	prometheusMetrics.Evaluation("feature1", "", models.EvaluationOutcomeDefault)
+	prometheusMetrics.Evaluation(`feature"2`, "", models.EvaluationOutcomeNotFound)
+	prometheusMetrics.NotifierLatency("feature1", 2*time.Millisecond)
+	prometheusMetrics.NotifierLatency("feature1", 2*time.Second)
+	prometheusMetrics.ParseError("features")
+	prometheusMetrics.Reconnect()
+	prometheusMetrics.Reconnect()
+	prometheusMetrics.SSEEvent("features")
+	prometheusMetrics.SSEEvent("ack")
+	prometheusMetrics.SSEEvent("ack")
+
+	// Scrape the metrics:
+	recorder := httptest.NewRecorder()
+	prometheusMetrics.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, prometheusContentType, recorder.Header().Get("Content-Type"))
+	body := recorder.Body.String()
+
+	// Check that everything was rendered:
+	assert.Contains(t, body, "# TYPE featurehub_analytics_collector_failures_total counter\n")
+	assert.Contains(t, body, `featurehub_analytics_collector_failures_total{collector="*analytics.GoogleAnalyticsCollector"} 1`+"\n")
+	assert.Contains(t, body, `featurehub_analytics_events_dropped_total{collector="*analytics.GoogleAnalyticsCollector"} 1`+"\n")
+	assert.Contains(t, body, `featurehub_evaluations_total{key="feature1",strategy="s1",outcome="strategy"} 2`+"\n")
+	assert.Contains(t, body, `featurehub_evaluations_total{key="feature1",strategy="",outcome="default"} 1`+"\n")
+	assert.Contains(t, body, `featurehub_evaluations_total{key="feature\"2",strategy="",outcome="not_found"} 1`+"\n")
+	assert.Contains(t, body, "# TYPE featurehub_notifier_latency_seconds histogram\n")
+	assert.Contains(t, body, `featurehub_notifier_latency_seconds_bucket{key="feature1",le="0.001"} 0`+"\n")
+	assert.Contains(t, body, `featurehub_notifier_latency_seconds_bucket{key="feature1",le="0.005"} 1`+"\n")
+	assert.Contains(t, body, `featurehub_notifier_latency_seconds_bucket{key="feature1",le="+Inf"} 2`+"\n")
+	assert.Contains(t, body, `featurehub_notifier_latency_seconds_sum{key="feature1"} 2.002`+"\n")
+	assert.Contains(t, body, `featurehub_notifier_latency_seconds_count{key="feature1"} 2`+"\n")
+	assert.Contains(t, body, `featurehub_sse_parse_errors_total{event="features"} 1`+"\n")
+	assert.Contains(t, body, "featurehub_sse_reconnects_total 2\n")
+	assert.Contains(t, body, `featurehub_sse_events_total{event="ack"} 2`+"\n")
+	assert.Contains(t, body, `featurehub_sse_events_total{event="features"} 1`+"\n")
+	assert.Contains(t, body, "featurehub_sse_last_event_timestamp_seconds ")
+}
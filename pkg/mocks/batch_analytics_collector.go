@@ -0,0 +1,200 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package mocks
+
+import (
+	"sync"
+
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/interfaces"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
+)
+
+type FakeBatchAnalyticsCollector struct {
+	LogEventStub        func(string, map[string]string, map[string]*models.FeatureState) error
+	logEventMutex       sync.RWMutex
+	logEventArgsForCall []struct {
+		arg1 string
+		arg2 map[string]string
+		arg3 map[string]*models.FeatureState
+	}
+	logEventReturns struct {
+		result1 error
+	}
+	logEventReturnsOnCall map[int]struct {
+		result1 error
+	}
+	LogEventsStub        func([]*models.AnalyticsEvent) ([]*models.AnalyticsEvent, error)
+	logEventsMutex       sync.RWMutex
+	logEventsArgsForCall []struct {
+		arg1 []*models.AnalyticsEvent
+	}
+	logEventsReturns struct {
+		result1 []*models.AnalyticsEvent
+		result2 error
+	}
+	logEventsReturnsOnCall map[int]struct {
+		result1 []*models.AnalyticsEvent
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeBatchAnalyticsCollector) LogEvent(arg1 string, arg2 map[string]string, arg3 map[string]*models.FeatureState) error {
+	fake.logEventMutex.Lock()
+	ret, specificReturn := fake.logEventReturnsOnCall[len(fake.logEventArgsForCall)]
+	fake.logEventArgsForCall = append(fake.logEventArgsForCall, struct {
+		arg1 string
+		arg2 map[string]string
+		arg3 map[string]*models.FeatureState
+	}{arg1, arg2, arg3})
+	stub := fake.LogEventStub
+	fakeReturns := fake.logEventReturns
+	fake.recordInvocation("LogEvent", []interface{}{arg1, arg2, arg3})
+	fake.logEventMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeBatchAnalyticsCollector) LogEventCallCount() int {
+	fake.logEventMutex.RLock()
+	defer fake.logEventMutex.RUnlock()
+	return len(fake.logEventArgsForCall)
+}
+
+func (fake *FakeBatchAnalyticsCollector) LogEventCalls(stub func(string, map[string]string, map[string]*models.FeatureState) error) {
+	fake.logEventMutex.Lock()
+	defer fake.logEventMutex.Unlock()
+	fake.LogEventStub = stub
+}
+
+func (fake *FakeBatchAnalyticsCollector) LogEventArgsForCall(i int) (string, map[string]string, map[string]*models.FeatureState) {
+	fake.logEventMutex.RLock()
+	defer fake.logEventMutex.RUnlock()
+	argsForCall := fake.logEventArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeBatchAnalyticsCollector) LogEventReturns(result1 error) {
+	fake.logEventMutex.Lock()
+	defer fake.logEventMutex.Unlock()
+	fake.LogEventStub = nil
+	fake.logEventReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeBatchAnalyticsCollector) LogEventReturnsOnCall(i int, result1 error) {
+	fake.logEventMutex.Lock()
+	defer fake.logEventMutex.Unlock()
+	fake.LogEventStub = nil
+	if fake.logEventReturnsOnCall == nil {
+		fake.logEventReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.logEventReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeBatchAnalyticsCollector) LogEvents(arg1 []*models.AnalyticsEvent) ([]*models.AnalyticsEvent, error) {
+	var arg1Copy []*models.AnalyticsEvent
+	if arg1 != nil {
+		arg1Copy = make([]*models.AnalyticsEvent, len(arg1))
+		copy(arg1Copy, arg1)
+	}
+	fake.logEventsMutex.Lock()
+	ret, specificReturn := fake.logEventsReturnsOnCall[len(fake.logEventsArgsForCall)]
+	fake.logEventsArgsForCall = append(fake.logEventsArgsForCall, struct {
+		arg1 []*models.AnalyticsEvent
+	}{arg1Copy})
+	stub := fake.LogEventsStub
+	fakeReturns := fake.logEventsReturns
+	fake.recordInvocation("LogEvents", []interface{}{arg1Copy})
+	fake.logEventsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeBatchAnalyticsCollector) LogEventsCallCount() int {
+	fake.logEventsMutex.RLock()
+	defer fake.logEventsMutex.RUnlock()
+	return len(fake.logEventsArgsForCall)
+}
+
+func (fake *FakeBatchAnalyticsCollector) LogEventsCalls(stub func([]*models.AnalyticsEvent) ([]*models.AnalyticsEvent, error)) {
+	fake.logEventsMutex.Lock()
+	defer fake.logEventsMutex.Unlock()
+	fake.LogEventsStub = stub
+}
+
+func (fake *FakeBatchAnalyticsCollector) LogEventsArgsForCall(i int) []*models.AnalyticsEvent {
+	fake.logEventsMutex.RLock()
+	defer fake.logEventsMutex.RUnlock()
+	argsForCall := fake.logEventsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeBatchAnalyticsCollector) LogEventsReturns(result1 []*models.AnalyticsEvent, result2 error) {
+	fake.logEventsMutex.Lock()
+	defer fake.logEventsMutex.Unlock()
+	fake.LogEventsStub = nil
+	fake.logEventsReturns = struct {
+		result1 []*models.AnalyticsEvent
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeBatchAnalyticsCollector) LogEventsReturnsOnCall(i int, result1 []*models.AnalyticsEvent, result2 error) {
+	fake.logEventsMutex.Lock()
+	defer fake.logEventsMutex.Unlock()
+	fake.LogEventsStub = nil
+	if fake.logEventsReturnsOnCall == nil {
+		fake.logEventsReturnsOnCall = make(map[int]struct {
+			result1 []*models.AnalyticsEvent
+			result2 error
+		})
+	}
+	fake.logEventsReturnsOnCall[i] = struct {
+		result1 []*models.AnalyticsEvent
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeBatchAnalyticsCollector) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.logEventMutex.RLock()
+	defer fake.logEventMutex.RUnlock()
+	fake.logEventsMutex.RLock()
+	defer fake.logEventsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeBatchAnalyticsCollector) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ interfaces.BatchAnalyticsCollector = new(FakeBatchAnalyticsCollector)
@@ -2,6 +2,7 @@
 package mocks
 
 import (
+	"context"
 	"sync"
 
 	"github.com/featurehub-io/featurehub-go-sdk/pkg/interfaces"
@@ -14,6 +15,22 @@ type FakeClient struct {
 	addAnalyticsCollectorArgsForCall []struct {
 		arg1 interfaces.AnalyticsCollector
 	}
+	AddImpressionCollectorStub        func(interfaces.ImpressionCollector)
+	addImpressionCollectorMutex       sync.RWMutex
+	addImpressionCollectorArgsForCall []struct {
+		arg1 interfaces.ImpressionCollector
+	}
+	AddNotifierAllFeaturesStub        func(models.CallbackFuncFeature) string
+	addNotifierAllFeaturesMutex       sync.RWMutex
+	addNotifierAllFeaturesArgsForCall []struct {
+		arg1 models.CallbackFuncFeature
+	}
+	addNotifierAllFeaturesReturns struct {
+		result1 string
+	}
+	addNotifierAllFeaturesReturnsOnCall map[int]struct {
+		result1 string
+	}
 	AddNotifierBooleanStub        func(string, models.CallbackFuncBoolean) string
 	addNotifierBooleanMutex       sync.RWMutex
 	addNotifierBooleanArgsForCall []struct {
@@ -74,6 +91,10 @@ type FakeClient struct {
 	addNotifierStringReturnsOnCall map[int]struct {
 		result1 string
 	}
+	CloseStub        func()
+	closeMutex       sync.RWMutex
+	closeArgsForCall []struct {
+	}
 	DeleteNotifierStub        func(string, string) error
 	deleteNotifierMutex       sync.RWMutex
 	deleteNotifierArgsForCall []struct {
@@ -86,6 +107,32 @@ type FakeClient struct {
 	deleteNotifierReturnsOnCall map[int]struct {
 		result1 error
 	}
+	FailedReadinessListenerStub        func(func(err error))
+	failedReadinessListenerMutex       sync.RWMutex
+	failedReadinessListenerArgsForCall []struct {
+		arg1 func(err error)
+	}
+	FeaturesStub        func() map[string]*models.FeatureState
+	featuresMutex       sync.RWMutex
+	featuresArgsForCall []struct {
+	}
+	featuresReturns struct {
+		result1 map[string]*models.FeatureState
+	}
+	featuresReturnsOnCall map[int]struct {
+		result1 map[string]*models.FeatureState
+	}
+	FlushAnalyticsStub        func(context.Context) error
+	flushAnalyticsMutex       sync.RWMutex
+	flushAnalyticsArgsForCall []struct {
+		arg1 context.Context
+	}
+	flushAnalyticsReturns struct {
+		result1 error
+	}
+	flushAnalyticsReturnsOnCall map[int]struct {
+		result1 error
+	}
 	GetBooleanStub        func(string) (bool, error)
 	getBooleanMutex       sync.RWMutex
 	getBooleanArgsForCall []struct {
@@ -151,6 +198,16 @@ type FakeClient struct {
 		result1 string
 		result2 error
 	}
+	KeysStub        func() []string
+	keysMutex       sync.RWMutex
+	keysArgsForCall []struct {
+	}
+	keysReturns struct {
+		result1 []string
+	}
+	keysReturnsOnCall map[int]struct {
+		result1 []string
+	}
 	LogAnalyticsEventStub        func(string, map[string]string)
 	logAnalyticsEventMutex       sync.RWMutex
 	logAnalyticsEventArgsForCall []struct {
@@ -169,11 +226,67 @@ type FakeClient struct {
 	logAnalyticsEventSyncReturnsOnCall map[int]struct {
 		result1 error
 	}
+	ReadinessStub        func() models.Readiness
+	readinessMutex       sync.RWMutex
+	readinessArgsForCall []struct {
+	}
+	readinessReturns struct {
+		result1 models.Readiness
+	}
+	readinessReturnsOnCall map[int]struct {
+		result1 models.Readiness
+	}
 	ReadinessListenerStub        func(func())
 	readinessListenerMutex       sync.RWMutex
 	readinessListenerArgsForCall []struct {
 		arg1 func()
 	}
+	ReadyStub        func() <-chan struct{}
+	readyMutex       sync.RWMutex
+	readyArgsForCall []struct {
+	}
+	readyReturns struct {
+		result1 <-chan struct{}
+	}
+	readyReturnsOnCall map[int]struct {
+		result1 <-chan struct{}
+	}
+	StaleStub        func() bool
+	staleMutex       sync.RWMutex
+	staleArgsForCall []struct {
+	}
+	staleReturns struct {
+		result1 bool
+	}
+	staleReturnsOnCall map[int]struct {
+		result1 bool
+	}
+	StaleListenerStub        func(func(stale bool))
+	staleListenerMutex       sync.RWMutex
+	staleListenerArgsForCall []struct {
+		arg1 func(stale bool)
+	}
+	StatusStub        func() models.ClientStatus
+	statusMutex       sync.RWMutex
+	statusArgsForCall []struct {
+	}
+	statusReturns struct {
+		result1 models.ClientStatus
+	}
+	statusReturnsOnCall map[int]struct {
+		result1 models.ClientStatus
+	}
+	WaitUntilReadyStub        func(context.Context) error
+	waitUntilReadyMutex       sync.RWMutex
+	waitUntilReadyArgsForCall []struct {
+		arg1 context.Context
+	}
+	waitUntilReadyReturns struct {
+		result1 error
+	}
+	waitUntilReadyReturnsOnCall map[int]struct {
+		result1 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
@@ -210,6 +323,99 @@ func (fake *FakeClient) AddAnalyticsCollectorArgsForCall(i int) interfaces.Analy
 	return argsForCall.arg1
 }
 
+func (fake *FakeClient) AddImpressionCollector(arg1 interfaces.ImpressionCollector) {
+	fake.addImpressionCollectorMutex.Lock()
+	fake.addImpressionCollectorArgsForCall = append(fake.addImpressionCollectorArgsForCall, struct {
+		arg1 interfaces.ImpressionCollector
+	}{arg1})
+	stub := fake.AddImpressionCollectorStub
+	fake.recordInvocation("AddImpressionCollector", []interface{}{arg1})
+	fake.addImpressionCollectorMutex.Unlock()
+	if stub != nil {
+		fake.AddImpressionCollectorStub(arg1)
+	}
+}
+
+func (fake *FakeClient) AddImpressionCollectorCallCount() int {
+	fake.addImpressionCollectorMutex.RLock()
+	defer fake.addImpressionCollectorMutex.RUnlock()
+	return len(fake.addImpressionCollectorArgsForCall)
+}
+
+func (fake *FakeClient) AddImpressionCollectorCalls(stub func(interfaces.ImpressionCollector)) {
+	fake.addImpressionCollectorMutex.Lock()
+	defer fake.addImpressionCollectorMutex.Unlock()
+	fake.AddImpressionCollectorStub = stub
+}
+
+func (fake *FakeClient) AddImpressionCollectorArgsForCall(i int) interfaces.ImpressionCollector {
+	fake.addImpressionCollectorMutex.RLock()
+	defer fake.addImpressionCollectorMutex.RUnlock()
+	argsForCall := fake.addImpressionCollectorArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeClient) AddNotifierAllFeatures(arg1 models.CallbackFuncFeature) string {
+	fake.addNotifierAllFeaturesMutex.Lock()
+	ret, specificReturn := fake.addNotifierAllFeaturesReturnsOnCall[len(fake.addNotifierAllFeaturesArgsForCall)]
+	fake.addNotifierAllFeaturesArgsForCall = append(fake.addNotifierAllFeaturesArgsForCall, struct {
+		arg1 models.CallbackFuncFeature
+	}{arg1})
+	stub := fake.AddNotifierAllFeaturesStub
+	fakeReturns := fake.addNotifierAllFeaturesReturns
+	fake.recordInvocation("AddNotifierAllFeatures", []interface{}{arg1})
+	fake.addNotifierAllFeaturesMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeClient) AddNotifierAllFeaturesCallCount() int {
+	fake.addNotifierAllFeaturesMutex.RLock()
+	defer fake.addNotifierAllFeaturesMutex.RUnlock()
+	return len(fake.addNotifierAllFeaturesArgsForCall)
+}
+
+func (fake *FakeClient) AddNotifierAllFeaturesCalls(stub func(models.CallbackFuncFeature) string) {
+	fake.addNotifierAllFeaturesMutex.Lock()
+	defer fake.addNotifierAllFeaturesMutex.Unlock()
+	fake.AddNotifierAllFeaturesStub = stub
+}
+
+func (fake *FakeClient) AddNotifierAllFeaturesArgsForCall(i int) models.CallbackFuncFeature {
+	fake.addNotifierAllFeaturesMutex.RLock()
+	defer fake.addNotifierAllFeaturesMutex.RUnlock()
+	argsForCall := fake.addNotifierAllFeaturesArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeClient) AddNotifierAllFeaturesReturns(result1 string) {
+	fake.addNotifierAllFeaturesMutex.Lock()
+	defer fake.addNotifierAllFeaturesMutex.Unlock()
+	fake.AddNotifierAllFeaturesStub = nil
+	fake.addNotifierAllFeaturesReturns = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeClient) AddNotifierAllFeaturesReturnsOnCall(i int, result1 string) {
+	fake.addNotifierAllFeaturesMutex.Lock()
+	defer fake.addNotifierAllFeaturesMutex.Unlock()
+	fake.AddNotifierAllFeaturesStub = nil
+	if fake.addNotifierAllFeaturesReturnsOnCall == nil {
+		fake.addNotifierAllFeaturesReturnsOnCall = make(map[int]struct {
+			result1 string
+		})
+	}
+	fake.addNotifierAllFeaturesReturnsOnCall[i] = struct {
+		result1 string
+	}{result1}
+}
+
 func (fake *FakeClient) AddNotifierBoolean(arg1 string, arg2 models.CallbackFuncBoolean) string {
 	fake.addNotifierBooleanMutex.Lock()
 	ret, specificReturn := fake.addNotifierBooleanReturnsOnCall[len(fake.addNotifierBooleanArgsForCall)]
@@ -520,6 +726,30 @@ func (fake *FakeClient) AddNotifierStringReturnsOnCall(i int, result1 string) {
 	}{result1}
 }
 
+func (fake *FakeClient) Close() {
+	fake.closeMutex.Lock()
+	fake.closeArgsForCall = append(fake.closeArgsForCall, struct {
+	}{})
+	stub := fake.CloseStub
+	fake.recordInvocation("Close", []interface{}{})
+	fake.closeMutex.Unlock()
+	if stub != nil {
+		fake.CloseStub()
+	}
+}
+
+func (fake *FakeClient) CloseCallCount() int {
+	fake.closeMutex.RLock()
+	defer fake.closeMutex.RUnlock()
+	return len(fake.closeArgsForCall)
+}
+
+func (fake *FakeClient) CloseCalls(stub func()) {
+	fake.closeMutex.Lock()
+	defer fake.closeMutex.Unlock()
+	fake.CloseStub = stub
+}
+
 func (fake *FakeClient) DeleteNotifier(arg1 string, arg2 string) error {
 	fake.deleteNotifierMutex.Lock()
 	ret, specificReturn := fake.deleteNotifierReturnsOnCall[len(fake.deleteNotifierArgsForCall)]
@@ -582,6 +812,152 @@ func (fake *FakeClient) DeleteNotifierReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeClient) FailedReadinessListener(arg1 func(err error)) {
+	fake.failedReadinessListenerMutex.Lock()
+	fake.failedReadinessListenerArgsForCall = append(fake.failedReadinessListenerArgsForCall, struct {
+		arg1 func(err error)
+	}{arg1})
+	stub := fake.FailedReadinessListenerStub
+	fake.recordInvocation("FailedReadinessListener", []interface{}{arg1})
+	fake.failedReadinessListenerMutex.Unlock()
+	if stub != nil {
+		fake.FailedReadinessListenerStub(arg1)
+	}
+}
+
+func (fake *FakeClient) FailedReadinessListenerCallCount() int {
+	fake.failedReadinessListenerMutex.RLock()
+	defer fake.failedReadinessListenerMutex.RUnlock()
+	return len(fake.failedReadinessListenerArgsForCall)
+}
+
+func (fake *FakeClient) FailedReadinessListenerCalls(stub func(func(err error))) {
+	fake.failedReadinessListenerMutex.Lock()
+	defer fake.failedReadinessListenerMutex.Unlock()
+	fake.FailedReadinessListenerStub = stub
+}
+
+func (fake *FakeClient) FailedReadinessListenerArgsForCall(i int) func(err error) {
+	fake.failedReadinessListenerMutex.RLock()
+	defer fake.failedReadinessListenerMutex.RUnlock()
+	argsForCall := fake.failedReadinessListenerArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeClient) Features() map[string]*models.FeatureState {
+	fake.featuresMutex.Lock()
+	ret, specificReturn := fake.featuresReturnsOnCall[len(fake.featuresArgsForCall)]
+	fake.featuresArgsForCall = append(fake.featuresArgsForCall, struct {
+	}{})
+	stub := fake.FeaturesStub
+	fakeReturns := fake.featuresReturns
+	fake.recordInvocation("Features", []interface{}{})
+	fake.featuresMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeClient) FeaturesCallCount() int {
+	fake.featuresMutex.RLock()
+	defer fake.featuresMutex.RUnlock()
+	return len(fake.featuresArgsForCall)
+}
+
+func (fake *FakeClient) FeaturesCalls(stub func() map[string]*models.FeatureState) {
+	fake.featuresMutex.Lock()
+	defer fake.featuresMutex.Unlock()
+	fake.FeaturesStub = stub
+}
+
+func (fake *FakeClient) FeaturesReturns(result1 map[string]*models.FeatureState) {
+	fake.featuresMutex.Lock()
+	defer fake.featuresMutex.Unlock()
+	fake.FeaturesStub = nil
+	fake.featuresReturns = struct {
+		result1 map[string]*models.FeatureState
+	}{result1}
+}
+
+func (fake *FakeClient) FeaturesReturnsOnCall(i int, result1 map[string]*models.FeatureState) {
+	fake.featuresMutex.Lock()
+	defer fake.featuresMutex.Unlock()
+	fake.FeaturesStub = nil
+	if fake.featuresReturnsOnCall == nil {
+		fake.featuresReturnsOnCall = make(map[int]struct {
+			result1 map[string]*models.FeatureState
+		})
+	}
+	fake.featuresReturnsOnCall[i] = struct {
+		result1 map[string]*models.FeatureState
+	}{result1}
+}
+
+func (fake *FakeClient) FlushAnalytics(arg1 context.Context) error {
+	fake.flushAnalyticsMutex.Lock()
+	ret, specificReturn := fake.flushAnalyticsReturnsOnCall[len(fake.flushAnalyticsArgsForCall)]
+	fake.flushAnalyticsArgsForCall = append(fake.flushAnalyticsArgsForCall, struct {
+		arg1 context.Context
+	}{arg1})
+	stub := fake.FlushAnalyticsStub
+	fakeReturns := fake.flushAnalyticsReturns
+	fake.recordInvocation("FlushAnalytics", []interface{}{arg1})
+	fake.flushAnalyticsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeClient) FlushAnalyticsCallCount() int {
+	fake.flushAnalyticsMutex.RLock()
+	defer fake.flushAnalyticsMutex.RUnlock()
+	return len(fake.flushAnalyticsArgsForCall)
+}
+
+func (fake *FakeClient) FlushAnalyticsCalls(stub func(context.Context) error) {
+	fake.flushAnalyticsMutex.Lock()
+	defer fake.flushAnalyticsMutex.Unlock()
+	fake.FlushAnalyticsStub = stub
+}
+
+func (fake *FakeClient) FlushAnalyticsArgsForCall(i int) context.Context {
+	fake.flushAnalyticsMutex.RLock()
+	defer fake.flushAnalyticsMutex.RUnlock()
+	argsForCall := fake.flushAnalyticsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeClient) FlushAnalyticsReturns(result1 error) {
+	fake.flushAnalyticsMutex.Lock()
+	defer fake.flushAnalyticsMutex.Unlock()
+	fake.FlushAnalyticsStub = nil
+	fake.flushAnalyticsReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeClient) FlushAnalyticsReturnsOnCall(i int, result1 error) {
+	fake.flushAnalyticsMutex.Lock()
+	defer fake.flushAnalyticsMutex.Unlock()
+	fake.FlushAnalyticsStub = nil
+	if fake.flushAnalyticsReturnsOnCall == nil {
+		fake.flushAnalyticsReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.flushAnalyticsReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeClient) GetBoolean(arg1 string) (bool, error) {
 	fake.getBooleanMutex.Lock()
 	ret, specificReturn := fake.getBooleanReturnsOnCall[len(fake.getBooleanArgsForCall)]
@@ -902,6 +1278,59 @@ func (fake *FakeClient) GetStringReturnsOnCall(i int, result1 string, result2 er
 	}{result1, result2}
 }
 
+func (fake *FakeClient) Keys() []string {
+	fake.keysMutex.Lock()
+	ret, specificReturn := fake.keysReturnsOnCall[len(fake.keysArgsForCall)]
+	fake.keysArgsForCall = append(fake.keysArgsForCall, struct {
+	}{})
+	stub := fake.KeysStub
+	fakeReturns := fake.keysReturns
+	fake.recordInvocation("Keys", []interface{}{})
+	fake.keysMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeClient) KeysCallCount() int {
+	fake.keysMutex.RLock()
+	defer fake.keysMutex.RUnlock()
+	return len(fake.keysArgsForCall)
+}
+
+func (fake *FakeClient) KeysCalls(stub func() []string) {
+	fake.keysMutex.Lock()
+	defer fake.keysMutex.Unlock()
+	fake.KeysStub = stub
+}
+
+func (fake *FakeClient) KeysReturns(result1 []string) {
+	fake.keysMutex.Lock()
+	defer fake.keysMutex.Unlock()
+	fake.KeysStub = nil
+	fake.keysReturns = struct {
+		result1 []string
+	}{result1}
+}
+
+func (fake *FakeClient) KeysReturnsOnCall(i int, result1 []string) {
+	fake.keysMutex.Lock()
+	defer fake.keysMutex.Unlock()
+	fake.KeysStub = nil
+	if fake.keysReturnsOnCall == nil {
+		fake.keysReturnsOnCall = make(map[int]struct {
+			result1 []string
+		})
+	}
+	fake.keysReturnsOnCall[i] = struct {
+		result1 []string
+	}{result1}
+}
+
 func (fake *FakeClient) LogAnalyticsEvent(arg1 string, arg2 map[string]string) {
 	fake.logAnalyticsEventMutex.Lock()
 	fake.logAnalyticsEventArgsForCall = append(fake.logAnalyticsEventArgsForCall, struct {
@@ -997,6 +1426,59 @@ func (fake *FakeClient) LogAnalyticsEventSyncReturnsOnCall(i int, result1 error)
 	}{result1}
 }
 
+func (fake *FakeClient) Readiness() models.Readiness {
+	fake.readinessMutex.Lock()
+	ret, specificReturn := fake.readinessReturnsOnCall[len(fake.readinessArgsForCall)]
+	fake.readinessArgsForCall = append(fake.readinessArgsForCall, struct {
+	}{})
+	stub := fake.ReadinessStub
+	fakeReturns := fake.readinessReturns
+	fake.recordInvocation("Readiness", []interface{}{})
+	fake.readinessMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeClient) ReadinessCallCount() int {
+	fake.readinessMutex.RLock()
+	defer fake.readinessMutex.RUnlock()
+	return len(fake.readinessArgsForCall)
+}
+
+func (fake *FakeClient) ReadinessCalls(stub func() models.Readiness) {
+	fake.readinessMutex.Lock()
+	defer fake.readinessMutex.Unlock()
+	fake.ReadinessStub = stub
+}
+
+func (fake *FakeClient) ReadinessReturns(result1 models.Readiness) {
+	fake.readinessMutex.Lock()
+	defer fake.readinessMutex.Unlock()
+	fake.ReadinessStub = nil
+	fake.readinessReturns = struct {
+		result1 models.Readiness
+	}{result1}
+}
+
+func (fake *FakeClient) ReadinessReturnsOnCall(i int, result1 models.Readiness) {
+	fake.readinessMutex.Lock()
+	defer fake.readinessMutex.Unlock()
+	fake.ReadinessStub = nil
+	if fake.readinessReturnsOnCall == nil {
+		fake.readinessReturnsOnCall = make(map[int]struct {
+			result1 models.Readiness
+		})
+	}
+	fake.readinessReturnsOnCall[i] = struct {
+		result1 models.Readiness
+	}{result1}
+}
+
 func (fake *FakeClient) ReadinessListener(arg1 func()) {
 	fake.readinessListenerMutex.Lock()
 	fake.readinessListenerArgsForCall = append(fake.readinessListenerArgsForCall, struct {
@@ -1029,11 +1511,267 @@ func (fake *FakeClient) ReadinessListenerArgsForCall(i int) func() {
 	return argsForCall.arg1
 }
 
+func (fake *FakeClient) Ready() <-chan struct{} {
+	fake.readyMutex.Lock()
+	ret, specificReturn := fake.readyReturnsOnCall[len(fake.readyArgsForCall)]
+	fake.readyArgsForCall = append(fake.readyArgsForCall, struct {
+	}{})
+	stub := fake.ReadyStub
+	fakeReturns := fake.readyReturns
+	fake.recordInvocation("Ready", []interface{}{})
+	fake.readyMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeClient) ReadyCallCount() int {
+	fake.readyMutex.RLock()
+	defer fake.readyMutex.RUnlock()
+	return len(fake.readyArgsForCall)
+}
+
+func (fake *FakeClient) ReadyCalls(stub func() <-chan struct{}) {
+	fake.readyMutex.Lock()
+	defer fake.readyMutex.Unlock()
+	fake.ReadyStub = stub
+}
+
+func (fake *FakeClient) ReadyReturns(result1 <-chan struct{}) {
+	fake.readyMutex.Lock()
+	defer fake.readyMutex.Unlock()
+	fake.ReadyStub = nil
+	fake.readyReturns = struct {
+		result1 <-chan struct{}
+	}{result1}
+}
+
+func (fake *FakeClient) ReadyReturnsOnCall(i int, result1 <-chan struct{}) {
+	fake.readyMutex.Lock()
+	defer fake.readyMutex.Unlock()
+	fake.ReadyStub = nil
+	if fake.readyReturnsOnCall == nil {
+		fake.readyReturnsOnCall = make(map[int]struct {
+			result1 <-chan struct{}
+		})
+	}
+	fake.readyReturnsOnCall[i] = struct {
+		result1 <-chan struct{}
+	}{result1}
+}
+
+func (fake *FakeClient) Stale() bool {
+	fake.staleMutex.Lock()
+	ret, specificReturn := fake.staleReturnsOnCall[len(fake.staleArgsForCall)]
+	fake.staleArgsForCall = append(fake.staleArgsForCall, struct {
+	}{})
+	stub := fake.StaleStub
+	fakeReturns := fake.staleReturns
+	fake.recordInvocation("Stale", []interface{}{})
+	fake.staleMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeClient) StaleCallCount() int {
+	fake.staleMutex.RLock()
+	defer fake.staleMutex.RUnlock()
+	return len(fake.staleArgsForCall)
+}
+
+func (fake *FakeClient) StaleCalls(stub func() bool) {
+	fake.staleMutex.Lock()
+	defer fake.staleMutex.Unlock()
+	fake.StaleStub = stub
+}
+
+func (fake *FakeClient) StaleReturns(result1 bool) {
+	fake.staleMutex.Lock()
+	defer fake.staleMutex.Unlock()
+	fake.StaleStub = nil
+	fake.staleReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeClient) StaleReturnsOnCall(i int, result1 bool) {
+	fake.staleMutex.Lock()
+	defer fake.staleMutex.Unlock()
+	fake.StaleStub = nil
+	if fake.staleReturnsOnCall == nil {
+		fake.staleReturnsOnCall = make(map[int]struct {
+			result1 bool
+		})
+	}
+	fake.staleReturnsOnCall[i] = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeClient) StaleListener(arg1 func(stale bool)) {
+	fake.staleListenerMutex.Lock()
+	fake.staleListenerArgsForCall = append(fake.staleListenerArgsForCall, struct {
+		arg1 func(stale bool)
+	}{arg1})
+	stub := fake.StaleListenerStub
+	fake.recordInvocation("StaleListener", []interface{}{arg1})
+	fake.staleListenerMutex.Unlock()
+	if stub != nil {
+		fake.StaleListenerStub(arg1)
+	}
+}
+
+func (fake *FakeClient) StaleListenerCallCount() int {
+	fake.staleListenerMutex.RLock()
+	defer fake.staleListenerMutex.RUnlock()
+	return len(fake.staleListenerArgsForCall)
+}
+
+func (fake *FakeClient) StaleListenerCalls(stub func(func(stale bool))) {
+	fake.staleListenerMutex.Lock()
+	defer fake.staleListenerMutex.Unlock()
+	fake.StaleListenerStub = stub
+}
+
+func (fake *FakeClient) StaleListenerArgsForCall(i int) func(stale bool) {
+	fake.staleListenerMutex.RLock()
+	defer fake.staleListenerMutex.RUnlock()
+	argsForCall := fake.staleListenerArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeClient) Status() models.ClientStatus {
+	fake.statusMutex.Lock()
+	ret, specificReturn := fake.statusReturnsOnCall[len(fake.statusArgsForCall)]
+	fake.statusArgsForCall = append(fake.statusArgsForCall, struct {
+	}{})
+	stub := fake.StatusStub
+	fakeReturns := fake.statusReturns
+	fake.recordInvocation("Status", []interface{}{})
+	fake.statusMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeClient) StatusCallCount() int {
+	fake.statusMutex.RLock()
+	defer fake.statusMutex.RUnlock()
+	return len(fake.statusArgsForCall)
+}
+
+func (fake *FakeClient) StatusCalls(stub func() models.ClientStatus) {
+	fake.statusMutex.Lock()
+	defer fake.statusMutex.Unlock()
+	fake.StatusStub = stub
+}
+
+func (fake *FakeClient) StatusReturns(result1 models.ClientStatus) {
+	fake.statusMutex.Lock()
+	defer fake.statusMutex.Unlock()
+	fake.StatusStub = nil
+	fake.statusReturns = struct {
+		result1 models.ClientStatus
+	}{result1}
+}
+
+func (fake *FakeClient) StatusReturnsOnCall(i int, result1 models.ClientStatus) {
+	fake.statusMutex.Lock()
+	defer fake.statusMutex.Unlock()
+	fake.StatusStub = nil
+	if fake.statusReturnsOnCall == nil {
+		fake.statusReturnsOnCall = make(map[int]struct {
+			result1 models.ClientStatus
+		})
+	}
+	fake.statusReturnsOnCall[i] = struct {
+		result1 models.ClientStatus
+	}{result1}
+}
+
+func (fake *FakeClient) WaitUntilReady(arg1 context.Context) error {
+	fake.waitUntilReadyMutex.Lock()
+	ret, specificReturn := fake.waitUntilReadyReturnsOnCall[len(fake.waitUntilReadyArgsForCall)]
+	fake.waitUntilReadyArgsForCall = append(fake.waitUntilReadyArgsForCall, struct {
+		arg1 context.Context
+	}{arg1})
+	stub := fake.WaitUntilReadyStub
+	fakeReturns := fake.waitUntilReadyReturns
+	fake.recordInvocation("WaitUntilReady", []interface{}{arg1})
+	fake.waitUntilReadyMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeClient) WaitUntilReadyCallCount() int {
+	fake.waitUntilReadyMutex.RLock()
+	defer fake.waitUntilReadyMutex.RUnlock()
+	return len(fake.waitUntilReadyArgsForCall)
+}
+
+func (fake *FakeClient) WaitUntilReadyCalls(stub func(context.Context) error) {
+	fake.waitUntilReadyMutex.Lock()
+	defer fake.waitUntilReadyMutex.Unlock()
+	fake.WaitUntilReadyStub = stub
+}
+
+func (fake *FakeClient) WaitUntilReadyArgsForCall(i int) context.Context {
+	fake.waitUntilReadyMutex.RLock()
+	defer fake.waitUntilReadyMutex.RUnlock()
+	argsForCall := fake.waitUntilReadyArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeClient) WaitUntilReadyReturns(result1 error) {
+	fake.waitUntilReadyMutex.Lock()
+	defer fake.waitUntilReadyMutex.Unlock()
+	fake.WaitUntilReadyStub = nil
+	fake.waitUntilReadyReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeClient) WaitUntilReadyReturnsOnCall(i int, result1 error) {
+	fake.waitUntilReadyMutex.Lock()
+	defer fake.waitUntilReadyMutex.Unlock()
+	fake.WaitUntilReadyStub = nil
+	if fake.waitUntilReadyReturnsOnCall == nil {
+		fake.waitUntilReadyReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.waitUntilReadyReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeClient) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
 	fake.addAnalyticsCollectorMutex.RLock()
 	defer fake.addAnalyticsCollectorMutex.RUnlock()
+	fake.addImpressionCollectorMutex.RLock()
+	defer fake.addImpressionCollectorMutex.RUnlock()
+	fake.addNotifierAllFeaturesMutex.RLock()
+	defer fake.addNotifierAllFeaturesMutex.RUnlock()
 	fake.addNotifierBooleanMutex.RLock()
 	defer fake.addNotifierBooleanMutex.RUnlock()
 	fake.addNotifierFeatureMutex.RLock()
@@ -1044,8 +1782,16 @@ func (fake *FakeClient) Invocations() map[string][][]interface{} {
 	defer fake.addNotifierNumberMutex.RUnlock()
 	fake.addNotifierStringMutex.RLock()
 	defer fake.addNotifierStringMutex.RUnlock()
+	fake.closeMutex.RLock()
+	defer fake.closeMutex.RUnlock()
 	fake.deleteNotifierMutex.RLock()
 	defer fake.deleteNotifierMutex.RUnlock()
+	fake.failedReadinessListenerMutex.RLock()
+	defer fake.failedReadinessListenerMutex.RUnlock()
+	fake.featuresMutex.RLock()
+	defer fake.featuresMutex.RUnlock()
+	fake.flushAnalyticsMutex.RLock()
+	defer fake.flushAnalyticsMutex.RUnlock()
 	fake.getBooleanMutex.RLock()
 	defer fake.getBooleanMutex.RUnlock()
 	fake.getFeatureMutex.RLock()
@@ -1056,12 +1802,26 @@ func (fake *FakeClient) Invocations() map[string][][]interface{} {
 	defer fake.getRawJSONMutex.RUnlock()
 	fake.getStringMutex.RLock()
 	defer fake.getStringMutex.RUnlock()
+	fake.keysMutex.RLock()
+	defer fake.keysMutex.RUnlock()
 	fake.logAnalyticsEventMutex.RLock()
 	defer fake.logAnalyticsEventMutex.RUnlock()
 	fake.logAnalyticsEventSyncMutex.RLock()
 	defer fake.logAnalyticsEventSyncMutex.RUnlock()
+	fake.readinessMutex.RLock()
+	defer fake.readinessMutex.RUnlock()
 	fake.readinessListenerMutex.RLock()
 	defer fake.readinessListenerMutex.RUnlock()
+	fake.readyMutex.RLock()
+	defer fake.readyMutex.RUnlock()
+	fake.staleMutex.RLock()
+	defer fake.staleMutex.RUnlock()
+	fake.staleListenerMutex.RLock()
+	defer fake.staleListenerMutex.RUnlock()
+	fake.statusMutex.RLock()
+	defer fake.statusMutex.RUnlock()
+	fake.waitUntilReadyMutex.RLock()
+	defer fake.waitUntilReadyMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
@@ -0,0 +1,190 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package mocks
+
+import (
+	"sync"
+
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/interfaces"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
+)
+
+type FakeContextAnalyticsCollector struct {
+	LogContextEventStub        func(*models.AnalyticsEvent) error
+	logContextEventMutex       sync.RWMutex
+	logContextEventArgsForCall []struct {
+		arg1 *models.AnalyticsEvent
+	}
+	logContextEventReturns struct {
+		result1 error
+	}
+	logContextEventReturnsOnCall map[int]struct {
+		result1 error
+	}
+	LogEventStub        func(string, map[string]string, map[string]*models.FeatureState) error
+	logEventMutex       sync.RWMutex
+	logEventArgsForCall []struct {
+		arg1 string
+		arg2 map[string]string
+		arg3 map[string]*models.FeatureState
+	}
+	logEventReturns struct {
+		result1 error
+	}
+	logEventReturnsOnCall map[int]struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeContextAnalyticsCollector) LogContextEvent(arg1 *models.AnalyticsEvent) error {
+	fake.logContextEventMutex.Lock()
+	ret, specificReturn := fake.logContextEventReturnsOnCall[len(fake.logContextEventArgsForCall)]
+	fake.logContextEventArgsForCall = append(fake.logContextEventArgsForCall, struct {
+		arg1 *models.AnalyticsEvent
+	}{arg1})
+	stub := fake.LogContextEventStub
+	fakeReturns := fake.logContextEventReturns
+	fake.recordInvocation("LogContextEvent", []interface{}{arg1})
+	fake.logContextEventMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeContextAnalyticsCollector) LogContextEventCallCount() int {
+	fake.logContextEventMutex.RLock()
+	defer fake.logContextEventMutex.RUnlock()
+	return len(fake.logContextEventArgsForCall)
+}
+
+func (fake *FakeContextAnalyticsCollector) LogContextEventCalls(stub func(*models.AnalyticsEvent) error) {
+	fake.logContextEventMutex.Lock()
+	defer fake.logContextEventMutex.Unlock()
+	fake.LogContextEventStub = stub
+}
+
+func (fake *FakeContextAnalyticsCollector) LogContextEventArgsForCall(i int) *models.AnalyticsEvent {
+	fake.logContextEventMutex.RLock()
+	defer fake.logContextEventMutex.RUnlock()
+	argsForCall := fake.logContextEventArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeContextAnalyticsCollector) LogContextEventReturns(result1 error) {
+	fake.logContextEventMutex.Lock()
+	defer fake.logContextEventMutex.Unlock()
+	fake.LogContextEventStub = nil
+	fake.logContextEventReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeContextAnalyticsCollector) LogContextEventReturnsOnCall(i int, result1 error) {
+	fake.logContextEventMutex.Lock()
+	defer fake.logContextEventMutex.Unlock()
+	fake.LogContextEventStub = nil
+	if fake.logContextEventReturnsOnCall == nil {
+		fake.logContextEventReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.logContextEventReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeContextAnalyticsCollector) LogEvent(arg1 string, arg2 map[string]string, arg3 map[string]*models.FeatureState) error {
+	fake.logEventMutex.Lock()
+	ret, specificReturn := fake.logEventReturnsOnCall[len(fake.logEventArgsForCall)]
+	fake.logEventArgsForCall = append(fake.logEventArgsForCall, struct {
+		arg1 string
+		arg2 map[string]string
+		arg3 map[string]*models.FeatureState
+	}{arg1, arg2, arg3})
+	stub := fake.LogEventStub
+	fakeReturns := fake.logEventReturns
+	fake.recordInvocation("LogEvent", []interface{}{arg1, arg2, arg3})
+	fake.logEventMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeContextAnalyticsCollector) LogEventCallCount() int {
+	fake.logEventMutex.RLock()
+	defer fake.logEventMutex.RUnlock()
+	return len(fake.logEventArgsForCall)
+}
+
+func (fake *FakeContextAnalyticsCollector) LogEventCalls(stub func(string, map[string]string, map[string]*models.FeatureState) error) {
+	fake.logEventMutex.Lock()
+	defer fake.logEventMutex.Unlock()
+	fake.LogEventStub = stub
+}
+
+func (fake *FakeContextAnalyticsCollector) LogEventArgsForCall(i int) (string, map[string]string, map[string]*models.FeatureState) {
+	fake.logEventMutex.RLock()
+	defer fake.logEventMutex.RUnlock()
+	argsForCall := fake.logEventArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeContextAnalyticsCollector) LogEventReturns(result1 error) {
+	fake.logEventMutex.Lock()
+	defer fake.logEventMutex.Unlock()
+	fake.LogEventStub = nil
+	fake.logEventReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeContextAnalyticsCollector) LogEventReturnsOnCall(i int, result1 error) {
+	fake.logEventMutex.Lock()
+	defer fake.logEventMutex.Unlock()
+	fake.LogEventStub = nil
+	if fake.logEventReturnsOnCall == nil {
+		fake.logEventReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.logEventReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeContextAnalyticsCollector) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.logContextEventMutex.RLock()
+	defer fake.logContextEventMutex.RUnlock()
+	fake.logEventMutex.RLock()
+	defer fake.logEventMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeContextAnalyticsCollector) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ interfaces.ContextAnalyticsCollector = new(FakeContextAnalyticsCollector)
@@ -0,0 +1,117 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package mocks
+
+import (
+	"sync"
+
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/interfaces"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
+)
+
+type FakeImpressionCollector struct {
+	LogImpressionsStub        func([]*models.Impression) error
+	logImpressionsMutex       sync.RWMutex
+	logImpressionsArgsForCall []struct {
+		arg1 []*models.Impression
+	}
+	logImpressionsReturns struct {
+		result1 error
+	}
+	logImpressionsReturnsOnCall map[int]struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeImpressionCollector) LogImpressions(arg1 []*models.Impression) error {
+	var arg1Copy []*models.Impression
+	if arg1 != nil {
+		arg1Copy = make([]*models.Impression, len(arg1))
+		copy(arg1Copy, arg1)
+	}
+	fake.logImpressionsMutex.Lock()
+	ret, specificReturn := fake.logImpressionsReturnsOnCall[len(fake.logImpressionsArgsForCall)]
+	fake.logImpressionsArgsForCall = append(fake.logImpressionsArgsForCall, struct {
+		arg1 []*models.Impression
+	}{arg1Copy})
+	stub := fake.LogImpressionsStub
+	fakeReturns := fake.logImpressionsReturns
+	fake.recordInvocation("LogImpressions", []interface{}{arg1Copy})
+	fake.logImpressionsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeImpressionCollector) LogImpressionsCallCount() int {
+	fake.logImpressionsMutex.RLock()
+	defer fake.logImpressionsMutex.RUnlock()
+	return len(fake.logImpressionsArgsForCall)
+}
+
+func (fake *FakeImpressionCollector) LogImpressionsCalls(stub func([]*models.Impression) error) {
+	fake.logImpressionsMutex.Lock()
+	defer fake.logImpressionsMutex.Unlock()
+	fake.LogImpressionsStub = stub
+}
+
+func (fake *FakeImpressionCollector) LogImpressionsArgsForCall(i int) []*models.Impression {
+	fake.logImpressionsMutex.RLock()
+	defer fake.logImpressionsMutex.RUnlock()
+	argsForCall := fake.logImpressionsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeImpressionCollector) LogImpressionsReturns(result1 error) {
+	fake.logImpressionsMutex.Lock()
+	defer fake.logImpressionsMutex.Unlock()
+	fake.LogImpressionsStub = nil
+	fake.logImpressionsReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeImpressionCollector) LogImpressionsReturnsOnCall(i int, result1 error) {
+	fake.logImpressionsMutex.Lock()
+	defer fake.logImpressionsMutex.Unlock()
+	fake.LogImpressionsStub = nil
+	if fake.logImpressionsReturnsOnCall == nil {
+		fake.logImpressionsReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.logImpressionsReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeImpressionCollector) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.logImpressionsMutex.RLock()
+	defer fake.logImpressionsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeImpressionCollector) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ interfaces.ImpressionCollector = new(FakeImpressionCollector)
@@ -0,0 +1,309 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package mocks
+
+import (
+	"sync"
+	"time"
+
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/interfaces"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
+)
+
+type FakeMetrics struct {
+	AnalyticsCollectorFailureStub        func(string)
+	analyticsCollectorFailureMutex       sync.RWMutex
+	analyticsCollectorFailureArgsForCall []struct {
+		arg1 string
+	}
+	AnalyticsEventDroppedStub        func(string)
+	analyticsEventDroppedMutex       sync.RWMutex
+	analyticsEventDroppedArgsForCall []struct {
+		arg1 string
+	}
+	EvaluationStub        func(string, string, models.EvaluationOutcome)
+	evaluationMutex       sync.RWMutex
+	evaluationArgsForCall []struct {
+		arg1 string
+		arg2 string
+		arg3 models.EvaluationOutcome
+	}
+	NotifierLatencyStub        func(string, time.Duration)
+	notifierLatencyMutex       sync.RWMutex
+	notifierLatencyArgsForCall []struct {
+		arg1 string
+		arg2 time.Duration
+	}
+	ParseErrorStub        func(string)
+	parseErrorMutex       sync.RWMutex
+	parseErrorArgsForCall []struct {
+		arg1 string
+	}
+	ReconnectStub        func()
+	reconnectMutex       sync.RWMutex
+	reconnectArgsForCall []struct {
+	}
+	SSEEventStub        func(string)
+	sSEEventMutex       sync.RWMutex
+	sSEEventArgsForCall []struct {
+		arg1 string
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeMetrics) AnalyticsCollectorFailure(arg1 string) {
+	fake.analyticsCollectorFailureMutex.Lock()
+	fake.analyticsCollectorFailureArgsForCall = append(fake.analyticsCollectorFailureArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.AnalyticsCollectorFailureStub
+	fake.recordInvocation("AnalyticsCollectorFailure", []interface{}{arg1})
+	fake.analyticsCollectorFailureMutex.Unlock()
+	if stub != nil {
+		fake.AnalyticsCollectorFailureStub(arg1)
+	}
+}
+
+func (fake *FakeMetrics) AnalyticsCollectorFailureCallCount() int {
+	fake.analyticsCollectorFailureMutex.RLock()
+	defer fake.analyticsCollectorFailureMutex.RUnlock()
+	return len(fake.analyticsCollectorFailureArgsForCall)
+}
+
+func (fake *FakeMetrics) AnalyticsCollectorFailureCalls(stub func(string)) {
+	fake.analyticsCollectorFailureMutex.Lock()
+	defer fake.analyticsCollectorFailureMutex.Unlock()
+	fake.AnalyticsCollectorFailureStub = stub
+}
+
+func (fake *FakeMetrics) AnalyticsCollectorFailureArgsForCall(i int) string {
+	fake.analyticsCollectorFailureMutex.RLock()
+	defer fake.analyticsCollectorFailureMutex.RUnlock()
+	argsForCall := fake.analyticsCollectorFailureArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeMetrics) AnalyticsEventDropped(arg1 string) {
+	fake.analyticsEventDroppedMutex.Lock()
+	fake.analyticsEventDroppedArgsForCall = append(fake.analyticsEventDroppedArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.AnalyticsEventDroppedStub
+	fake.recordInvocation("AnalyticsEventDropped", []interface{}{arg1})
+	fake.analyticsEventDroppedMutex.Unlock()
+	if stub != nil {
+		fake.AnalyticsEventDroppedStub(arg1)
+	}
+}
+
+func (fake *FakeMetrics) AnalyticsEventDroppedCallCount() int {
+	fake.analyticsEventDroppedMutex.RLock()
+	defer fake.analyticsEventDroppedMutex.RUnlock()
+	return len(fake.analyticsEventDroppedArgsForCall)
+}
+
+func (fake *FakeMetrics) AnalyticsEventDroppedCalls(stub func(string)) {
+	fake.analyticsEventDroppedMutex.Lock()
+	defer fake.analyticsEventDroppedMutex.Unlock()
+	fake.AnalyticsEventDroppedStub = stub
+}
+
+func (fake *FakeMetrics) AnalyticsEventDroppedArgsForCall(i int) string {
+	fake.analyticsEventDroppedMutex.RLock()
+	defer fake.analyticsEventDroppedMutex.RUnlock()
+	argsForCall := fake.analyticsEventDroppedArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeMetrics) Evaluation(arg1 string, arg2 string, arg3 models.EvaluationOutcome) {
+	fake.evaluationMutex.Lock()
+	fake.evaluationArgsForCall = append(fake.evaluationArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 models.EvaluationOutcome
+	}{arg1, arg2, arg3})
+	stub := fake.EvaluationStub
+	fake.recordInvocation("Evaluation", []interface{}{arg1, arg2, arg3})
+	fake.evaluationMutex.Unlock()
+	if stub != nil {
+		fake.EvaluationStub(arg1, arg2, arg3)
+	}
+}
+
+func (fake *FakeMetrics) EvaluationCallCount() int {
+	fake.evaluationMutex.RLock()
+	defer fake.evaluationMutex.RUnlock()
+	return len(fake.evaluationArgsForCall)
+}
+
+func (fake *FakeMetrics) EvaluationCalls(stub func(string, string, models.EvaluationOutcome)) {
+	fake.evaluationMutex.Lock()
+	defer fake.evaluationMutex.Unlock()
+	fake.EvaluationStub = stub
+}
+
+func (fake *FakeMetrics) EvaluationArgsForCall(i int) (string, string, models.EvaluationOutcome) {
+	fake.evaluationMutex.RLock()
+	defer fake.evaluationMutex.RUnlock()
+	argsForCall := fake.evaluationArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeMetrics) NotifierLatency(arg1 string, arg2 time.Duration) {
+	fake.notifierLatencyMutex.Lock()
+	fake.notifierLatencyArgsForCall = append(fake.notifierLatencyArgsForCall, struct {
+		arg1 string
+		arg2 time.Duration
+	}{arg1, arg2})
+	stub := fake.NotifierLatencyStub
+	fake.recordInvocation("NotifierLatency", []interface{}{arg1, arg2})
+	fake.notifierLatencyMutex.Unlock()
+	if stub != nil {
+		fake.NotifierLatencyStub(arg1, arg2)
+	}
+}
+
+func (fake *FakeMetrics) NotifierLatencyCallCount() int {
+	fake.notifierLatencyMutex.RLock()
+	defer fake.notifierLatencyMutex.RUnlock()
+	return len(fake.notifierLatencyArgsForCall)
+}
+
+func (fake *FakeMetrics) NotifierLatencyCalls(stub func(string, time.Duration)) {
+	fake.notifierLatencyMutex.Lock()
+	defer fake.notifierLatencyMutex.Unlock()
+	fake.NotifierLatencyStub = stub
+}
+
+func (fake *FakeMetrics) NotifierLatencyArgsForCall(i int) (string, time.Duration) {
+	fake.notifierLatencyMutex.RLock()
+	defer fake.notifierLatencyMutex.RUnlock()
+	argsForCall := fake.notifierLatencyArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeMetrics) ParseError(arg1 string) {
+	fake.parseErrorMutex.Lock()
+	fake.parseErrorArgsForCall = append(fake.parseErrorArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.ParseErrorStub
+	fake.recordInvocation("ParseError", []interface{}{arg1})
+	fake.parseErrorMutex.Unlock()
+	if stub != nil {
+		fake.ParseErrorStub(arg1)
+	}
+}
+
+func (fake *FakeMetrics) ParseErrorCallCount() int {
+	fake.parseErrorMutex.RLock()
+	defer fake.parseErrorMutex.RUnlock()
+	return len(fake.parseErrorArgsForCall)
+}
+
+func (fake *FakeMetrics) ParseErrorCalls(stub func(string)) {
+	fake.parseErrorMutex.Lock()
+	defer fake.parseErrorMutex.Unlock()
+	fake.ParseErrorStub = stub
+}
+
+func (fake *FakeMetrics) ParseErrorArgsForCall(i int) string {
+	fake.parseErrorMutex.RLock()
+	defer fake.parseErrorMutex.RUnlock()
+	argsForCall := fake.parseErrorArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeMetrics) Reconnect() {
+	fake.reconnectMutex.Lock()
+	fake.reconnectArgsForCall = append(fake.reconnectArgsForCall, struct {
+	}{})
+	stub := fake.ReconnectStub
+	fake.recordInvocation("Reconnect", []interface{}{})
+	fake.reconnectMutex.Unlock()
+	if stub != nil {
+		fake.ReconnectStub()
+	}
+}
+
+func (fake *FakeMetrics) ReconnectCallCount() int {
+	fake.reconnectMutex.RLock()
+	defer fake.reconnectMutex.RUnlock()
+	return len(fake.reconnectArgsForCall)
+}
+
+func (fake *FakeMetrics) ReconnectCalls(stub func()) {
+	fake.reconnectMutex.Lock()
+	defer fake.reconnectMutex.Unlock()
+	fake.ReconnectStub = stub
+}
+
+func (fake *FakeMetrics) SSEEvent(arg1 string) {
+	fake.sSEEventMutex.Lock()
+	fake.sSEEventArgsForCall = append(fake.sSEEventArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.SSEEventStub
+	fake.recordInvocation("SSEEvent", []interface{}{arg1})
+	fake.sSEEventMutex.Unlock()
+	if stub != nil {
+		fake.SSEEventStub(arg1)
+	}
+}
+
+func (fake *FakeMetrics) SSEEventCallCount() int {
+	fake.sSEEventMutex.RLock()
+	defer fake.sSEEventMutex.RUnlock()
+	return len(fake.sSEEventArgsForCall)
+}
+
+func (fake *FakeMetrics) SSEEventCalls(stub func(string)) {
+	fake.sSEEventMutex.Lock()
+	defer fake.sSEEventMutex.Unlock()
+	fake.SSEEventStub = stub
+}
+
+func (fake *FakeMetrics) SSEEventArgsForCall(i int) string {
+	fake.sSEEventMutex.RLock()
+	defer fake.sSEEventMutex.RUnlock()
+	argsForCall := fake.sSEEventArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeMetrics) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.analyticsCollectorFailureMutex.RLock()
+	defer fake.analyticsCollectorFailureMutex.RUnlock()
+	fake.analyticsEventDroppedMutex.RLock()
+	defer fake.analyticsEventDroppedMutex.RUnlock()
+	fake.evaluationMutex.RLock()
+	defer fake.evaluationMutex.RUnlock()
+	fake.notifierLatencyMutex.RLock()
+	defer fake.notifierLatencyMutex.RUnlock()
+	fake.parseErrorMutex.RLock()
+	defer fake.parseErrorMutex.RUnlock()
+	fake.reconnectMutex.RLock()
+	defer fake.reconnectMutex.RUnlock()
+	fake.sSEEventMutex.RLock()
+	defer fake.sSEEventMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeMetrics) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ interfaces.Metrics = new(FakeMetrics)
@@ -0,0 +1,125 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package mocks
+
+import (
+	"context"
+	"sync"
+
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/interfaces"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
+)
+
+type FakeTracer struct {
+	ConnectionStub        func(models.ConnectionEvent, error)
+	connectionMutex       sync.RWMutex
+	connectionArgsForCall []struct {
+		arg1 models.ConnectionEvent
+		arg2 error
+	}
+	EvaluationStub        func(context.Context, string, *models.EvaluatedFeature, models.EvaluationOutcome)
+	evaluationMutex       sync.RWMutex
+	evaluationArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 *models.EvaluatedFeature
+		arg4 models.EvaluationOutcome
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeTracer) Connection(arg1 models.ConnectionEvent, arg2 error) {
+	fake.connectionMutex.Lock()
+	fake.connectionArgsForCall = append(fake.connectionArgsForCall, struct {
+		arg1 models.ConnectionEvent
+		arg2 error
+	}{arg1, arg2})
+	stub := fake.ConnectionStub
+	fake.recordInvocation("Connection", []interface{}{arg1, arg2})
+	fake.connectionMutex.Unlock()
+	if stub != nil {
+		fake.ConnectionStub(arg1, arg2)
+	}
+}
+
+func (fake *FakeTracer) ConnectionCallCount() int {
+	fake.connectionMutex.RLock()
+	defer fake.connectionMutex.RUnlock()
+	return len(fake.connectionArgsForCall)
+}
+
+func (fake *FakeTracer) ConnectionCalls(stub func(models.ConnectionEvent, error)) {
+	fake.connectionMutex.Lock()
+	defer fake.connectionMutex.Unlock()
+	fake.ConnectionStub = stub
+}
+
+func (fake *FakeTracer) ConnectionArgsForCall(i int) (models.ConnectionEvent, error) {
+	fake.connectionMutex.RLock()
+	defer fake.connectionMutex.RUnlock()
+	argsForCall := fake.connectionArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeTracer) Evaluation(arg1 context.Context, arg2 string, arg3 *models.EvaluatedFeature, arg4 models.EvaluationOutcome) {
+	fake.evaluationMutex.Lock()
+	fake.evaluationArgsForCall = append(fake.evaluationArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 *models.EvaluatedFeature
+		arg4 models.EvaluationOutcome
+	}{arg1, arg2, arg3, arg4})
+	stub := fake.EvaluationStub
+	fake.recordInvocation("Evaluation", []interface{}{arg1, arg2, arg3, arg4})
+	fake.evaluationMutex.Unlock()
+	if stub != nil {
+		fake.EvaluationStub(arg1, arg2, arg3, arg4)
+	}
+}
+
+func (fake *FakeTracer) EvaluationCallCount() int {
+	fake.evaluationMutex.RLock()
+	defer fake.evaluationMutex.RUnlock()
+	return len(fake.evaluationArgsForCall)
+}
+
+func (fake *FakeTracer) EvaluationCalls(stub func(context.Context, string, *models.EvaluatedFeature, models.EvaluationOutcome)) {
+	fake.evaluationMutex.Lock()
+	defer fake.evaluationMutex.Unlock()
+	fake.EvaluationStub = stub
+}
+
+func (fake *FakeTracer) EvaluationArgsForCall(i int) (context.Context, string, *models.EvaluatedFeature, models.EvaluationOutcome) {
+	fake.evaluationMutex.RLock()
+	defer fake.evaluationMutex.RUnlock()
+	argsForCall := fake.evaluationArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeTracer) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.connectionMutex.RLock()
+	defer fake.connectionMutex.RUnlock()
+	fake.evaluationMutex.RLock()
+	defer fake.evaluationMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeTracer) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ interfaces.Tracer = new(FakeTracer)
@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// AnalyticsEvent is one call to LogAnalyticsEvent (as it passes through the analytics pipeline):
+type AnalyticsEvent struct {
+	Action    string                   `json:"action"`              // The action being logged
+	Context   *Context                 `json:"context,omitempty"`   // The context the event was logged with (if it was logged through a ClientWithContext)
+	Evaluated EvaluatedFeatures        `json:"evaluated,omitempty"` // The values of the features for the context (if it was logged through a ClientWithContext)
+	Features  map[string]*FeatureState `json:"features"`            // A snapshot of the features at the time of the event (by key)
+	Other     map[string]string        `json:"other"`               // Any other attributes provided with the event
+	Timestamp time.Time                `json:"timestamp"`           // When the event was logged
+}
+
+// ExperiencedFeatures returns the features as the event's context experienced them:
+// - features which were evaluated for a context have the evaluated value (and no strategies, because they have already been applied)
+// - otherwise they are the same as Features
+func (ae *AnalyticsEvent) ExperiencedFeatures() map[string]*FeatureState {
+	if len(ae.Evaluated) == 0 {
+		return ae.Features
+	}
+
+	experiencedFeatures := make(map[string]*FeatureState, len(ae.Features))
+	for key, featureState := range ae.Features {
+		evaluatedFeature, ok := ae.Evaluated[key]
+		if !ok {
+			experiencedFeatures[key] = featureState
+			continue
+		}
+		experiencedFeatures[key] = &FeatureState{
+			ID:      evaluatedFeature.ID,
+			Key:     evaluatedFeature.Key,
+			Type:    evaluatedFeature.Type,
+			Value:   evaluatedFeature.Value,
+			Version: evaluatedFeature.Version,
+		}
+	}
+	return experiencedFeatures
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// Assignment records which percentage strategy a userkey was assigned for a feature (for sticky bucketing):
+type Assignment struct {
+	AssignedAt time.Time `json:"assignedAt"`           // When the assignment was made
+	FeatureKey string    `json:"featureKey"`           // The key of the feature
+	StrategyID string    `json:"strategyId,omitempty"` // ID of the assigned strategy (empty if the userkey wasn't in any of the percentages, so it gets the default value)
+	Userkey    string    `json:"userkey"`              // The userkey from the context
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// ClientStatus describes the state of a client (useful for debugging and health-checks):
+type ClientStatus struct {
+	Connected     bool      `json:"connected"`     // Whether we are currently connected to the FeatureHub server
+	EnvironmentID string    `json:"environmentId"` // The environment ID (taken from the SDK key)
+	FeatureCount  int       `json:"featureCount"`  // How many features we currently have
+	HasData       bool      `json:"hasData"`       // Whether we have received any data yet
+	LastEventAt   time.Time `json:"lastEventAt"`   // When we last received an event from the server
+	LastEventID   string    `json:"lastEventId"`   // The ID of the last event we received (sent as Last-Event-ID when we reconnect)
+	Readiness     Readiness `json:"readiness"`     // Whether we're ready to serve features (not_ready, ready, failed or stale)
+	Running       bool      `json:"running"`       // Whether we're still handling events from the server (false before Start() and after Close())
+	ServerAddress string    `json:"serverAddress"` // The server address we're streaming from (this changes when we fail over)
+	Stale         bool      `json:"stale"`         // Whether we've gone too long without hearing from the server (see Config.StaleAfter)
+}
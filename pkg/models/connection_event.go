@@ -0,0 +1,17 @@
+package models
+
+// ConnectionEvent describes a change to the state of the SSE connection:
+type ConnectionEvent string
+
+const (
+	// ConnectionEventClosed means that the connection was closed (it won't be re-established):
+	ConnectionEventClosed ConnectionEvent = "closed"
+	// ConnectionEventConnected means that the connection was established (or re-established):
+	ConnectionEventConnected ConnectionEvent = "connected"
+	// ConnectionEventConnecting means that we're about to connect:
+	ConnectionEventConnecting ConnectionEvent = "connecting"
+	// ConnectionEventDisconnected means that the connection was interrupted (it will be re-established):
+	ConnectionEventDisconnected ConnectionEvent = "disconnected"
+	// ConnectionEventFailed means that we were unable to connect:
+	ConnectionEventFailed ConnectionEvent = "failed"
+)
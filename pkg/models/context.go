@@ -23,6 +23,32 @@ func (c *Context) String() string {
 	return url.QueryEscape(fmt.Sprintf("userkey=%s,session=%s,device=%s,platform=%s,country=%s,version=%s", c.Userkey, c.Session, c.Device, c.Platform, c.Country, c.Version))
 }
 
+// Attributes returns the context's attributes by name (leaving out any which are empty):
+// - custom attributes are included under their own names (the standard attributes take precedence if they clash)
+func (c *Context) Attributes() map[string]interface{} {
+	if c == nil {
+		return nil
+	}
+
+	attributes := make(map[string]interface{}, len(c.Custom)+6)
+	for name, value := range c.Custom {
+		attributes[name] = value
+	}
+	for name, value := range map[string]string{
+		"country":  string(c.Country),
+		"device":   string(c.Device),
+		"platform": string(c.Platform),
+		"session":  c.Session,
+		"userkey":  c.Userkey,
+		"version":  c.Version,
+	} {
+		if len(value) > 0 {
+			attributes[name] = value
+		}
+	}
+	return attributes
+}
+
 // UniqueKey returns our preferred unique key:
 func (c *Context) UniqueKey() (string, bool) {
 	switch {
@@ -20,4 +20,17 @@ func TestContext(t *testing.T) {
 	}
 
 	assert.Equal(t, url.QueryEscape("userkey=some-random-string,session=some-session-ID,device=desktop,platform=macos,country=new_zealand,version=5.0.0"), context.String())
+
+	// Its attributes (without the empty ones, and with custom attributes alongside the standard ones):
+	context.Version = ""
+	context.Custom = map[string]interface{}{"tier": "gold", "userkey": "ignored"}
+	assert.Equal(t, map[string]interface{}{
+		"country":  "new_zealand",
+		"device":   "desktop",
+		"platform": "macos",
+		"session":  "some-session-ID",
+		"tier":     "gold",
+		"userkey":  "some-random-string",
+	}, context.Attributes())
+	assert.Nil(t, (*Context)(nil).Attributes())
 }
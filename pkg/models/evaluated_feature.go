@@ -0,0 +1,59 @@
+package models
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// EvaluatedFeature is a feature with rollout strategies applied for a particular context:
+// It serialises to the same shape as the features served by FeatureHub's edge (without any strategies).
+type EvaluatedFeature struct {
+	ID         string           `json:"id,omitempty"`      // ID
+	Key        string           `json:"key,omitempty"`     // Name of the feature
+	Type       FeatureValueType `json:"type,omitempty"`    // Data type
+	Value      interface{}      `json:"value"`             // The value which applies to the context
+	Version    int64            `json:"version,omitempty"` // Version
+	StrategyID string           `json:"-"`                 // ID of the matched strategy (empty if the default value applied)
+}
+
+// EvaluatedFeatures maps evaluated features by key:
+type EvaluatedFeatures map[string]*EvaluatedFeature
+
+// MarshalJSON serialises evaluated features as a list (sorted by key), in the same shape as a FeatureHub "features" payload:
+func (ef EvaluatedFeatures) MarshalJSON() ([]byte, error) {
+	keys := make([]string, 0, len(ef))
+	for key := range ef {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	evaluatedFeatures := make([]*EvaluatedFeature, 0, len(ef))
+	for _, key := range keys {
+		evaluatedFeatures = append(evaluatedFeatures, ef[key])
+	}
+
+	return json.Marshal(evaluatedFeatures)
+}
+
+// UnmarshalJSON de-serialises a list of evaluated features back into a map:
+func (ef *EvaluatedFeatures) UnmarshalJSON(data []byte) error {
+	evaluatedFeatures := []*EvaluatedFeature{}
+	if err := json.Unmarshal(data, &evaluatedFeatures); err != nil {
+		return err
+	}
+
+	*ef = make(EvaluatedFeatures, len(evaluatedFeatures))
+	for _, evaluatedFeature := range evaluatedFeatures {
+		(*ef)[evaluatedFeature.Key] = evaluatedFeature
+	}
+
+	return nil
+}
+
+// Outcome tells us whether a strategy matched or the default value was used:
+func (ef *EvaluatedFeature) Outcome() EvaluationOutcome {
+	if len(ef.StrategyID) > 0 {
+		return EvaluationOutcomeStrategy
+	}
+	return EvaluationOutcomeDefault
+}
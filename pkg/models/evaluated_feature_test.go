@@ -0,0 +1,75 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/strategies"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluatedFeatures(t *testing.T) {
+
+	// A feature with a strategy for linux users:
+	featureState := &FeatureState{
+		ID:      "id1",
+		Key:     "feature1",
+		Type:    TypeBoolean,
+		Value:   true,
+		Version: 3,
+		Strategies: Strategies{
+			{
+				ID:    "s1",
+				Value: false,
+				Attributes: []*StrategyAttribute{
+					{
+						ID:          "a1",
+						Conditional: strategies.ConditionalEquals,
+						FieldName:   strategies.FieldNamePlatform,
+						Values:      []interface{}{string(ContextPlatformLinux)},
+						Type:        strategies.TypeString,
+					},
+				},
+			},
+			{
+				ID:    "s2",
+				Value: "not a boolean",
+				Attributes: []*StrategyAttribute{
+					{
+						ID:          "a2",
+						Conditional: strategies.ConditionalEquals,
+						FieldName:   strategies.FieldNamePlatform,
+						Values:      []interface{}{string(ContextPlatformWindows)},
+						Type:        strategies.TypeString,
+					},
+				},
+			},
+		},
+	}
+
+	// Evaluate for a linux user (matches the strategy):
+	evaluatedFeature := featureState.Evaluate(&Context{Platform: ContextPlatformLinux})
+	assert.Equal(t, false, evaluatedFeature.Value)
+	assert.Equal(t, "s1", evaluatedFeature.StrategyID)
+	assert.Equal(t, int64(3), evaluatedFeature.Version)
+
+	// Evaluate for a windows user (the strategy value is the wrong type, so we get the default):
+	evaluatedFeature = featureState.Evaluate(&Context{Platform: ContextPlatformWindows})
+	assert.Equal(t, true, evaluatedFeature.Value)
+	assert.Empty(t, evaluatedFeature.StrategyID)
+
+	// Serialise some evaluated features (false values must survive, and the output is sorted by key):
+	evaluatedFeatures := EvaluatedFeatures{
+		"feature2": {ID: "id2", Key: "feature2", Type: TypeString, Value: "two", Version: 1, StrategyID: "s2"},
+		"feature1": featureState.Evaluate(&Context{Platform: ContextPlatformLinux}),
+	}
+	evaluatedFeaturesJSON, err := json.Marshal(evaluatedFeatures)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `[{"id":"id1","key":"feature1","type":"BOOLEAN","value":false,"version":3},{"id":"id2","key":"feature2","type":"STRING","value":"two","version":1}]`, string(evaluatedFeaturesJSON))
+
+	// And back again:
+	unmarshaledFeatures := EvaluatedFeatures{}
+	assert.NoError(t, json.Unmarshal(evaluatedFeaturesJSON, &unmarshaledFeatures))
+	assert.Len(t, unmarshaledFeatures, 2)
+	assert.Equal(t, "two", unmarshaledFeatures["feature2"].Value)
+}
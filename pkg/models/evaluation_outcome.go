@@ -0,0 +1,15 @@
+package models
+
+// EvaluationOutcome describes how a feature evaluation turned out:
+type EvaluationOutcome string
+
+const (
+	// EvaluationOutcomeDefault means that no strategy matched, so the default value was used:
+	EvaluationOutcomeDefault EvaluationOutcome = "default"
+	// EvaluationOutcomeNotFound means that the feature doesn't exist:
+	EvaluationOutcomeNotFound EvaluationOutcome = "not_found"
+	// EvaluationOutcomeStrategy means that a strategy matched, so its value was used:
+	EvaluationOutcomeStrategy EvaluationOutcome = "strategy"
+	// EvaluationOutcomeTypeMismatch means that the feature isn't the type which was asked for:
+	EvaluationOutcomeTypeMismatch EvaluationOutcome = "type_mismatch"
+)
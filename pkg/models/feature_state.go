@@ -1,7 +1,10 @@
 package models
 
 import (
+	"time"
+
 	"github.com/featurehub-io/featurehub-go-sdk/pkg/errors"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/logging"
 )
 
 // FeatureState defines model for FeatureState.
@@ -85,3 +88,85 @@ func (fs *FeatureState) AsString() (string, error) {
 	// Return the default value as a fall-back:
 	return defaultValue, nil
 }
+
+// Copy returns a deep copy of this feature (so changing it doesn't change the original):
+func (fs *FeatureState) Copy() *FeatureState {
+	if fs == nil {
+		return nil
+	}
+	featureState := *fs
+	featureState.Strategies = fs.Strategies.Copy()
+	return &featureState
+}
+
+// Evaluate applies this feature's rollout strategies to the given context:
+// - if a strategy matches (and its value is the correct type) then its value is used
+// - otherwise the default value is used
+func (fs *FeatureState) Evaluate(clientContext *Context) *EvaluatedFeature {
+	return fs.EvaluateWithLogger(clientContext, getDefaultLogger())
+}
+
+// EvaluateWithLogger is Evaluate, logging its reasoning to the given logger (which may be nil):
+func (fs *FeatureState) EvaluateWithLogger(clientContext *Context, logger logging.Logger) *EvaluatedFeature {
+	return fs.evaluated(fs.Strategies.MatchWithLogger(clientContext, logger))
+}
+
+// EvaluateAssigned applies this feature's rollout strategies to the given context, keeping its userkey in the percentage strategy it has been assigned (sticky bucketing):
+// - with an assignment, percentage-based rules only match the assigned strategy (so changing percentages doesn't move the userkey between strategies)
+// - without one, the feature is evaluated as usual, and a new assignment is returned if a percentage strategy decided the outcome
+// - userkeys which only missed out on a percentage (but passed its attribute-based rules) are assigned to no strategy, so growing the percentage doesn't pull them in
+// - features without percentage strategies, and contexts without a userkey, are never assigned
+func (fs *FeatureState) EvaluateAssigned(clientContext *Context, assignment *Assignment, logger logging.Logger) (*EvaluatedFeature, *Assignment) {
+	if clientContext == nil || len(clientContext.Userkey) == 0 || !fs.Strategies.HasPercentage() {
+		return fs.EvaluateWithLogger(clientContext, logger), nil
+	}
+
+	// Honour an existing assignment:
+	if assignment != nil {
+		return fs.evaluated(fs.Strategies.MatchAssigned(clientContext, assignment.StrategyID, logger)), nil
+	}
+
+	// Otherwise evaluate as usual, and work out whether the outcome was decided by a percentage:
+	evaluatedFeature := fs.EvaluateWithLogger(clientContext, logger)
+	newAssignment := &Assignment{
+		AssignedAt: time.Now(),
+		FeatureKey: fs.Key,
+		Userkey:    clientContext.Userkey,
+	}
+	if len(evaluatedFeature.StrategyID) == 0 {
+
+		// A userkey which failed attribute-based rules may pass them later, so we only remember those which missed out on a percentage:
+		if !fs.Strategies.matchesPercentageAttributes(clientContext, logger) {
+			return evaluatedFeature, nil
+		}
+		return evaluatedFeature, newAssignment
+	}
+	for _, strategy := range fs.Strategies {
+		if strategy.ID == evaluatedFeature.StrategyID && strategy.Percentage > 0 {
+			newAssignment.StrategyID = strategy.ID
+			return evaluatedFeature, newAssignment
+		}
+	}
+
+	// An attribute-only strategy matched, so there is nothing to remember:
+	return evaluatedFeature, nil
+}
+
+// evaluated builds an evaluated feature from whichever strategy matched (using its value if it is the correct type, otherwise the default value):
+func (fs *FeatureState) evaluated(strategy *Strategy) *EvaluatedFeature {
+	evaluatedFeature := &EvaluatedFeature{
+		ID:      fs.ID,
+		Key:     fs.Key,
+		Type:    fs.Type,
+		Value:   fs.Value,
+		Version: fs.Version,
+	}
+
+	// Figure out which value to use:
+	if strategy != nil && fs.Type.matchesValue(strategy.Value) {
+		evaluatedFeature.Value = strategy.Value
+		evaluatedFeature.StrategyID = strategy.ID
+	}
+
+	return evaluatedFeature
+}
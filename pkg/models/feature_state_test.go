@@ -1,8 +1,10 @@
 package models
 
 import (
+	"fmt"
 	"testing"
 
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/strategies"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -48,3 +50,121 @@ func TestFeatureStateTest(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "hello, i am a string", stringValue)
 }
+
+func TestFeatureStateEvaluateAssigned(t *testing.T) {
+
+	// A feature with an attribute strategy, then a percentage one:
+	featureState := &FeatureState{
+		ID:    "featureStateSticky",
+		Key:   "featureStateSticky",
+		Type:  TypeString,
+		Value: "default",
+		Strategies: Strategies{
+			{
+				ID:    "russians",
+				Value: "for the russians",
+				Attributes: []*StrategyAttribute{
+					{FieldName: strategies.FieldNameCountry, Conditional: strategies.ConditionalEquals, Type: strategies.TypeString, Values: []interface{}{"russia"}},
+				},
+			},
+			{ID: "half", Percentage: 500000, Value: "for half"},
+		},
+		Version: 1,
+	}
+
+	// Find a userkey in each half:
+	var inside, outside string
+	for i := 0; len(inside) == 0 || len(outside) == 0; i++ {
+		userkey := fmt.Sprintf("user%d", i)
+		if PercentageBucket(userkey) <= 500000 {
+			inside = userkey
+		} else {
+			outside = userkey
+		}
+	}
+
+	// Without an assignment we evaluate as usual, and get one back:
+	evaluatedFeature, assignment := featureState.EvaluateAssigned(&Context{Userkey: inside}, nil, nil)
+	assert.Equal(t, "for half", evaluatedFeature.Value)
+	assert.Equal(t, "half", assignment.StrategyID)
+	insideAssignment := assignment
+	evaluatedFeature, assignment = featureState.EvaluateAssigned(&Context{Userkey: outside}, nil, nil)
+	assert.Equal(t, "default", evaluatedFeature.Value)
+	assert.Empty(t, assignment.StrategyID)
+	assert.Equal(t, outside, assignment.Userkey)
+	outsideAssignment := assignment
+
+	// Changing the percentage doesn't move assigned userkeys:
+	featureState.Strategies[1].Percentage = 1
+	featureState.Version = 2
+	evaluatedFeature, assignment = featureState.EvaluateAssigned(&Context{Userkey: inside}, insideAssignment, nil)
+	assert.Equal(t, "for half", evaluatedFeature.Value)
+	assert.Nil(t, assignment)
+	assert.Equal(t, "default", featureState.Evaluate(&Context{Userkey: inside}).Value)
+	featureState.Strategies[1].Percentage = 1000000
+	evaluatedFeature, _ = featureState.EvaluateAssigned(&Context{Userkey: outside}, outsideAssignment, nil)
+	assert.Equal(t, "default", evaluatedFeature.Value)
+	assert.Equal(t, "for half", featureState.Evaluate(&Context{Userkey: outside}).Value)
+
+	// Attribute strategies still apply:
+	evaluatedFeature, _ = featureState.EvaluateAssigned(&Context{Country: ContextCountryRussia, Userkey: outside}, outsideAssignment, nil)
+	assert.Equal(t, "for the russians", evaluatedFeature.Value)
+
+	// Nothing is assigned when an attribute strategy decides, without a userkey, or without any percentage strategies:
+	_, assignment = featureState.EvaluateAssigned(&Context{Country: ContextCountryRussia, Userkey: outside}, nil, nil)
+	assert.Nil(t, assignment)
+	_, assignment = featureState.EvaluateAssigned(&Context{Session: "session1"}, nil, nil)
+	assert.Nil(t, assignment)
+	_, assignment = (&FeatureState{Key: "plain", Type: TypeString, Value: "plain"}).EvaluateAssigned(&Context{Userkey: inside}, nil, nil)
+	assert.Nil(t, assignment)
+
+	// Userkeys which fail the attributes of a percentage strategy aren't assigned, so they can still get it when their attributes change:
+	featureState.Strategies = Strategies{
+		{
+			ID:         "half-of-new-zealand",
+			Percentage: 500000,
+			Value:      "for half of new zealand",
+			Attributes: []*StrategyAttribute{
+				{FieldName: strategies.FieldNameCountry, Conditional: strategies.ConditionalEquals, Type: strategies.TypeString, Values: []interface{}{"new_zealand"}},
+			},
+		},
+	}
+	evaluatedFeature, assignment = featureState.EvaluateAssigned(&Context{Country: ContextCountryUnitedKingdom, Userkey: inside}, nil, nil)
+	assert.Equal(t, "default", evaluatedFeature.Value)
+	assert.Nil(t, assignment)
+	evaluatedFeature, assignment = featureState.EvaluateAssigned(&Context{Country: ContextCountryNewZealand, Userkey: inside}, nil, nil)
+	assert.Equal(t, "for half of new zealand", evaluatedFeature.Value)
+	assert.Equal(t, "half-of-new-zealand", assignment.StrategyID)
+	_, assignment = featureState.EvaluateAssigned(&Context{Country: ContextCountryNewZealand, Userkey: outside}, nil, nil)
+	assert.Empty(t, assignment.StrategyID)
+}
+
+func TestFeatureStateCopy(t *testing.T) {
+	featureState := &FeatureState{
+		Key:   "featureStateCopy",
+		Type:  TypeString,
+		Value: "default",
+		Strategies: Strategies{
+			{
+				ID:    "s1",
+				Value: "for the russians",
+				Attributes: []*StrategyAttribute{
+					{FieldName: strategies.FieldNameCountry, Conditional: strategies.ConditionalEquals, Type: strategies.TypeString, Values: []interface{}{"russia"}},
+				},
+			},
+		},
+	}
+
+	// Changing the copy (all the way down to the attribute values) doesn't change the original:
+	featureStateCopy := featureState.Copy()
+	assert.Equal(t, featureState, featureStateCopy)
+	featureStateCopy.Value = "changed"
+	featureStateCopy.Strategies[0].Value = "changed"
+	featureStateCopy.Strategies[0].Attributes[0].FieldName = strategies.FieldNameDevice
+	featureStateCopy.Strategies[0].Attributes[0].Values[0] = "france"
+	assert.Equal(t, "default", featureState.Value)
+	assert.Equal(t, "for the russians", featureState.Strategies[0].Value)
+	assert.Equal(t, strategies.FieldNameCountry, featureState.Strategies[0].Attributes[0].FieldName)
+	assert.Equal(t, "russia", featureState.Strategies[0].Attributes[0].Values[0])
+	assert.Nil(t, (*FeatureState)(nil).Copy())
+}
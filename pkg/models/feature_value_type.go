@@ -15,3 +15,19 @@ const (
 
 // FeatureValueType defines model for FeatureValueType.
 type FeatureValueType string
+
+// matchesValue checks that the given value can be asserted as this type:
+func (fvt FeatureValueType) matchesValue(value interface{}) bool {
+	var ok bool
+	switch fvt {
+	case TypeBoolean:
+		_, ok = value.(bool)
+	case TypeNumber:
+		_, ok = value.(float64)
+	case TypeJSON, TypeString:
+		_, ok = value.(string)
+	default:
+		ok = true
+	}
+	return ok
+}
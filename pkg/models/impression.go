@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// Impression records that a feature was evaluated for a context (ie what a user actually experienced):
+type Impression struct {
+	Key        string      `json:"key"`                  // The key of the feature
+	Session    string      `json:"session,omitempty"`    // The session from the context
+	StrategyID string      `json:"strategyId,omitempty"` // ID of the matched strategy (empty if the default value applied)
+	Timestamp  time.Time   `json:"timestamp"`            // When the feature was evaluated
+	Userkey    string      `json:"userkey,omitempty"`    // The userkey from the context
+	Value      interface{} `json:"value"`                // The value which applied to the context
+	Version    int64       `json:"version"`              // The version of the feature
+}
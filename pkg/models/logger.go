@@ -1,11 +1,34 @@
 package models
 
-import "github.com/sirupsen/logrus"
+import (
+	"sync"
 
-// logger is a package-global logger which can be used by model methods (this prevents us having to pass a logger all over the place):
-var logger *logrus.Logger = logrus.New()
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/logging"
+	"github.com/sirupsen/logrus"
+)
 
-// SetLogger allows other packages to update the logger:
+var (
+	defaultLogger      logging.Logger = logging.NoopLogger{}
+	defaultLoggerMutex sync.RWMutex
+)
+
+// SetLogger sets the logger used by the model methods which don't take one (eg Evaluate and Calculate), which otherwise don't log anything:
+//
+// Deprecated: use the methods which take a logger (eg EvaluateWithLogger and CalculateWithLogger), or configure clients with Config.WithLogger.
 func SetLogger(newLogger *logrus.Logger) {
-	logger = newLogger
+	defaultLoggerMutex.Lock()
+	defer defaultLoggerMutex.Unlock()
+
+	if newLogger == nil {
+		defaultLogger = logging.NoopLogger{}
+		return
+	}
+	defaultLogger = logging.NewLogrusLogger(newLogger)
+}
+
+// getDefaultLogger returns the logger used by the model methods which don't take one:
+func getDefaultLogger() logging.Logger {
+	defaultLoggerMutex.RLock()
+	defer defaultLoggerMutex.RUnlock()
+	return defaultLogger
 }
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/strategies"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetLogger(t *testing.T) {
+
+	// A feature with a strategy which won't match:
+	featureStrategies := Strategies{
+		{
+			ID:    "strategy1",
+			Value: "for the russians",
+			Attributes: []*StrategyAttribute{
+				{FieldName: strategies.FieldNameCountry, Conditional: strategies.ConditionalEquals, Type: strategies.TypeString, Values: []interface{}{"russia"}},
+			},
+		},
+	}
+	clientContext := &Context{Country: "new_zealand", Userkey: "someone"}
+
+	// Without a logger the old methods still work (and don't log anything):
+	assert.Nil(t, featureStrategies.Calculate(clientContext))
+
+	// Set a (deprecated) package-level logger:
+	logger := logrus.New()
+	logger.SetLevel(logrus.TraceLevel)
+	logBuffer := new(bytes.Buffer)
+	logger.SetOutput(logBuffer)
+	SetLogger(logger)
+	defer SetLogger(nil)
+
+	// The old methods log to it:
+	assert.Nil(t, featureStrategies.Calculate(clientContext))
+	assert.Contains(t, logBuffer.String(), "Checking strategy (strategy1)")
+
+	// The methods which take a logger don't:
+	logBuffer.Reset()
+	assert.Nil(t, featureStrategies.CalculateWithLogger(clientContext, nil))
+	assert.Empty(t, logBuffer.String())
+}
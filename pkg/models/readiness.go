@@ -0,0 +1,15 @@
+package models
+
+// Readiness describes whether a client is ready to serve features:
+type Readiness string
+
+const (
+	// ReadinessFailed means that the client hit an unrecoverable error before it was ready (this is final):
+	ReadinessFailed Readiness = "failed"
+	// ReadinessNotReady means that the client hasn't received any data yet:
+	ReadinessNotReady Readiness = "not_ready"
+	// ReadinessReady means that the client has received data, and is hearing from the server:
+	ReadinessReady Readiness = "ready"
+	// ReadinessStale means that the client has received data, but hasn't heard from the server for too long (see Config.StaleAfter):
+	ReadinessStale Readiness = "stale"
+)
@@ -0,0 +1,13 @@
+package models
+
+// SharedStrategy defines model for a shared (application-level) rollout strategy, which can be referenced by many features:
+type SharedStrategy struct {
+	Attributes []*StrategyAttribute `json:"attributes"`
+	ID         string               `json:"id"`
+	Name       string               `json:"name"`
+	Percentage float64              `json:"percentage"`
+	Version    int64                `json:"version,omitempty"`
+}
+
+// SharedStrategies maps shared strategies by their ID:
+type SharedStrategies map[string]*SharedStrategy
@@ -0,0 +1,69 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/strategies"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSharedStrategies(t *testing.T) {
+
+	// Some strategies, one inline and one referencing a shared strategy:
+	testStrategies := Strategies{
+		{
+			ID:               "s1",
+			SharedStrategyID: "shared-country",
+			Value:            "this is for the shared countries",
+		},
+		{
+			ID:    "s2",
+			Name:  "inline-platform",
+			Value: "this is for linux users",
+			Attributes: []*StrategyAttribute{
+				{
+					ID:          "a2",
+					Conditional: strategies.ConditionalEquals,
+					FieldName:   strategies.FieldNamePlatform,
+					Values:      []interface{}{string(ContextPlatformLinux)},
+					Type:        strategies.TypeString,
+				},
+			},
+		},
+	}
+	assert.True(t, testStrategies.ReferencesSharedStrategies())
+	assert.True(t, testStrategies.ReferencesSharedStrategy("shared-country"))
+	assert.False(t, testStrategies.ReferencesSharedStrategy("something-else"))
+
+	// Unresolved references must never match (even though they have no attributes):
+	clientContext := &Context{Country: ContextCountryNewZealand, Platform: ContextPlatformLinux}
+	assert.Equal(t, "this is for linux users", testStrategies.Calculate(clientContext))
+
+	// Unknown shared strategies stay unresolved:
+	assert.Equal(t, "this is for linux users", testStrategies.Resolve(SharedStrategies{}).Calculate(clientContext))
+
+	// Resolve against a shared strategy definition:
+	sharedStrategies := SharedStrategies{
+		"shared-country": {
+			ID:   "shared-country",
+			Name: "shared-country",
+			Attributes: []*StrategyAttribute{
+				{
+					ID:          "sa1",
+					Conditional: strategies.ConditionalEquals,
+					FieldName:   strategies.FieldNameCountry,
+					Values:      []interface{}{string(ContextCountryNewZealand)},
+					Type:        strategies.TypeString,
+				},
+			},
+		},
+	}
+	resolvedStrategies := testStrategies.Resolve(sharedStrategies)
+	assert.Equal(t, "shared-country", resolvedStrategies[0].Name)
+	assert.Len(t, resolvedStrategies[0].Attributes, 1)
+	assert.Equal(t, "this is for the shared countries", resolvedStrategies.Calculate(clientContext))
+
+	// The original strategies are left alone:
+	assert.Empty(t, testStrategies[0].Attributes)
+	assert.Equal(t, "this is for linux users", testStrategies.Calculate(clientContext))
+}
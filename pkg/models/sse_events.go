@@ -26,3 +26,12 @@ const FHFeature Event = "feature"
 
 // FHFeatures is a FeauterHub SSE event (an entire feature set):
 const FHFeatures Event = "features"
+
+// FHDeleteStrategy is a FeatureHub SSE event (telling us that a shared strategy has been deleted):
+const FHDeleteStrategy Event = "delete_strategy"
+
+// FHStrategy is a FeatureHub SSE event (an update to a specific shared strategy):
+const FHStrategy Event = "strategy"
+
+// FHStrategies is a FeatureHub SSE event (an entire set of shared strategies):
+const FHStrategies Event = "strategies"
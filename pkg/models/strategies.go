@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"math"
 
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/logging"
 	"github.com/featurehub-io/featurehub-go-sdk/pkg/strategies"
 	"github.com/spaolacci/murmur3"
 )
@@ -17,11 +18,13 @@ type Strategies []Strategy
 
 // Strategy defines model for Strategy.
 type Strategy struct {
-	Attributes []*StrategyAttribute `json:"attributes"`
-	ID         string               `json:"id"`
-	Name       string               `json:"name"`
-	Percentage float64              `json:"percentage"`
-	Value      interface{}          `json:"value,omitempty"` // this value is used if it is a simple attribute or percentage. If it is more complex then the pairs are passed
+	Attributes       []*StrategyAttribute `json:"attributes"`
+	ID               string               `json:"id"`
+	Name             string               `json:"name"`
+	Percentage       float64              `json:"percentage"`
+	SharedStrategyID string               `json:"sharedStrategyId,omitempty"` // references a shared (application) strategy, which provides the percentage and attributes
+	Value            interface{}          `json:"value,omitempty"`            // this value is used if it is a simple attribute or percentage. If it is more complex then the pairs are passed
+	resolved         bool                 // set once a shared strategy reference has been resolved
 }
 
 // StrategyAttribute defines a more complex strategy than simple percentages:
@@ -35,37 +38,200 @@ type StrategyAttribute struct {
 
 // Calculate contains the logic to check each strategy and decide which one applies (if any):
 func (ss Strategies) Calculate(clientContext *Context) interface{} {
+	return ss.CalculateWithLogger(clientContext, getDefaultLogger())
+}
+
+// CalculateWithLogger is Calculate, logging its reasoning to the given logger (which may be nil):
+func (ss Strategies) CalculateWithLogger(clientContext *Context, logger logging.Logger) interface{} {
+
+	// Use the value of whichever strategy matched:
+	if strategy := ss.MatchWithLogger(clientContext, logger); strategy != nil {
+		return strategy.Value
+	}
+
+	// Otherwise just return nil:
+	return nil
+}
+
+// Copy returns a deep copy of these strategies (including their attributes):
+func (ss Strategies) Copy() Strategies {
+	if ss == nil {
+		return nil
+	}
+	strategies := make(Strategies, len(ss))
+	for i, strategy := range ss {
+		strategies[i] = strategy
+		if strategy.Attributes == nil {
+			continue
+		}
+		strategies[i].Attributes = make([]*StrategyAttribute, len(strategy.Attributes))
+		for j, attribute := range strategy.Attributes {
+			if attribute == nil {
+				continue
+			}
+			attributeCopy := *attribute
+			attributeCopy.Values = append([]interface{}(nil), attribute.Values...)
+			strategies[i].Attributes[j] = &attributeCopy
+		}
+	}
+	return strategies
+}
+
+// Match returns the first strategy which applies to the given context (or nil if none of them do):
+func (ss Strategies) Match(clientContext *Context) *Strategy {
+	return ss.MatchWithLogger(clientContext, getDefaultLogger())
+}
+
+// MatchWithLogger is Match, logging its reasoning to the given logger (which may be nil):
+func (ss Strategies) MatchWithLogger(clientContext *Context, logger logging.Logger) *Strategy {
+	logger = logging.OrNoop(logger)
 
 	// Pre-calculate our hashKey:
 	hashKey, _ := clientContext.UniqueKey()
 
-	// Go through the available strategies:
+	return ss.match(clientContext, logger, func(strategy Strategy) bool {
+		return strategy.proceedWithPercentage(hashKey, logger)
+	})
+}
+
+// MatchAssigned is like Match, but percentage-based rules only match the strategy the context has been assigned (an empty ID means none of them):
+func (ss Strategies) MatchAssigned(clientContext *Context, strategyID string, logger logging.Logger) *Strategy {
+	logger = logging.OrNoop(logger)
+
+	return ss.match(clientContext, logger, func(strategy Strategy) bool {
+		return strategy.Percentage == 0 || strategy.ID == strategyID
+	})
+}
+
+// HasPercentage tells us whether any of these strategies have a percentage-based rule:
+func (ss Strategies) HasPercentage() bool {
 	for _, strategy := range ss {
+		if strategy.Percentage > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesPercentageAttributes tells us whether the context passes the attribute-based rules of any (resolved) percentage strategy:
+func (ss Strategies) matchesPercentageAttributes(clientContext *Context, logger logging.Logger) bool {
+	logger = logging.OrNoop(logger)
+
+	for _, strategy := range ss {
+		if strategy.Percentage == 0 || (len(strategy.SharedStrategyID) > 0 && !strategy.resolved) {
+			continue
+		}
+		if strategy.proceedWithAttributes(clientContext, logger) {
+			return true
+		}
+	}
+	return false
+}
+
+// match returns the first strategy which passes the given percentage check and applies to the given context (or nil if none of them do):
+func (ss Strategies) match(clientContext *Context, logger logging.Logger, proceedWithPercentage func(strategy Strategy) bool) *Strategy {
+
+	// Go through the available strategies:
+	for i, strategy := range ss {
 		logger.Tracef("Checking strategy (%s)", strategy.ID)
 
+		// Strategies which reference a shared strategy can't match until they have been resolved:
+		if len(strategy.SharedStrategyID) > 0 && !strategy.resolved {
+			logger.Tracef("Unresolved shared strategy (%s:%s) - trying next strategy", strategy.ID, strategy.SharedStrategyID)
+			continue
+		}
+
 		// Check if we match any percentage-based rule:
-		if !strategy.proceedWithPercentage(hashKey) {
+		if !proceedWithPercentage(strategy) {
 			logger.Tracef("Failed strategy (%s) percentage - trying next strategy", strategy.ID)
 			continue
 		}
 
 		// Check if we match the attribute-based rules:
-		if !strategy.proceedWithAttributes(clientContext) {
+		if !strategy.proceedWithAttributes(clientContext, logger) {
 			logger.Tracef("Failed strategy (%s) attributes - trying next strategy", strategy.ID)
 			continue
 		}
 
-		// If we got this far then we matched this strategy, so we return its value:
+		// If we got this far then we matched this strategy, so we return it:
 		logger.Debugf("Matched strategy (%s:%s)", strategy.ID, strategy.Name)
-		return strategy.Value
+		return &ss[i]
 	}
 
 	// Otherwise just return nil:
 	return nil
 }
 
+// Resolve returns a copy of these strategies with any shared strategy references filled in from the given shared strategies:
+// - the percentage and attributes are taken from the shared strategy, the value stays with the feature
+// - references to shared strategies which we don't know about are left unresolved (and will never match)
+func (ss Strategies) Resolve(sharedStrategies SharedStrategies) Strategies {
+	return ss.ResolveWithLogger(sharedStrategies, getDefaultLogger())
+}
+
+// ResolveWithLogger is Resolve, logging any references which can't be resolved to the given logger (which may be nil):
+func (ss Strategies) ResolveWithLogger(sharedStrategies SharedStrategies, logger logging.Logger) Strategies {
+	if ss == nil {
+		return nil
+	}
+	logger = logging.OrNoop(logger)
+
+	resolvedStrategies := make(Strategies, len(ss))
+	for i, strategy := range ss {
+		resolvedStrategies[i] = strategy
+
+		// Inline strategies don't need anything else:
+		if len(strategy.SharedStrategyID) == 0 {
+			continue
+		}
+
+		// Look up the shared strategy:
+		sharedStrategy, ok := sharedStrategies[strategy.SharedStrategyID]
+		if !ok {
+			logger.Tracef("Shared strategy (%s) not found for strategy (%s)", strategy.SharedStrategyID, strategy.ID)
+			resolvedStrategies[i].resolved = false
+			continue
+		}
+
+		// Take the matching rules from the shared strategy:
+		resolvedStrategies[i].Attributes = sharedStrategy.Attributes
+		resolvedStrategies[i].Percentage = sharedStrategy.Percentage
+		if len(strategy.Name) == 0 {
+			resolvedStrategies[i].Name = sharedStrategy.Name
+		}
+		resolvedStrategies[i].resolved = true
+	}
+
+	return resolvedStrategies
+}
+
+// ReferencesSharedStrategies tells us whether any of these strategies reference a shared strategy:
+func (ss Strategies) ReferencesSharedStrategies() bool {
+	for _, strategy := range ss {
+		if len(strategy.SharedStrategyID) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// ReferencesSharedStrategy tells us whether any of these strategies reference the shared strategy with the given ID:
+func (ss Strategies) ReferencesSharedStrategy(sharedStrategyID string) bool {
+	for _, strategy := range ss {
+		if strategy.SharedStrategyID == sharedStrategyID {
+			return true
+		}
+	}
+	return false
+}
+
+// PercentageBucket returns the bucket (0 to 1000000, the same scale as strategy percentages) which percentage strategies put the given hash key in:
+func PercentageBucket(hashKey string) float64 {
+	return float64(murmur3.Sum32([]byte(hashKey))) / maxMurmur32Hash * 1000000
+}
+
 // proceedWithPercentage contains the logic to match percentage-based rules on a user-key / session-key hash:
-func (s Strategy) proceedWithPercentage(hashKey string) bool {
+func (s Strategy) proceedWithPercentage(hashKey string, logger logging.Logger) bool {
 
 	// Make sure we have a percentage rule:
 	if s.Percentage == 0 {
@@ -78,7 +244,7 @@ func (s Strategy) proceedWithPercentage(hashKey string) bool {
 	}
 
 	// Murmur32 sum on the key gives us a consistent number:
-	hashedPercentage := float64(murmur3.Sum32([]byte(hashKey))) / maxMurmur32Hash * 1000000
+	hashedPercentage := PercentageBucket(hashKey)
 
 	// If our calculated percentage is less than the strategy percentage then we matched!
 	if hashedPercentage <= s.Percentage {
@@ -91,7 +257,7 @@ func (s Strategy) proceedWithPercentage(hashKey string) bool {
 }
 
 // proceedWithPercentage contains the logic to match attribute-based rules on the rest of the client context:
-func (s Strategy) proceedWithAttributes(clientContext *Context) bool {
+func (s Strategy) proceedWithAttributes(clientContext *Context, logger logging.Logger) bool {
 
 	// We can't continue without a clientContext:
 	if clientContext == nil {
@@ -106,7 +272,7 @@ func (s Strategy) proceedWithAttributes(clientContext *Context) bool {
 
 		// Match by country name:
 		case strategies.FieldNameCountry:
-			matched, err := sa.matchType(sa.Values, fmt.Sprintf("%s", clientContext.Country))
+			matched, err := sa.matchType(sa.Values, fmt.Sprintf("%s", clientContext.Country), logger)
 			if err != nil {
 				logger.WithError(err).Error("Unable to match type")
 			}
@@ -118,7 +284,7 @@ func (s Strategy) proceedWithAttributes(clientContext *Context) bool {
 
 		// Match by device type:
 		case strategies.FieldNameDevice:
-			matched, err := sa.matchType(sa.Values, fmt.Sprintf("%s", clientContext.Device))
+			matched, err := sa.matchType(sa.Values, fmt.Sprintf("%s", clientContext.Device), logger)
 			if err != nil {
 				logger.WithError(err).Error("Unable to match type")
 			}
@@ -130,7 +296,7 @@ func (s Strategy) proceedWithAttributes(clientContext *Context) bool {
 
 		// Match by platform:
 		case strategies.FieldNamePlatform:
-			matched, err := sa.matchType(sa.Values, fmt.Sprintf("%s", clientContext.Platform))
+			matched, err := sa.matchType(sa.Values, fmt.Sprintf("%s", clientContext.Platform), logger)
 			if err != nil {
 				logger.WithError(err).Error("Unable to match type")
 			}
@@ -143,7 +309,7 @@ func (s Strategy) proceedWithAttributes(clientContext *Context) bool {
 		// Match by userkey:
 		case strategies.FieldNameUserkey:
 			logger.Trace("Trying userkey")
-			matched, err := sa.matchType(sa.Values, fmt.Sprintf("%s", clientContext.Userkey))
+			matched, err := sa.matchType(sa.Values, fmt.Sprintf("%s", clientContext.Userkey), logger)
 			if err != nil {
 				logger.WithError(err).Error("Unable to match type")
 			}
@@ -156,7 +322,7 @@ func (s Strategy) proceedWithAttributes(clientContext *Context) bool {
 		// Match by version:
 		case strategies.FieldNameVersion:
 			logger.Trace("Trying version")
-			matched, err := sa.matchType(sa.Values, fmt.Sprintf("%s", clientContext.Version))
+			matched, err := sa.matchType(sa.Values, fmt.Sprintf("%s", clientContext.Version), logger)
 			if err != nil {
 				logger.WithError(err).Error("Unable to match type")
 			}
@@ -174,7 +340,7 @@ func (s Strategy) proceedWithAttributes(clientContext *Context) bool {
 			// Look up the field by name in the clientContext.Custom attribute:
 			customContextValue, ok := clientContext.Custom[sa.FieldName]
 			if ok {
-				matched, err := sa.matchType(sa.Values, customContextValue)
+				matched, err := sa.matchType(sa.Values, customContextValue, logger)
 				if err != nil {
 					logger.WithError(err).Error("Unable to match type")
 				}
@@ -193,7 +359,7 @@ func (s Strategy) proceedWithAttributes(clientContext *Context) bool {
 }
 
 // matchType checks the given value against the given slice of options with the attribute's conditional logic:
-func (sa *StrategyAttribute) matchType(options []interface{}, value interface{}) (bool, error) {
+func (sa *StrategyAttribute) matchType(options []interface{}, value interface{}, logger logging.Logger) (bool, error) {
 
 	// Handle the different conditionals available to us:
 	logger.Tracef("Looking for %v within %v", value, options)
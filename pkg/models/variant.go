@@ -0,0 +1,13 @@
+package models
+
+// Variant is the variant of an experiment (a STRING feature) which a context has been assigned to:
+type Variant struct {
+	Bucket       int    `json:"bucket"`               // The context's percentage bucket (0 to 999999, the same scale as strategy percentages)
+	Experiment   string `json:"experiment"`           // The key of the feature
+	Holdout      bool   `json:"holdout,omitempty"`    // The context is in the holdout group (so it gets the default value)
+	InExperiment bool   `json:"inExperiment"`         // Whether the context is taking part (not if it is held out, excluded by a layer or has no userkey or session)
+	Layer        string `json:"layer,omitempty"`      // The mutual-exclusion layer the experiment is in (if any)
+	Name         string `json:"name"`                 // The name of the variant (ie the value of the feature)
+	StrategyID   string `json:"strategyId,omitempty"` // ID of the matched strategy (empty if the default value applied)
+	Version      int64  `json:"version"`              // The version of the feature
+}
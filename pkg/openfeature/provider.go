@@ -0,0 +1,364 @@
+package openfeature
+
+import (
+	"context"
+	"encoding/json"
+	goerrors "errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/errors"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
+	streamingclient "github.com/featurehub-io/featurehub-go-sdk/pkg/streaming-client"
+	of "github.com/open-feature/go-sdk/openfeature"
+)
+
+const (
+	providerName = "FeatureHub"
+
+	// Variant reported when no strategy matched:
+	variantDefault = "default"
+
+	// How often to check the status of the client:
+	defaultStatusInterval = time.Second
+)
+
+// Provider implements an OpenFeature provider (FeatureProvider, StateHandler and EventHandler) backed by a StreamingClient:
+// - OpenFeature evaluation contexts are mapped onto a models.Context (see ContextFromEvaluationContext)
+// - the provider is ready once the client has data, in the error state if the client fails first, and stale while the client is stale (or closed)
+// - configuration-changed events are emitted whenever a feature is updated or deleted
+type Provider struct {
+	client         *streamingclient.StreamingClient
+	events         chan of.Event
+	notifierUUID   string
+	state          of.State
+	stateMutex     sync.Mutex
+	statusInterval time.Duration
+	stop           chan struct{}
+}
+
+// NewProvider returns a Provider for the given client (which should already have been started):
+func NewProvider(client *streamingclient.StreamingClient) *Provider {
+	return &Provider{
+		client:         client,
+		events:         make(chan of.Event, 100),
+		state:          of.NotReadyState,
+		statusInterval: defaultStatusInterval,
+		stop:           make(chan struct{}),
+	}
+}
+
+// Metadata describes the provider:
+func (p *Provider) Metadata() of.Metadata {
+	return of.Metadata{Name: providerName}
+}
+
+// Hooks returns the provider's hooks (we don't have any):
+func (p *Provider) Hooks() []of.Hook {
+	return []of.Hook{}
+}
+
+// BooleanEvaluation resolves a BOOLEAN feature:
+func (p *Provider) BooleanEvaluation(ctx context.Context, flag string, defaultValue bool, evalCtx of.FlattenedContext) of.BoolResolutionDetail {
+	evaluatedFeature, resolutionDetail := p.evaluate(ctx, flag, models.TypeBoolean, evalCtx)
+	if evaluatedFeature == nil {
+		return of.BoolResolutionDetail{Value: defaultValue, ProviderResolutionDetail: resolutionDetail}
+	}
+
+	// Assert the value:
+	value, ok := evaluatedFeature.Value.(bool)
+	if !ok {
+		return of.BoolResolutionDetail{Value: defaultValue, ProviderResolutionDetail: typeMismatch("Unable to assert value as a bool")}
+	}
+
+	return of.BoolResolutionDetail{Value: value, ProviderResolutionDetail: resolutionDetail}
+}
+
+// FloatEvaluation resolves a NUMBER feature:
+func (p *Provider) FloatEvaluation(ctx context.Context, flag string, defaultValue float64, evalCtx of.FlattenedContext) of.FloatResolutionDetail {
+	evaluatedFeature, resolutionDetail := p.evaluate(ctx, flag, models.TypeNumber, evalCtx)
+	if evaluatedFeature == nil {
+		return of.FloatResolutionDetail{Value: defaultValue, ProviderResolutionDetail: resolutionDetail}
+	}
+
+	// Assert the value:
+	value, ok := evaluatedFeature.Value.(float64)
+	if !ok {
+		return of.FloatResolutionDetail{Value: defaultValue, ProviderResolutionDetail: typeMismatch("Unable to assert value as a float64")}
+	}
+
+	return of.FloatResolutionDetail{Value: value, ProviderResolutionDetail: resolutionDetail}
+}
+
+// IntEvaluation resolves a NUMBER feature (as long as its value is a whole number):
+func (p *Provider) IntEvaluation(ctx context.Context, flag string, defaultValue int64, evalCtx of.FlattenedContext) of.IntResolutionDetail {
+	evaluatedFeature, resolutionDetail := p.evaluate(ctx, flag, models.TypeNumber, evalCtx)
+	if evaluatedFeature == nil {
+		return of.IntResolutionDetail{Value: defaultValue, ProviderResolutionDetail: resolutionDetail}
+	}
+
+	// Assert the value:
+	value, ok := evaluatedFeature.Value.(float64)
+	if !ok || value != math.Trunc(value) {
+		return of.IntResolutionDetail{Value: defaultValue, ProviderResolutionDetail: typeMismatch("Unable to assert value as an int64")}
+	}
+
+	return of.IntResolutionDetail{Value: int64(value), ProviderResolutionDetail: resolutionDetail}
+}
+
+// ObjectEvaluation resolves a JSON feature (unmarshaled into an interface{}):
+func (p *Provider) ObjectEvaluation(ctx context.Context, flag string, defaultValue interface{}, evalCtx of.FlattenedContext) of.InterfaceResolutionDetail {
+	evaluatedFeature, resolutionDetail := p.evaluate(ctx, flag, models.TypeJSON, evalCtx)
+	if evaluatedFeature == nil {
+		return of.InterfaceResolutionDetail{Value: defaultValue, ProviderResolutionDetail: resolutionDetail}
+	}
+
+	// Assert the value:
+	rawJSON, ok := evaluatedFeature.Value.(string)
+	if !ok {
+		return of.InterfaceResolutionDetail{Value: defaultValue, ProviderResolutionDetail: typeMismatch("Unable to assert value as a string")}
+	}
+
+	// Unmarshal it:
+	var value interface{}
+	if err := json.Unmarshal([]byte(rawJSON), &value); err != nil {
+		return of.InterfaceResolutionDetail{
+			Value: defaultValue,
+			ProviderResolutionDetail: of.ProviderResolutionDetail{
+				Reason:          of.ErrorReason,
+				ResolutionError: of.NewParseErrorResolutionError(err.Error()),
+			},
+		}
+	}
+
+	return of.InterfaceResolutionDetail{Value: value, ProviderResolutionDetail: resolutionDetail}
+}
+
+// StringEvaluation resolves a STRING feature:
+func (p *Provider) StringEvaluation(ctx context.Context, flag string, defaultValue string, evalCtx of.FlattenedContext) of.StringResolutionDetail {
+	evaluatedFeature, resolutionDetail := p.evaluate(ctx, flag, models.TypeString, evalCtx)
+	if evaluatedFeature == nil {
+		return of.StringResolutionDetail{Value: defaultValue, ProviderResolutionDetail: resolutionDetail}
+	}
+
+	// Assert the value:
+	value, ok := evaluatedFeature.Value.(string)
+	if !ok {
+		return of.StringResolutionDetail{Value: defaultValue, ProviderResolutionDetail: typeMismatch("Unable to assert value as a string")}
+	}
+
+	return of.StringResolutionDetail{Value: value, ProviderResolutionDetail: resolutionDetail}
+}
+
+// EventChannel delivers provider events to the OpenFeature SDK:
+func (p *Provider) EventChannel() <-chan of.Event {
+	return p.events
+}
+
+// Init blocks until the client has data (or fails, in which case the provider goes into the error state), then starts emitting events:
+func (p *Provider) Init(evaluationContext of.EvaluationContext) error {
+
+	// Stop waiting if we're shut down:
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-p.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	// Wait for data:
+	if err := p.client.WaitUntilReady(ctx); err != nil {
+		select {
+		case <-p.stop:
+			return fmt.Errorf("provider was shut down before the client had data")
+		default:
+			p.setState(of.ErrorState)
+			return fmt.Errorf("the FeatureHub client failed before it had data: %w", err)
+		}
+	}
+	p.setState(of.ReadyState)
+
+	// Tell OpenFeature about every change to a feature:
+	p.notifierUUID = p.client.AddNotifierAllFeatures(func(feature *models.FeatureState) {
+		p.emit(of.ProviderConfigChange, "Feature updated", feature.Key)
+	})
+
+	// Keep an eye on the connection:
+	go p.watchStatus()
+
+	return nil
+}
+
+// Shutdown stops emitting events:
+func (p *Provider) Shutdown() {
+	select {
+	case <-p.stop:
+		return
+	default:
+		close(p.stop)
+	}
+
+	if p.notifierUUID != "" {
+		p.client.DeleteNotifier(streamingclient.NotifierKeyAllFeatures, p.notifierUUID)
+	}
+	p.setState(of.NotReadyState)
+}
+
+// Status returns the current state of the provider:
+func (p *Provider) Status() of.State {
+	p.stateMutex.Lock()
+	defer p.stateMutex.Unlock()
+	return p.state
+}
+
+// ContextFromEvaluationContext maps an OpenFeature evaluation context onto a FeatureHub context:
+// - the targeting key becomes the userkey
+// - "userkey", "session", "device", "platform", "country" and "version" attributes populate the corresponding fields
+// - everything else is a custom attribute
+func ContextFromEvaluationContext(evalCtx of.FlattenedContext) *models.Context {
+	clientContext := &models.Context{
+		Custom: make(map[string]interface{}),
+	}
+
+	for name, value := range evalCtx {
+		stringValue, isString := value.(string)
+
+		switch {
+		case name == of.TargetingKey && isString:
+			clientContext.Userkey = stringValue
+		case name == "userkey" && isString:
+			if clientContext.Userkey == "" {
+				clientContext.Userkey = stringValue
+			}
+		case name == "session" && isString:
+			clientContext.Session = stringValue
+		case name == "device" && isString:
+			clientContext.Device = models.ContextDevice(stringValue)
+		case name == "platform" && isString:
+			clientContext.Platform = models.ContextPlatform(stringValue)
+		case name == "country" && isString:
+			clientContext.Country = models.ContextCountry(stringValue)
+		case name == "version" && isString:
+			clientContext.Version = stringValue
+		default:
+			clientContext.Custom[name] = value
+		}
+	}
+
+	return clientContext
+}
+
+// evaluate applies the evaluation context to a feature, returning either the evaluated feature or details of why it couldn't be evaluated:
+func (p *Provider) evaluate(ctx context.Context, flag string, expectedType models.FeatureValueType, evalCtx of.FlattenedContext) (*models.EvaluatedFeature, of.ProviderResolutionDetail) {
+
+	// We can't do anything until the client has data:
+	if !p.client.Status().HasData {
+		return nil, of.ProviderResolutionDetail{
+			Reason:          of.ErrorReason,
+			ResolutionError: of.NewProviderNotReadyResolutionError("The FeatureHub client has no data yet"),
+		}
+	}
+
+	// Apply the context:
+	evaluatedFeature, err := p.client.WithContext(ContextFromEvaluationContext(evalCtx)).WithTraceContext(ctx).Evaluate(flag, expectedType)
+	if err != nil {
+		return nil, of.ProviderResolutionDetail{
+			Reason:          of.ErrorReason,
+			ResolutionError: resolutionError(err),
+		}
+	}
+
+	// Describe how we got here:
+	resolutionDetail := of.ProviderResolutionDetail{
+		Reason:  of.DefaultReason,
+		Variant: variantDefault,
+		FlagMetadata: of.FlagMetadata{
+			"id":      evaluatedFeature.ID,
+			"version": evaluatedFeature.Version,
+		},
+	}
+	if evaluatedFeature.Outcome() == models.EvaluationOutcomeStrategy {
+		resolutionDetail.Reason = of.TargetingMatchReason
+		resolutionDetail.Variant = evaluatedFeature.StrategyID
+	}
+
+	return evaluatedFeature, resolutionDetail
+}
+
+// emit sends an event to the OpenFeature SDK (unless we've been shut down):
+func (p *Provider) emit(eventType of.EventType, message string, flagChanges ...string) {
+	event := of.Event{
+		ProviderName: providerName,
+		EventType:    eventType,
+		ProviderEventDetails: of.ProviderEventDetails{
+			Message:     message,
+			FlagChanges: flagChanges,
+		},
+	}
+
+	select {
+	case p.events <- event:
+	case <-p.stop:
+	}
+}
+
+// setState records the current state of the provider:
+func (p *Provider) setState(state of.State) {
+	p.stateMutex.Lock()
+	defer p.stateMutex.Unlock()
+	p.state = state
+}
+
+// watchStatus emits stale events while the client is stale (or closed), and ready events when it comes back:
+func (p *Provider) watchStatus() {
+	ticker := time.NewTicker(p.statusInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+		}
+
+		// A closed client won't receive any more updates, so it is as good as stale:
+		status := p.client.Status()
+		stale := status.Readiness == models.ReadinessStale || !status.Running
+		switch state := p.Status(); {
+		case stale && state == of.ReadyState:
+			p.setState(of.StaleState)
+			p.emit(of.ProviderStale, "The FeatureHub client is stale")
+		case !stale && state == of.StaleState:
+			p.setState(of.ReadyState)
+			p.emit(of.ProviderReady, "The FeatureHub client is hearing from the server again")
+		}
+	}
+}
+
+// resolutionError maps errors from the client onto OpenFeature resolution errors:
+func resolutionError(err error) of.ResolutionError {
+	var errFeatureNotFound *errors.ErrFeatureNotFound
+	var errInvalidType *errors.ErrInvalidType
+
+	switch {
+	case goerrors.As(err, &errFeatureNotFound):
+		return of.NewFlagNotFoundResolutionError(err.Error())
+	case goerrors.As(err, &errInvalidType):
+		return of.NewTypeMismatchResolutionError(err.Error())
+	default:
+		return of.NewGeneralResolutionError(err.Error())
+	}
+}
+
+// typeMismatch describes a value which couldn't be asserted as the requested type:
+func typeMismatch(message string) of.ProviderResolutionDetail {
+	return of.ProviderResolutionDetail{
+		Reason:          of.ErrorReason,
+		ResolutionError: of.NewTypeMismatchResolutionError(message),
+	}
+}
@@ -0,0 +1,192 @@
+package openfeature
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
+	streamingclient "github.com/featurehub-io/featurehub-go-sdk/pkg/streaming-client"
+	of "github.com/open-feature/go-sdk/openfeature"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+const testFeaturesJSON = `[{"id":"1","key":"booleanfeature","type":"BOOLEAN","value":true,"version":2,"strategies":[{"id":"s1","name":"linux","value":false,"attributes":[{"id":"a1","conditional":"EQUALS","fieldName":"platform","values":["linux"],"type":"STRING"}]}]},{"id":"2","key":"stringfeature","type":"STRING","value":"this is a string","version":1},{"id":"3","key":"numberfeature","type":"NUMBER","value":42,"version":1},{"id":"4","key":"jsonfeature","type":"JSON","value":"{\"fruit\":\"apple\"}","version":1}]`
+
+func TestProvider(t *testing.T) {
+
+	// A fake FeatureHub server which streams features, then whatever else we give it:
+	serverEvents := make(chan string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, "event: features\ndata: %s\n\n", testFeaturesJSON)
+		w.(http.Flusher).Flush()
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case serverEvent := <-serverEvents:
+				fmt.Fprint(w, serverEvent)
+				w.(http.Flusher).Flush()
+			}
+		}
+	}))
+	defer server.Close()
+	defer server.CloseClientConnections()
+
+	// Connect a client:
+	config := streamingclient.NewConfig(server.URL, "default/environment-id/my-secret-api-key").WithLogLevel(logrus.ErrorLevel).WithWaitForData(true)
+	client, err := streamingclient.NewStreamingClient(config)
+	assert.NoError(t, err)
+	client.Start()
+
+	// Register a provider with OpenFeature:
+	provider := NewProvider(client)
+	provider.statusInterval = 10 * time.Millisecond
+	assert.Equal(t, of.NotReadyState, provider.Status())
+	assert.NoError(t, of.SetNamedProviderAndWait("featurehub", provider))
+	defer of.Shutdown()
+	assert.Equal(t, of.ReadyState, provider.Status())
+	ofClient := of.NewClient("featurehub")
+
+	// Listen for events:
+	configChanges := make(chan of.EventDetails, 10)
+	configChangeCallback := func(details of.EventDetails) { configChanges <- details }
+	ofClient.AddHandler(of.ProviderConfigChange, &configChangeCallback)
+	staleEvents := make(chan of.EventDetails, 10)
+	staleCallback := func(details of.EventDetails) { staleEvents <- details }
+	ofClient.AddHandler(of.ProviderStale, &staleCallback)
+
+	// A strategy matches:
+	ctx := context.Background()
+	linuxContext := of.NewEvaluationContext("user1", map[string]interface{}{"platform": "linux"})
+	booleanDetails, err := ofClient.BooleanValueDetails(ctx, "booleanfeature", true, linuxContext)
+	assert.NoError(t, err)
+	assert.False(t, booleanDetails.Value)
+	assert.Equal(t, of.TargetingMatchReason, booleanDetails.Reason)
+	assert.Equal(t, "s1", booleanDetails.Variant)
+	assert.Equal(t, int64(2), booleanDetails.FlagMetadata["version"])
+
+	// No strategy matches:
+	booleanDetails, err = ofClient.BooleanValueDetails(ctx, "booleanfeature", false, of.NewEvaluationContext("user1", nil))
+	assert.NoError(t, err)
+	assert.True(t, booleanDetails.Value)
+	assert.Equal(t, of.DefaultReason, booleanDetails.Reason)
+	assert.Equal(t, variantDefault, booleanDetails.Variant)
+
+	// Other types:
+	stringValue, err := ofClient.StringValue(ctx, "stringfeature", "", of.EvaluationContext{})
+	assert.NoError(t, err)
+	assert.Equal(t, "this is a string", stringValue)
+	floatValue, err := ofClient.FloatValue(ctx, "numberfeature", 0, of.EvaluationContext{})
+	assert.NoError(t, err)
+	assert.Equal(t, float64(42), floatValue)
+	intValue, err := ofClient.IntValue(ctx, "numberfeature", 0, of.EvaluationContext{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), intValue)
+	objectValue, err := ofClient.ObjectValue(ctx, "jsonfeature", nil, of.EvaluationContext{})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"fruit": "apple"}, objectValue)
+
+	// Errors are mapped to OpenFeature error codes:
+	booleanDetails, err = ofClient.BooleanValueDetails(ctx, "nosuchfeature", true, of.EvaluationContext{})
+	assert.Error(t, err)
+	assert.True(t, booleanDetails.Value)
+	assert.Equal(t, of.FlagNotFoundCode, booleanDetails.ErrorCode)
+	assert.Equal(t, of.ErrorReason, booleanDetails.Reason)
+	stringDetails, err := ofClient.StringValueDetails(ctx, "booleanfeature", "default", of.EvaluationContext{})
+	assert.Error(t, err)
+	assert.Equal(t, "default", stringDetails.Value)
+	assert.Equal(t, of.TypeMismatchCode, stringDetails.ErrorCode)
+
+	// Updating a feature emits a configuration-changed event:
+	serverEvents <- "event: feature\ndata: {\"id\":\"2\",\"key\":\"stringfeature\",\"type\":\"STRING\",\"value\":\"this is a new string\",\"version\":2}\n\n"
+	select {
+	case details := <-configChanges:
+		assert.Equal(t, []string{"stringfeature"}, details.FlagChanges)
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "No configuration-changed event was received")
+	}
+
+	// Deleting a feature also emits a configuration-changed event:
+	serverEvents <- "event: delete_feature\ndata: {\"id\":\"4\",\"key\":\"jsonfeature\",\"type\":\"JSON\",\"version\":2}\n\n"
+	select {
+	case details := <-configChanges:
+		assert.Equal(t, []string{"jsonfeature"}, details.FlagChanges)
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "No configuration-changed event was received")
+	}
+
+	// When the server goes stale we get a stale event:
+	serverEvents <- "event: config\ndata: {\"edge.stale\":true}\n\n"
+	select {
+	case <-staleEvents:
+		assert.Equal(t, of.StaleState, provider.Status())
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "No stale event was received")
+	}
+
+	// Shutting down:
+	provider.Shutdown()
+	assert.Equal(t, of.NotReadyState, provider.Status())
+	assert.Equal(t, 0, client.NotifierCounts()[streamingclient.NotifierKeyAllFeatures])
+}
+
+func TestProviderFailure(t *testing.T) {
+
+	// A fake FeatureHub server which fails straight away:
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, "event: failure\ndata: {}\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+	defer server.CloseClientConnections()
+
+	// Connect a client (which mustn't exit the test on failure):
+	config := streamingclient.NewConfig(server.URL, "default/environment-id/my-secret-api-key").WithLogLevel(logrus.ErrorLevel)
+	client, err := streamingclient.NewStreamingClient(config)
+	assert.NoError(t, err)
+	client.WithFatalErrorHandler(func(error, string, map[string]interface{}) {}).Start()
+	defer client.Close()
+
+	// Registering the provider fails (rather than waiting forever), leaving it in the error state:
+	provider := NewProvider(client)
+	provider.statusInterval = 10 * time.Millisecond
+	assert.Error(t, of.SetNamedProviderAndWait("featurehub-failure", provider))
+	assert.Equal(t, of.ErrorState, provider.Status())
+}
+
+func TestContextFromEvaluationContext(t *testing.T) {
+
+	clientContext := ContextFromEvaluationContext(of.FlattenedContext{
+		of.TargetingKey: "user1",
+		"userkey":       "ignored",
+		"session":       "session1",
+		"device":        "desktop",
+		"platform":      "macos",
+		"country":       "new_zealand",
+		"version":       "1.2.3",
+		"beta":          true,
+		"age":           int64(42),
+	})
+
+	assert.Equal(t, "user1", clientContext.Userkey)
+	assert.Equal(t, "session1", clientContext.Session)
+	assert.Equal(t, models.ContextDeviceDesktop, clientContext.Device)
+	assert.Equal(t, models.ContextPlatformMacos, clientContext.Platform)
+	assert.Equal(t, models.ContextCountry("new_zealand"), clientContext.Country)
+	assert.Equal(t, "1.2.3", clientContext.Version)
+	assert.Equal(t, map[string]interface{}{"beta": true, "age": int64(42)}, clientContext.Custom)
+
+	// Without a targeting key the userkey attribute is used:
+	clientContext = ContextFromEvaluationContext(of.FlattenedContext{"userkey": "user2"})
+	assert.Equal(t, "user2", clientContext.Userkey)
+}
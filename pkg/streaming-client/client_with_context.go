@@ -1,16 +1,23 @@
 package streamingclient
 
 import (
+	"context"
+	"strconv"
+	"strings"
+
 	"github.com/featurehub-io/featurehub-go-sdk/pkg/errors"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/experiments"
 	"github.com/featurehub-io/featurehub-go-sdk/pkg/interfaces"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/logging"
 	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
 )
 
 // ClientWithContext bundles a Context with a client:
 type ClientWithContext struct {
 	*models.Context
-	client interfaces.Client
-	config *Config
+	client       interfaces.Client
+	config       *Config
+	traceContext context.Context
 }
 
 // Client provides access to the client:
@@ -23,136 +30,105 @@ func (cc *ClientWithContext) GetFeature(key string) (*models.FeatureState, error
 	return cc.client.GetFeature(key)
 }
 
+// Features returns a snapshot of all of the features (by key), without the context applied:
+func (cc *ClientWithContext) Features() map[string]*models.FeatureState {
+	return cc.client.Features()
+}
+
 // GetBoolean searches for a feature by key, returns the value as a boolean:
 func (cc *ClientWithContext) GetBoolean(key string) (bool, error) {
 
-	// Use the existing GetFeature method:
-	fs, err := cc.client.GetFeature(key)
+	// Apply our context to the feature:
+	evaluatedFeature, err := cc.Evaluate(key, models.TypeBoolean)
 	if err != nil {
 		return false, err
 	}
 
-	// Make sure the feature is the correct type:
-	if fs.Type != models.TypeBoolean {
-		return false, errors.NewErrInvalidType(string(fs.Type))
-	}
-
 	// Assert the value:
-	defaultValue, ok := fs.Value.(bool)
+	value, ok := evaluatedFeature.Value.(bool)
 	if !ok {
 		return false, errors.NewErrInvalidType("Unable to assert value as a bool")
 	}
 
-	// Figure out which value to use:
-	if calculatedValue := fs.Strategies.Calculate(cc.Context); calculatedValue != nil {
-
-		// Assert the value:
-		if strategyValue, ok := calculatedValue.(bool); ok {
-			return strategyValue, nil
-		}
-	}
-
-	// Return the default value as a fall-back:
-	return defaultValue, nil
+	return value, nil
 }
 
 // GetNumber searches for a feature by key, returns the value as a float64:
 func (cc *ClientWithContext) GetNumber(key string) (float64, error) {
 
-	// Use the existing GetFeature method:
-	fs, err := cc.client.GetFeature(key)
+	// Apply our context to the feature:
+	evaluatedFeature, err := cc.Evaluate(key, models.TypeNumber)
 	if err != nil {
 		return 0, err
 	}
 
-	// Make sure the feature is the correct type:
-	if fs.Type != models.TypeNumber {
-		return 0, errors.NewErrInvalidType(string(fs.Type))
-	}
-
 	// Assert the value:
-	defaultValue, ok := fs.Value.(float64)
+	value, ok := evaluatedFeature.Value.(float64)
 	if !ok {
 		return 0, errors.NewErrInvalidType("Unable to assert value as a float64")
 	}
 
-	// Figure out which value to use:
-	if calculatedValue := fs.Strategies.Calculate(cc.Context); calculatedValue != nil {
-
-		// Assert the value:
-		if strategyValue, ok := calculatedValue.(float64); ok {
-			return strategyValue, nil
-		}
-	}
-
-	// Return the default value as a fall-back:
-	return defaultValue, nil
+	return value, nil
 }
 
 // GetRawJSON searches for a feature by key, returns the value as a JSON string:
 func (cc *ClientWithContext) GetRawJSON(key string) (string, error) {
 
-	// Use the existing GetFeature method:
-	fs, err := cc.client.GetFeature(key)
+	// Apply our context to the feature:
+	evaluatedFeature, err := cc.Evaluate(key, models.TypeJSON)
 	if err != nil {
 		return "{}", err
 	}
 
-	// Make sure the feature is the correct type:
-	if fs.Type != models.TypeJSON {
-		return "{}", errors.NewErrInvalidType(string(fs.Type))
-	}
-
 	// Assert the value:
-	defaultValue, ok := fs.Value.(string)
+	value, ok := evaluatedFeature.Value.(string)
 	if !ok {
 		return "{}", errors.NewErrInvalidType("Unable to assert value as a string")
 	}
 
-	// Figure out which value to use:
-	if calculatedValue := fs.Strategies.Calculate(cc.Context); calculatedValue != nil {
-
-		// Assert the value:
-		if strategyValue, ok := calculatedValue.(string); ok {
-			return strategyValue, nil
-		}
-	}
-
-	// Return the default value as a fall-back:
-	return defaultValue, nil
+	return value, nil
 }
 
 // GetString searches for a feature by key, returns the value as a string:
 func (cc *ClientWithContext) GetString(key string) (string, error) {
 
-	// Use the existing GetFeature method:
-	fs, err := cc.client.GetFeature(key)
+	// Apply our context to the feature:
+	evaluatedFeature, err := cc.Evaluate(key, models.TypeString)
 	if err != nil {
 		return "", err
 	}
 
-	// Make sure the feature is the correct type:
-	if fs.Type != models.TypeString {
-		return "", errors.NewErrInvalidType(string(fs.Type))
-	}
-
 	// Assert the value:
-	defaultValue, ok := fs.Value.(string)
+	value, ok := evaluatedFeature.Value.(string)
 	if !ok {
 		return "", errors.NewErrInvalidType("Unable to assert value as a string")
 	}
 
-	// Figure out which value to use:
-	if calculatedValue := fs.Strategies.Calculate(cc.Context); calculatedValue != nil {
+	return value, nil
+}
 
-		// Assert the value:
-		if strategyValue, ok := calculatedValue.(string); ok {
-			return strategyValue, nil
-		}
+// EvaluateAll applies the context to every feature we have, returning the values which apply (by key):
+// - if any key prefixes are provided then only features whose keys start with one of them are included
+// - the result can be serialised to JSON and handed to browsers (strategies are not included)
+// - the context is assigned to percentage strategies (with an assignment store), and an impression is logged for each feature (because the context is going to experience them)
+// - returns an ErrNotReady if the client hasn't received any data yet (rather than an empty set of features)
+func (cc *ClientWithContext) EvaluateAll(keyPrefixes ...string) (models.EvaluatedFeatures, error) {
+	if !cc.client.Status().HasData {
+		return nil, errors.NewErrNotReady("no features have been received yet")
 	}
+	return cc.evaluateAll(keyPrefixes, true), nil
+}
 
-	// Return the default value as a fall-back:
-	return defaultValue, nil
+// PreviewAll is EvaluateAll without logging any impressions or storing any assignments (eg for debugging, where nobody experiences the values):
+func (cc *ClientWithContext) PreviewAll(keyPrefixes ...string) models.EvaluatedFeatures {
+	return cc.evaluateAll(keyPrefixes, false)
+}
+
+// WithTraceContext returns a copy of this ClientWithContext which reports evaluations against the given context (eg one carrying an active span):
+func (cc *ClientWithContext) WithTraceContext(ctx context.Context) *ClientWithContext {
+	clientWithTraceContext := *cc
+	clientWithTraceContext.traceContext = ctx
+	return &clientWithTraceContext
 }
 
 // WithContext returns a new clienWithContext:
@@ -167,6 +143,16 @@ func (cc *ClientWithContext) AddAnalyticsCollector(newAnalyticsCollector interfa
 	cc.client.AddAnalyticsCollector(newAnalyticsCollector)
 }
 
+// AddImpressionCollector configures a new impression collector, adding it to the list:
+func (cc *ClientWithContext) AddImpressionCollector(newImpressionCollector interfaces.ImpressionCollector) {
+	cc.client.AddImpressionCollector(newImpressionCollector)
+}
+
+// AddNotifierAllFeatures configures a notifier for every feature:
+func (cc *ClientWithContext) AddNotifierAllFeatures(callbackFunc models.CallbackFuncFeature) (notifierUUID string) {
+	return cc.client.AddNotifierAllFeatures(callbackFunc)
+}
+
 // AddNotifierBoolean configures a notifier for a BOOLEAN value:
 func (cc *ClientWithContext) AddNotifierBoolean(featureKey string, callbackFunc models.CallbackFuncBoolean) (notifierUUID string) {
 	return cc.client.AddNotifierBoolean(featureKey, callbackFunc)
@@ -192,22 +178,233 @@ func (cc *ClientWithContext) AddNotifierString(featureKey string, callbackFunc m
 	return cc.client.AddNotifierString(featureKey, callbackFunc)
 }
 
+// Close disconnects the underlying client from the FeatureHub server:
+func (cc *ClientWithContext) Close() {
+	cc.client.Close()
+}
+
 // DeleteNotifier removes a previously configured notifier (by key and UUID, because we support more than one notifier per key):
 func (cc *ClientWithContext) DeleteNotifier(featureKey, notifierUUID string) error {
 	return cc.client.DeleteNotifier(featureKey, notifierUUID)
 }
 
-// LogAnalyticsEvent sends an analytics event (non-blocking, fire and forget):
+// LogAnalyticsEvent sends an analytics event (non-blocking, fire and forget), along with our context and the values it experiences:
 func (cc *ClientWithContext) LogAnalyticsEvent(action string, other map[string]string) {
+	if streamingClient, ok := cc.client.(*StreamingClient); ok {
+		streamingClient.logAnalyticsEvent(cc.Context, action, other)
+		return
+	}
 	cc.client.LogAnalyticsEvent(action, other)
 }
 
-// LogAnalyticsEventSync sends an analytics event, and wait for it to complete:
+// FlushAnalytics blocks until every analytics event logged so far has been submitted (or the context is done):
+func (cc *ClientWithContext) FlushAnalytics(ctx context.Context) error {
+	return cc.client.FlushAnalytics(ctx)
+}
+
+// LogAnalyticsEventSync sends an analytics event (along with our context and the values it experiences), and wait for it to complete:
 func (cc *ClientWithContext) LogAnalyticsEventSync(action string, other map[string]string) error {
-	return cc.LogAnalyticsEventSync(action, other)
+	if streamingClient, ok := cc.client.(*StreamingClient); ok {
+		return streamingClient.logAnalyticsEventSync(cc.Context, action, other)
+	}
+	return cc.client.LogAnalyticsEventSync(action, other)
+}
+
+// Keys returns the keys of all features (sorted):
+func (cc *ClientWithContext) Keys() []string {
+	return cc.client.Keys()
+}
+
+// Status returns the current status of the underlying client:
+func (cc *ClientWithContext) Status() models.ClientStatus {
+	return cc.client.Status()
 }
 
 // ReadinessListener adds a function which will be called when the client is ready:
 func (cc *ClientWithContext) ReadinessListener(callbackFunc func()) {
-	cc.ReadinessListener(callbackFunc)
+	cc.client.ReadinessListener(callbackFunc)
+}
+
+// FailedReadinessListener adds a function which will be called if the underlying client fails before it is ready:
+func (cc *ClientWithContext) FailedReadinessListener(callbackFunc func(err error)) {
+	cc.client.FailedReadinessListener(callbackFunc)
+}
+
+// Readiness returns the current readiness state of the underlying client:
+func (cc *ClientWithContext) Readiness() models.Readiness {
+	return cc.client.Readiness()
+}
+
+// Ready returns a channel which is closed once the underlying client has received data for the first time:
+func (cc *ClientWithContext) Ready() <-chan struct{} {
+	return cc.client.Ready()
+}
+
+// WaitUntilReady blocks until the underlying client has received data for the first time (or fails, or the context is done):
+func (cc *ClientWithContext) WaitUntilReady(ctx context.Context) error {
+	return cc.client.WaitUntilReady(ctx)
+}
+
+// Stale tells us whether the underlying client has gone too long without hearing from the FeatureHub server:
+func (cc *ClientWithContext) Stale() bool {
+	return cc.client.Stale()
+}
+
+// StaleListener adds a function which will be called when the underlying client becomes stale (or stops being stale):
+func (cc *ClientWithContext) StaleListener(callbackFunc func(stale bool)) {
+	cc.client.StaleListener(callbackFunc)
+}
+
+// Evaluate looks up a feature by key, makes sure it is the expected type, and applies our context to it (telling us which strategy matched):
+func (cc *ClientWithContext) Evaluate(key string, expectedType models.FeatureValueType) (*models.EvaluatedFeature, error) {
+	return cc.evaluate(key, expectedType, true)
+}
+
+// Variant assigns our context to a variant of an experiment (a STRING feature whose strategies are the variants), logging an exposure the first time:
+// - exposures are logged at most once per context and variant while they are remembered (see experiments.Assigner.Expose)
+// - contexts in the holdout group (or in another experiment in the same layer) get the default value
+// - contexts without a userkey or session can't take part, so they get whatever the strategies give them (without an exposure)
+// - exposures are logged with the "experiment-exposure" action (through the underlying client's analytics)
+func (cc *ClientWithContext) Variant(key string) (*models.Variant, error) {
+
+	// Work out which group our context is in:
+	hashKey, hasHashKey := cc.Context.UniqueKey()
+	assigner := cc.assigner()
+	assignment := experiments.Assignment{}
+	if hasHashKey {
+		assignment = assigner.Assign(hashKey, key)
+	}
+
+	// Only contexts taking part get the value from the strategies:
+	evaluatedFeature, err := cc.evaluate(key, models.TypeString, !hasHashKey || assignment.InExperiment())
+	if err != nil {
+		return nil, err
+	}
+
+	name, _ := evaluatedFeature.Value.(string)
+	variant := &models.Variant{
+		Bucket:       assignment.Bucket,
+		Experiment:   key,
+		Holdout:      assignment.Holdout,
+		InExperiment: hasHashKey && assignment.InExperiment(),
+		Layer:        assignment.Layer,
+		Name:         name,
+		StrategyID:   evaluatedFeature.StrategyID,
+		Version:      evaluatedFeature.Version,
+	}
+
+	// Log an exposure for contexts taking part (and the holdout group, who are the control), once per variant:
+	if !hasHashKey || assignment.Excluded || !assigner.Expose(hashKey, key, variant.Name) {
+		return variant, nil
+	}
+	cc.LogAnalyticsEvent(experiments.ExposureAction, map[string]string{
+		"bucket":     strconv.Itoa(variant.Bucket),
+		"experiment": variant.Experiment,
+		"holdout":    strconv.FormatBool(variant.Holdout),
+		"layer":      variant.Layer,
+		"strategyId": variant.StrategyID,
+		"variant":    variant.Name,
+	})
+
+	return variant, nil
+}
+
+// evaluate looks up a feature by key, makes sure it is the expected type, and applies our context to it (or just uses the default value):
+func (cc *ClientWithContext) evaluate(key string, expectedType models.FeatureValueType, applyStrategies bool) (*models.EvaluatedFeature, error) {
+
+	// Use the existing GetFeature method:
+	fs, err := cc.client.GetFeature(key)
+	if err != nil {
+		cc.recordEvaluation(key, nil, models.EvaluationOutcomeNotFound)
+		return nil, err
+	}
+
+	// Make sure the feature is the correct type:
+	if fs.Type != expectedType {
+		cc.recordEvaluation(key, nil, models.EvaluationOutcomeTypeMismatch)
+		return nil, errors.NewErrInvalidType(string(fs.Type))
+	}
+
+	// Figure out which value to use:
+	evaluatedFeature := &models.EvaluatedFeature{ID: fs.ID, Key: fs.Key, Type: fs.Type, Value: fs.Value, Version: fs.Version}
+	if applyStrategies {
+		evaluatedFeature = cc.config.evaluate(fs, cc.Context, cc.logger())
+	}
+	cc.recordEvaluation(key, evaluatedFeature, evaluatedFeature.Outcome())
+	cc.logImpression(evaluatedFeature)
+
+	return evaluatedFeature, nil
+}
+
+// evaluateAll applies our context to every feature (with one of the key prefixes, if there are any), assigning it and logging impressions if it is going to experience them:
+func (cc *ClientWithContext) evaluateAll(keyPrefixes []string, experienced bool) models.EvaluatedFeatures {
+	evaluatedFeatures := make(models.EvaluatedFeatures)
+	for key, fs := range cc.client.Features() {
+		if !hasAnyPrefix(key, keyPrefixes) {
+			continue
+		}
+		if !experienced {
+			evaluatedFeature := cc.config.evaluateReadOnly(fs, cc.Context, cc.logger())
+			cc.recordEvaluation(key, evaluatedFeature, evaluatedFeature.Outcome())
+			evaluatedFeatures[key] = evaluatedFeature
+			continue
+		}
+		evaluatedFeature := cc.config.evaluate(fs, cc.Context, cc.logger())
+		cc.recordEvaluation(key, evaluatedFeature, evaluatedFeature.Outcome())
+		cc.logImpression(evaluatedFeature)
+		evaluatedFeatures[key] = evaluatedFeature
+	}
+	return evaluatedFeatures
+}
+
+// logImpression lets any impression collectors know what this context experienced (only a StreamingClient has them):
+func (cc *ClientWithContext) logImpression(evaluatedFeature *models.EvaluatedFeature) {
+	if streamingClient, ok := cc.client.(*StreamingClient); ok {
+		streamingClient.logImpression(cc.Context, evaluatedFeature)
+	}
+}
+
+// assigner returns the experiment assigner of the underlying client (only a StreamingClient keeps one, so others share one with the config):
+func (cc *ClientWithContext) assigner() *experiments.Assigner {
+	if streamingClient, ok := cc.client.(*StreamingClient); ok {
+		return streamingClient.experimentAssigner()
+	}
+	return cc.config.experimentAssigner()
+}
+
+// logger returns the logger of the underlying client (only a StreamingClient has one):
+func (cc *ClientWithContext) logger() logging.Logger {
+	if streamingClient, ok := cc.client.(*StreamingClient); ok {
+		return streamingClient.logger
+	}
+	return nil
+}
+
+// recordEvaluation reports an evaluation to the configured metrics and tracer:
+func (cc *ClientWithContext) recordEvaluation(key string, evaluatedFeature *models.EvaluatedFeature, outcome models.EvaluationOutcome) {
+	var strategyID string
+	if evaluatedFeature != nil {
+		strategyID = evaluatedFeature.StrategyID
+	}
+
+	traceContext := cc.traceContext
+	if traceContext == nil {
+		traceContext = context.Background()
+	}
+
+	cc.config.getMetrics().Evaluation(key, strategyID, outcome)
+	cc.config.getTracer().Evaluation(traceContext, key, evaluatedFeature, outcome)
+}
+
+// hasAnyPrefix tells us whether the key starts with any of the given prefixes (or if there are no prefixes at all):
+func hasAnyPrefix(key string, prefixes []string) bool {
+	if len(prefixes) == 0 {
+		return true
+	}
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
 }
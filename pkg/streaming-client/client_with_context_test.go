@@ -2,12 +2,19 @@ package streamingclient
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/donovanhide/eventsource"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/assignments"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/errors"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/experiments"
 	"github.com/featurehub-io/featurehub-go-sdk/pkg/interfaces"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/logging"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/mocks"
 	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
 	"github.com/featurehub-io/featurehub-go-sdk/pkg/strategies"
 	"github.com/sirupsen/logrus"
@@ -230,7 +237,7 @@ func TestClientWithContext(t *testing.T) {
 		},
 		config:   config,
 		features: make(map[string]*models.FeatureState),
-		logger:   logger,
+		logger:   logging.NewLogrusLogger(logger),
 	}
 
 	// Make a client context:
@@ -387,3 +394,445 @@ func TestClientWithContext(t *testing.T) {
 	assert.Equal(t, "you have the custom string", stringValue)
 	assert.NoError(t, err)
 }
+
+func TestClientWithContextEvaluateAll(t *testing.T) {
+
+	// Make a logger:
+	logger := logrus.New()
+	logger.SetLevel(logrus.TraceLevel)
+	logger.SetOutput(new(bytes.Buffer))
+
+	// Use the config to make a new StreamingClient with a mock apiClient::
+	testClient := &StreamingClient{
+		apiClient: &eventsource.Stream{
+			Errors: make(chan error, 100),
+			Events: make(chan eventsource.Event, 100),
+		},
+		config:   &Config{WaitForData: true},
+		features: make(map[string]*models.FeatureState),
+		logger:   logging.NewLogrusLogger(logger),
+	}
+
+	// Marshal the TestFeature1States to JSON:
+	TestFeature1StatesJSON, err := json.Marshal(TestFeature1States)
+	assert.NoError(t, err)
+
+	// Load the mock apiClient up with a "features" event:
+	testClient.apiClient.Events <- &testEvent{
+		data:  string(TestFeature1StatesJSON),
+		event: "features",
+	}
+
+	// Start handling events:
+	testClient.Start()
+
+	// Evaluate everything for a russian user:
+	evaluatedFeatures, err := testClient.
+		WithContext(&models.Context{Country: models.ContextCountryRussia}).
+		EvaluateAll()
+	assert.NoError(t, err)
+	assert.Len(t, evaluatedFeatures, len(TestFeature1States))
+	assert.Equal(t, "this is for the russians", evaluatedFeatures["TestFeature1"].Value)
+	assert.Equal(t, "s1", evaluatedFeatures["TestFeature1"].StrategyID)
+	assert.Equal(t, "this is the default value", evaluatedFeatures["TestFeature2"].Value)
+	assert.Empty(t, evaluatedFeatures["TestFeature2"].StrategyID)
+	assert.Equal(t, true, evaluatedFeatures["TestBoolean"].Value)
+	assert.Equal(t, float64(54321), evaluatedFeatures["TestNumber"].Value)
+
+	// Filter by key prefix:
+	evaluatedFeatures, err = testClient.
+		WithContext(&models.Context{Country: models.ContextCountryRussia}).
+		EvaluateAll("TestFeature", "TestJSON")
+	assert.NoError(t, err)
+	assert.Len(t, evaluatedFeatures, 3)
+	assert.Contains(t, evaluatedFeatures, "TestFeature1")
+	assert.Contains(t, evaluatedFeatures, "TestFeature2")
+	assert.Contains(t, evaluatedFeatures, "TestJSON")
+
+	// Make sure that strategies are not exposed when serialised:
+	evaluatedFeaturesJSON, err := json.Marshal(evaluatedFeatures)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(evaluatedFeaturesJSON), "strateg")
+	assert.NotContains(t, string(evaluatedFeaturesJSON), "country")
+
+	// A client without any data isn't ready to be evaluated:
+	fakeClient := new(mocks.FakeClient)
+	fakeClient.FeaturesReturns(map[string]*models.FeatureState{"TestFeature2": TestFeature1States[1]})
+	evaluatedFeatures, err = (&ClientWithContext{client: fakeClient, Context: &models.Context{}}).EvaluateAll()
+	assert.IsType(t, &errors.ErrNotReady{}, err)
+	assert.Nil(t, evaluatedFeatures)
+	assert.Equal(t, 0, fakeClient.FeaturesCallCount())
+
+	// Any client implementation can be evaluated (once it has data):
+	fakeClient.StatusReturns(models.ClientStatus{HasData: true})
+	evaluatedFeatures, err = (&ClientWithContext{client: fakeClient, Context: &models.Context{}}).EvaluateAll()
+	assert.NoError(t, err)
+	assert.Len(t, evaluatedFeatures, 1)
+	assert.Equal(t, 1, fakeClient.FeaturesCallCount())
+}
+
+func TestClientWithContextImpressions(t *testing.T) {
+
+	// Make a logger:
+	logger := logrus.New()
+	logger.SetOutput(new(bytes.Buffer))
+
+	// Use the config to make a new StreamingClient with a mock apiClient::
+	testClient := &StreamingClient{
+		apiClient: &eventsource.Stream{
+			Errors: make(chan error, 100),
+			Events: make(chan eventsource.Event, 100),
+		},
+		config:   &Config{WaitForData: true},
+		features: make(map[string]*models.FeatureState),
+		logger:   logging.NewLogrusLogger(logger),
+	}
+
+	// Load the mock apiClient up with a "features" event:
+	TestFeature1StatesJSON, err := json.Marshal(TestFeature1States)
+	assert.NoError(t, err)
+	testClient.apiClient.Events <- &testEvent{
+		data:  string(TestFeature1StatesJSON),
+		event: "features",
+	}
+	testClient.Start()
+
+	// Evaluations don't cost anything until there is an impression collector:
+	russianContext := testClient.WithContext(&models.Context{Country: models.ContextCountryRussia, Session: "session1", Userkey: "user1"})
+	_, err = russianContext.GetString("TestFeature1")
+	assert.NoError(t, err)
+	fakeImpressionCollector := new(mocks.FakeImpressionCollector)
+	russianContext.AddImpressionCollector(fakeImpressionCollector)
+
+	// Evaluate some features (a couple of times, and one which doesn't exist):
+	for i := 0; i < 2; i++ {
+		_, err = russianContext.GetString("TestFeature1")
+		assert.NoError(t, err)
+		_, err = russianContext.GetString("TestFeature2")
+		assert.NoError(t, err)
+		_, err = russianContext.GetString("does-not-exist")
+		assert.Error(t, err)
+	}
+	_, err = testClient.WithContext(&models.Context{Userkey: "user2"}).GetString("TestFeature1")
+	assert.NoError(t, err)
+
+	// Bulk evaluations count as impressions too (but previews don't):
+	_, err = russianContext.EvaluateAll("TestFeature", "TestBoolean")
+	assert.NoError(t, err)
+	assert.Len(t, russianContext.PreviewAll("TestString"), 1)
+
+	// Check what the collector was told (duplicates are ignored):
+	assert.NoError(t, testClient.FlushAnalytics(context.Background()))
+	assert.Equal(t, 1, fakeImpressionCollector.LogImpressionsCallCount())
+	impressions := fakeImpressionCollector.LogImpressionsArgsForCall(0)
+	assert.Len(t, impressions, 4)
+	assert.Equal(t, "TestFeature1", impressions[0].Key)
+	assert.Equal(t, "this is for the russians", impressions[0].Value)
+	assert.Equal(t, "s1", impressions[0].StrategyID)
+	assert.Equal(t, "session1", impressions[0].Session)
+	assert.Equal(t, "user1", impressions[0].Userkey)
+	assert.WithinDuration(t, time.Now(), impressions[0].Timestamp, time.Second)
+	assert.Equal(t, "TestFeature2", impressions[1].Key)
+	assert.Equal(t, "66", impressions[1].StrategyID)
+	assert.Equal(t, "this is for the 66 percent", impressions[1].Value)
+	assert.Equal(t, "user2", impressions[2].Userkey)
+	assert.Equal(t, "this is the default value", impressions[2].Value)
+	assert.Equal(t, "TestBoolean", impressions[3].Key)
+	assert.Equal(t, "user1", impressions[3].Userkey)
+}
+
+func TestClientWithContextAnalytics(t *testing.T) {
+
+	// Make a logger:
+	logger := logrus.New()
+	logger.SetOutput(new(bytes.Buffer))
+
+	// Use the config to make a new StreamingClient with a mock apiClient::
+	testClient := &StreamingClient{
+		apiClient: &eventsource.Stream{
+			Errors: make(chan error, 100),
+			Events: make(chan eventsource.Event, 100),
+		},
+		config:   &Config{WaitForData: true},
+		features: make(map[string]*models.FeatureState),
+		logger:   logging.NewLogrusLogger(logger),
+	}
+
+	// Load the mock apiClient up with a "features" event:
+	TestFeature1StatesJSON, err := json.Marshal(TestFeature1States)
+	assert.NoError(t, err)
+	testClient.apiClient.Events <- &testEvent{
+		data:  string(TestFeature1StatesJSON),
+		event: "features",
+	}
+	testClient.Start()
+
+	// A collector which takes the context, and one which doesn't:
+	fakeContextCollector := new(mocks.FakeContextAnalyticsCollector)
+	fakeCollector := new(mocks.FakeAnalyticsCollector)
+	testClient.AddAnalyticsCollector(fakeContextCollector)
+	testClient.AddAnalyticsCollector(fakeCollector)
+
+	// Log an event through a context (which changes before the event is submitted):
+	russianContext := testClient.WithContext(&models.Context{Country: models.ContextCountryRussia, Userkey: "user1"})
+	russianContext.LogAnalyticsEvent("todo-add", map[string]string{"testing": "true"})
+	russianContext.Country = models.ContextCountryNewZealand
+	assert.NoError(t, testClient.FlushAnalytics(context.Background()))
+
+	// The context collector gets the context, and the values it experienced (as well as the raw features):
+	assert.Equal(t, 0, fakeContextCollector.LogEventCallCount())
+	assert.Equal(t, 1, fakeContextCollector.LogContextEventCallCount())
+	event := fakeContextCollector.LogContextEventArgsForCall(0)
+	assert.Equal(t, "todo-add", event.Action)
+	assert.Equal(t, models.ContextCountryRussia, event.Context.Country)
+	assert.Equal(t, "user1", event.Context.Userkey)
+	assert.Equal(t, "this is for the russians", event.Evaluated["TestFeature1"].Value)
+	assert.Equal(t, "s1", event.Evaluated["TestFeature1"].StrategyID)
+	assert.Equal(t, "this is the default value", event.Features["TestFeature1"].Value)
+
+	// Other collectors still work, and see the values the context experienced:
+	assert.Equal(t, 1, fakeCollector.LogEventCallCount())
+	action, other, features := fakeCollector.LogEventArgsForCall(0)
+	assert.Equal(t, "todo-add", action)
+	assert.Equal(t, "true", other["testing"])
+	assert.Equal(t, "this is for the russians", features["TestFeature1"].Value)
+	assert.Empty(t, features["TestFeature1"].Strategies)
+
+	// The sync method does the same:
+	assert.NoError(t, russianContext.LogAnalyticsEventSync("todo-delete", nil))
+	assert.Equal(t, models.ContextCountryNewZealand, fakeContextCollector.LogContextEventArgsForCall(1).Context.Country)
+
+	// Events logged without a context don't have one:
+	testClient.LogAnalyticsEvent("todo-list", nil)
+	assert.NoError(t, testClient.FlushAnalytics(context.Background()))
+	event = fakeContextCollector.LogContextEventArgsForCall(2)
+	assert.Nil(t, event.Context)
+	assert.Nil(t, event.Evaluated)
+	_, _, features = fakeCollector.LogEventArgsForCall(2)
+	assert.Equal(t, "this is the default value", features["TestFeature1"].Value)
+}
+
+func TestClientWithContextVariant(t *testing.T) {
+
+	// Make a logger:
+	logger := logrus.New()
+	logger.SetOutput(new(bytes.Buffer))
+
+	// Use the config to make a new StreamingClient with a mock apiClient (and some experiments config):
+	testClient := &StreamingClient{
+		apiClient: &eventsource.Stream{
+			Errors: make(chan error, 100),
+			Events: make(chan eventsource.Event, 100),
+		},
+		config: &Config{
+			WaitForData: true,
+			experiments: experiments.Config{
+				Holdouts: map[string]float64{"TestString": 100},
+				Layers:   []experiments.Layer{{Name: "checkout", Experiments: []string{"TestFeature1", "TestFeature2"}}},
+			},
+		},
+		features: make(map[string]*models.FeatureState),
+		logger:   logging.NewLogrusLogger(logger),
+	}
+
+	// Load the mock apiClient up with a "features" event:
+	TestFeature1StatesJSON, err := json.Marshal(TestFeature1States)
+	assert.NoError(t, err)
+	testClient.apiClient.Events <- &testEvent{
+		data:  string(TestFeature1StatesJSON),
+		event: "features",
+	}
+	testClient.Start()
+	fakeCollector := new(mocks.FakeContextAnalyticsCollector)
+	testClient.AddAnalyticsCollector(fakeCollector)
+
+	// Contexts without a userkey or session can't take part:
+	variant, err := testClient.WithContext(&models.Context{Country: models.ContextCountryRussia}).Variant("TestFeature2")
+	assert.NoError(t, err)
+	assert.False(t, variant.InExperiment)
+	assert.Equal(t, "this is the default value", variant.Name)
+
+	// Each context is in one of the experiments in the layer, and gets the default value for the other:
+	for i := 0; i < 20; i++ {
+		clientWithContext := testClient.WithContext(&models.Context{Userkey: fmt.Sprintf("user%d", i)})
+		variant1, err := clientWithContext.Variant("TestFeature1")
+		assert.NoError(t, err)
+		variant2, err := clientWithContext.Variant("TestFeature2")
+		assert.NoError(t, err)
+		assert.NotEqual(t, variant1.InExperiment, variant2.InExperiment)
+		assert.Equal(t, "checkout", variant1.Layer)
+		assert.Equal(t, int(models.PercentageBucket(clientWithContext.Userkey)), variant2.Bucket)
+
+		// Those taking part get what the strategies give them:
+		for _, variant := range []*models.Variant{variant1, variant2} {
+			if !variant.InExperiment {
+				assert.Equal(t, "this is the default value", variant.Name)
+				assert.Empty(t, variant.StrategyID)
+				continue
+			}
+			value, err := clientWithContext.GetString(variant.Experiment)
+			assert.NoError(t, err)
+			assert.Equal(t, value, variant.Name)
+		}
+
+		// Asking again doesn't log another exposure:
+		_, err = clientWithContext.Variant("TestFeature2")
+		assert.NoError(t, err)
+	}
+
+	// One exposure for each context:
+	assert.NoError(t, testClient.FlushAnalytics(context.Background()))
+	assert.Equal(t, 20, fakeCollector.LogContextEventCallCount())
+	event := fakeCollector.LogContextEventArgsForCall(0)
+	assert.Equal(t, experiments.ExposureAction, event.Action)
+	assert.Equal(t, "user0", event.Context.Userkey)
+	assert.Equal(t, "checkout", event.Other["layer"])
+	assert.Equal(t, "false", event.Other["holdout"])
+
+	// The holdout group gets the default value, and is exposed as the control:
+	heldOutContext := testClient.WithContext(&models.Context{Session: "session1"})
+	variant, err = heldOutContext.Variant("TestString")
+	assert.NoError(t, err)
+	assert.True(t, variant.Holdout)
+	assert.False(t, variant.InExperiment)
+	assert.Equal(t, "this is another string", variant.Name)
+	assert.NoError(t, testClient.FlushAnalytics(context.Background()))
+	assert.Equal(t, 21, fakeCollector.LogContextEventCallCount())
+	assert.Equal(t, "true", fakeCollector.LogContextEventArgsForCall(20).Other["holdout"])
+	assert.Equal(t, "TestString", fakeCollector.LogContextEventArgsForCall(20).Other["experiment"])
+
+	// Experiments have to be STRING features which exist:
+	_, err = heldOutContext.Variant("TestBoolean")
+	assert.Error(t, err)
+	_, err = heldOutContext.Variant("doesnt-exist")
+	assert.Error(t, err)
+
+	// Any client implementation logs exposures through its own analytics (and only once per config):
+	fakeClient := new(mocks.FakeClient)
+	fakeClient.GetFeatureReturns(TestFeature1States[1], nil)
+	fakeConfig := &Config{client: fakeClient}
+	for i := 0; i < 2; i++ {
+		variant, err = fakeConfig.WithContext(&models.Context{Userkey: "user1"}).Variant("TestFeature2")
+		assert.NoError(t, err)
+		assert.True(t, variant.InExperiment)
+	}
+	assert.Equal(t, 1, fakeClient.LogAnalyticsEventCallCount())
+	action, other := fakeClient.LogAnalyticsEventArgsForCall(0)
+	assert.Equal(t, experiments.ExposureAction, action)
+	assert.Equal(t, "TestFeature2", other["experiment"])
+	assert.Equal(t, variant.Name, other["variant"])
+}
+
+func TestClientWithContextAssignments(t *testing.T) {
+
+	// Make a logger:
+	logger := logrus.New()
+	logger.SetOutput(new(bytes.Buffer))
+
+	// Use the config to make a new StreamingClient with a mock apiClient (and an assignment store):
+	assignmentStore := assignments.NewMemoryStore()
+	testClient := &StreamingClient{
+		apiClient: &eventsource.Stream{
+			Errors: make(chan error, 100),
+			Events: make(chan eventsource.Event, 100),
+		},
+		config:   &Config{WaitForData: true, assignmentStore: assignmentStore},
+		features: make(map[string]*models.FeatureState),
+		logger:   logging.NewLogrusLogger(logger),
+	}
+
+	// Load the mock apiClient up with a "features" event:
+	TestFeature1StatesJSON, err := json.Marshal(TestFeature1States)
+	assert.NoError(t, err)
+	testClient.apiClient.Events <- &testEvent{
+		data:  string(TestFeature1StatesJSON),
+		event: "features",
+	}
+	testClient.Start()
+
+	// Evaluate the percentage feature for some users (which assigns them):
+	values := make(map[string]string)
+	for i := 0; i < 10; i++ {
+		userkey := fmt.Sprintf("user%d", i)
+		value, err := testClient.WithContext(&models.Context{Userkey: userkey}).GetString("TestFeature2")
+		assert.NoError(t, err)
+		values[userkey] = value
+		assignment, err := assignmentStore.Get(userkey, "TestFeature2")
+		assert.NoError(t, err)
+		assert.Equal(t, userkey, assignment.Userkey)
+	}
+
+	// Features without percentage strategies, and contexts without a userkey, aren't assigned:
+	_, err = testClient.WithContext(&models.Context{Country: models.ContextCountryRussia, Userkey: "user0"}).GetString("TestFeature1")
+	assert.NoError(t, err)
+	assignment, _ := assignmentStore.Get("user0", "TestFeature1")
+	assert.Nil(t, assignment)
+	_, err = testClient.WithContext(&models.Context{Session: "session1"}).GetString("TestFeature2")
+	assert.NoError(t, err)
+	assignment, _ = assignmentStore.Get("", "TestFeature2")
+	assert.Nil(t, assignment)
+
+	// Shrink the percentages (so hardly anybody would match them any more):
+	testClient.featuresMutex.Lock()
+	testClient.features["TestFeature2"] = &models.FeatureState{
+		ID:    "TestFeature2",
+		Key:   "TestFeature2",
+		Type:  models.TypeString,
+		Value: "this is the default value",
+		Strategies: []models.Strategy{
+			{ID: "33", Name: "33Percent", Percentage: 1, Value: "this is for the 33 percent"},
+			{ID: "66", Name: "66Percent", Percentage: 2, Value: "this is for the 66 percent"},
+		},
+		Version: 2,
+	}
+	testClient.featuresMutex.Unlock()
+
+	// Everybody keeps what they had (in every kind of evaluation):
+	var moved int
+	for userkey, value := range values {
+		clientWithContext := testClient.WithContext(&models.Context{Userkey: userkey})
+		stickyValue, err := clientWithContext.GetString("TestFeature2")
+		assert.NoError(t, err)
+		assert.Equal(t, value, stickyValue)
+		evaluatedFeatures, err := clientWithContext.EvaluateAll()
+		assert.NoError(t, err)
+		assert.Equal(t, value, evaluatedFeatures["TestFeature2"].Value)
+		if value != "this is the default value" {
+			moved++
+		}
+	}
+	assert.Positive(t, moved)
+
+	// Until the assignments are reset:
+	assert.NoError(t, assignmentStore.Reset("TestFeature2"))
+	for userkey := range values {
+		value, err := testClient.WithContext(&models.Context{Userkey: userkey}).GetString("TestFeature2")
+		assert.NoError(t, err)
+		assert.Equal(t, "this is the default value", value)
+		assignment, _ := assignmentStore.Get(userkey, "TestFeature2")
+		assert.Empty(t, assignment.StrategyID)
+	}
+
+	// A broken store doesn't stop features being evaluated:
+	fakeAssignmentStore := new(mocks.FakeAssignmentStore)
+	fakeAssignmentStore.GetReturns(nil, errors.NewErrFromAPI("broken"))
+	fakeAssignmentStore.SetReturns(errors.NewErrFromAPI("broken"))
+	testClient.config.assignmentStore = fakeAssignmentStore
+	value, err := testClient.WithContext(&models.Context{Userkey: "user1"}).GetString("TestFeature2")
+	assert.NoError(t, err)
+	assert.Equal(t, "this is the default value", value)
+	assert.Equal(t, 1, fakeAssignmentStore.SetCallCount())
+
+	// EvaluateAll assigns (because the context experiences the values), but only looking at features (PreviewAll, the debug handler and analytics events) doesn't:
+	fakeAssignmentStore.GetReturns(nil, nil)
+	testClient.AddAnalyticsCollector(new(mocks.FakeAnalyticsCollector))
+	clientWithContext := testClient.WithContext(&models.Context{Userkey: "user1"})
+	_, err = clientWithContext.EvaluateAll()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, fakeAssignmentStore.SetCallCount())
+	assert.Equal(t, "TestFeature2", fakeAssignmentStore.SetArgsForCall(1).FeatureKey)
+	clientWithContext.PreviewAll()
+	assert.NoError(t, clientWithContext.LogAnalyticsEventSync("looking", nil))
+	assert.Equal(t, 2, fakeAssignmentStore.SetCallCount())
+	assert.Positive(t, fakeAssignmentStore.GetCallCount())
+}
@@ -2,27 +2,54 @@ package streamingclient
 
 import (
 	"fmt"
+	"net"
+	"net/http"
+	"net/url"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/analytics"
 	"github.com/featurehub-io/featurehub-go-sdk/pkg/errors"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/experiments"
 	"github.com/featurehub-io/featurehub-go-sdk/pkg/interfaces"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/logging"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/metrics"
 	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/tracing"
 	"github.com/sirupsen/logrus"
 )
 
 const (
-	defaultLogLevel   = logrus.InfoLevel
-	defaultNamedCache = "default"
+	defaultFailoverAfterErrors = 3
+	defaultLogLevel            = logrus.InfoLevel
+	defaultNamedCache          = "default"
 )
 
 // Config defines parameters for the client:
 type Config struct {
-	LogLevel          logrus.Level      // Logging level (default is "info")
-	SDKKey            string            // SDK key (copied from the UI), in the format "{namedCache}/environmentID/APIKey"
-	ServerAddress     string            // FeatureHub API endpoint
-	WaitForData       bool              // New() will block until some data has arrived
-	client            interfaces.Client // A FeatureHub client implementation
-	fatalErrorHandler *ErrorFunc        // A user-provided handler func for fatal asynchronous errors
+	ConnectTimeout          time.Duration              // How long to wait for the FeatureHub server to accept our connection (default is no timeout)
+	FailoverAfterErrors     int                        // How many connection errors in a row before we fail over to the next server address (default is 3)
+	FallbackServerAddresses []string                   // Other FeatureHub API endpoints to fail over to (in order) if ServerAddress stops working
+	LogLevel                logrus.Level               // Logging level (default is "info")
+	SDKKey                  string                     // SDK key (copied from the UI), in the format "{namedCache}/environmentID/APIKey"
+	ServerAddress           string                     // FeatureHub API endpoint
+	StaleAfter              time.Duration              // How long without any events (including acks) before we're stale and force a reconnect (default is never)
+	WaitForData             bool                       // New() will block until some data has arrived
+	WaitForDataTimeout      time.Duration              // How long WaitForData will block for (default is forever)
+	analyticsPipeline       analytics.PipelineConfig   // How analytics events are queued, batched and retried on their way to collectors
+	assignmentStore         interfaces.AssignmentStore // Remembers which percentage strategy each userkey was assigned (sticky bucketing)
+	client                  interfaces.Client          // A FeatureHub client implementation
+	experiments             experiments.Config         // How contexts are assigned to experiments (holdouts and mutual-exclusion layers)
+	experimentsAssigner     *experiments.Assigner      // Assigns contexts to experiments for clients which don't keep their own assigner
+	experimentsMutex        sync.Mutex                 // Guards experimentsAssigner
+	fatalErrorHandler       *ErrorFunc                 // A user-provided handler func for fatal asynchronous errors
+	headers                 http.Header                // Extra headers to send with every request to the FeatureHub server
+	httpClient              *http.Client               // A user-provided HTTP client (eg for mTLS, proxies or custom CAs)
+	impressions             analytics.ImpressionConfig // How impressions (of features being evaluated for a context) are sampled and de-duplicated
+	logger                  logging.Logger             // A user-provided logger (otherwise logrus is used, at LogLevel)
+	metrics                 interfaces.Metrics         // A user-provided metrics implementation
+	tracer                  interfaces.Tracer          // A user-provided tracer implementation
 }
 
 // NewConfig returns a configured Config:
@@ -65,29 +92,11 @@ func (c *Config) NewContext() *ClientWithContext {
 	}
 }
 
-// Validate can be called to check various config options:
+// Validate can be called to check various config options (everything that is wrong is reported at once):
 func (c *Config) Validate() error {
-
-	// LogLevel shouldn't be empty:
-	if c.LogLevel == 0 {
-		c.LogLevel = logrus.InfoLevel
+	if problems := c.problems(); len(problems) > 0 {
+		return errors.NewErrBadConfigProblems(problems...)
 	}
-
-	// SDKKey shouldn't be empty:
-	if len(c.SDKKey) == 0 {
-		return errors.NewErrBadConfig("SDKKey is required")
-	}
-
-	// SDKKey should be 3 strings delimited with a slash:
-	if len(strings.Split(c.SDKKey, "/")) < 2 {
-		return errors.NewErrBadConfig("Invalid SDKKey format")
-	}
-
-	// ServerAddress shouldn't be empty:
-	if len(c.ServerAddress) == 0 {
-		return errors.NewErrBadConfig("ServerAddress is required")
-	}
-
 	return nil
 }
 
@@ -100,25 +109,326 @@ func (c *Config) WithContext(context *models.Context) *ClientWithContext {
 	}
 }
 
+// WithAnalyticsPipeline configures how analytics events are queued, batched and retried on their way to collectors:
+func (c *Config) WithAnalyticsPipeline(pipelineConfig analytics.PipelineConfig) *Config {
+	c.analyticsPipeline = pipelineConfig
+	return c
+}
+
+// WithAssignmentStore makes percentage strategies sticky, by remembering which one each userkey was assigned (eg assignments.NewMemoryStore()):
+func (c *Config) WithAssignmentStore(assignmentStore interfaces.AssignmentStore) *Config {
+	c.assignmentStore = assignmentStore
+	return c
+}
+
+// WithConnectTimeout sets how long to wait for the FeatureHub server to accept our connection:
+func (c *Config) WithConnectTimeout(connectTimeout time.Duration) *Config {
+	c.ConnectTimeout = connectTimeout
+	return c
+}
+
+// WithExperiments configures how contexts are assigned to experiments (holdout groups and mutual-exclusion layers):
+func (c *Config) WithExperiments(experimentsConfig experiments.Config) *Config {
+	c.experiments = experimentsConfig
+	return c
+}
+
+// WithFailoverAfterErrors sets how many connection errors in a row it takes for us to fail over to the next server address:
+func (c *Config) WithFailoverAfterErrors(failoverAfterErrors int) *Config {
+	c.FailoverAfterErrors = failoverAfterErrors
+	return c
+}
+
+// WithFallbackServerAddresses adds other FeatureHub API endpoints to fail over to (in order) if ServerAddress stops working:
+func (c *Config) WithFallbackServerAddresses(fallbackServerAddresses ...string) *Config {
+	c.FallbackServerAddresses = fallbackServerAddresses
+	return c
+}
+
 // WithFatalErrorHandler configures an error handler which will be called for asynchronous fatal errors:
 func (c *Config) WithFatalErrorHandler(fatalErrorFunc ErrorFunc) *Config {
 	c.fatalErrorHandler = &fatalErrorFunc
 	return c
 }
 
+// WithHTTPClient configures the HTTP client used to talk to the FeatureHub server (eg one with mTLS, a proxy or custom CAs):
+func (c *Config) WithHTTPClient(httpClient *http.Client) *Config {
+	c.httpClient = httpClient
+	return c
+}
+
+// WithHeaders adds extra headers which will be sent with every request to the FeatureHub server (eg auth for a gateway):
+func (c *Config) WithHeaders(headers http.Header) *Config {
+	c.headers = headers.Clone()
+	return c
+}
+
+// WithImpressions configures how impressions (of features being evaluated for a context) are sampled and de-duplicated:
+func (c *Config) WithImpressions(impressionConfig analytics.ImpressionConfig) *Config {
+	c.impressions = impressionConfig
+	return c
+}
+
 // WithLogLevel adds a logLevel to the config:
 func (c *Config) WithLogLevel(logLevel logrus.Level) *Config {
 	c.LogLevel = logLevel
 	return c
 }
 
+// WithLogger configures a logger (eg logging.NewSlogLogger(slog.Default())), instead of the default logrus one:
+func (c *Config) WithLogger(logger logging.Logger) *Config {
+	c.logger = logger
+	return c
+}
+
+// WithMetrics configures a metrics implementation (eg metrics.NewPrometheusMetrics()):
+func (c *Config) WithMetrics(metrics interfaces.Metrics) *Config {
+	c.metrics = metrics
+	return c
+}
+
+// WithStaleAfter sets how long we can go without any events (including acks) before we're stale and force a reconnect:
+func (c *Config) WithStaleAfter(staleAfter time.Duration) *Config {
+	c.StaleAfter = staleAfter
+	return c
+}
+
+// WithTracer configures a tracer implementation (eg opentelemetry.NewTracer(nil)):
+func (c *Config) WithTracer(tracer interfaces.Tracer) *Config {
+	c.tracer = tracer
+	return c
+}
+
 // WithWaitForData adds a WaitForData config:
 func (c *Config) WithWaitForData(value bool) *Config {
 	c.WaitForData = value
 	return c
 }
 
+// WithWaitForDataTimeout limits how long WaitForData will block for:
+func (c *Config) WithWaitForDataTimeout(waitForDataTimeout time.Duration) *Config {
+	c.WaitForDataTimeout = waitForDataTimeout
+	return c
+}
+
 // featuresURL give us the full URL for receiving features:
 func (c *Config) featuresURL() string {
-	return fmt.Sprintf("%s/features/%s", c.ServerAddress, c.SDKKey)
+	return c.featuresURLFor(c.ServerAddress)
+}
+
+// featuresURLFor give us the full URL for receiving features from a particular server address:
+func (c *Config) featuresURLFor(serverAddress string) string {
+	return fmt.Sprintf("%s/features/%s", serverAddress, c.SDKKey)
+}
+
+// serverAddresses gives us every server address we can use, in order of preference:
+func (c *Config) serverAddresses() []string {
+	return append([]string{c.ServerAddress}, c.FallbackServerAddresses...)
+}
+
+// failoverAfterErrors gives us the number of connection errors in a row which trigger a failover:
+func (c *Config) failoverAfterErrors() int {
+	if c.FailoverAfterErrors <= 0 {
+		return defaultFailoverAfterErrors
+	}
+	return c.FailoverAfterErrors
+}
+
+// environmentID gives us the environment ID from the SDK key ("{namedCache}/environmentID/APIKey" or "environmentID/APIKey"):
+func (c *Config) environmentID() string {
+	sdkKeyParts := strings.Split(c.SDKKey, "/")
+	switch {
+	case len(sdkKeyParts) >= 3:
+		return sdkKeyParts[1]
+	case len(sdkKeyParts) == 2:
+		return sdkKeyParts[0]
+	default:
+		return ""
+	}
+}
+
+// newLogger makes a logger for a client (the configured one, any set with the deprecated SetLogger, or logrus at LogLevel), which redacts the SDK key from everything it logs:
+func (c *Config) newLogger() logging.Logger {
+	logger := c.logger
+	if logger == nil {
+		logrusLogger := getDefaultLogrusLogger()
+		if logrusLogger == nil {
+			logrusLogger = logrus.New()
+			logrusLogger.SetLevel(c.LogLevel)
+		}
+		logger = logging.NewLogrusLogger(logrusLogger)
+	}
+
+	// The API key is the last part of the SDK key:
+	sdkKeyParts := strings.Split(c.SDKKey, "/")
+	return logging.NewRedactingLogger(logger, c.SDKKey, sdkKeyParts[len(sdkKeyParts)-1])
+}
+
+// newHTTPClient makes an HTTP client for talking to the FeatureHub server (the configured one, or a default with the configured timeouts):
+func (c *Config) newHTTPClient() *http.Client {
+
+	// Copy the configured client (the SSE library changes its CheckRedirect, which shouldn't leak back to the caller):
+	if c.httpClient != nil {
+		httpClient := *c.httpClient
+		return &httpClient
+	}
+
+	if c.ConnectTimeout <= 0 {
+		return &http.Client{}
+	}
+
+	// Everything up to the response headers counts as connecting (the body is streamed for as long as we're connected):
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{Timeout: c.ConnectTimeout}).DialContext
+	transport.TLSHandshakeTimeout = c.ConnectTimeout
+	transport.ResponseHeaderTimeout = c.ConnectTimeout
+
+	return &http.Client{Transport: transport}
+}
+
+// newRequest prepares a GET request to the FeatureHub server (with any configured headers):
+func (c *Config) newRequest(url string) (*http.Request, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range c.headers {
+		req.Header[key] = values
+	}
+	return req, nil
+}
+
+// problems checks the config, returning anything that is wrong with it:
+func (c *Config) problems() []errors.ConfigProblem {
+	var problems []errors.ConfigProblem
+
+	// LogLevel shouldn't be empty:
+	if c.LogLevel == 0 {
+		c.LogLevel = logrus.InfoLevel
+	}
+
+	// SDKKey shouldn't be empty, and should be 2 or 3 strings delimited with a slash:
+	switch {
+	case len(c.SDKKey) == 0:
+		problems = append(problems, errors.ConfigProblem{Field: "SDKKey", Message: "SDKKey is required"})
+	case len(strings.Split(c.SDKKey, "/")) < 2:
+		problems = append(problems, errors.ConfigProblem{Field: "SDKKey", Message: "Invalid SDKKey format"})
+	}
+
+	// ServerAddress shouldn't be empty, and should be an HTTP(S) URL:
+	if len(c.ServerAddress) == 0 {
+		problems = append(problems, errors.ConfigProblem{Field: "ServerAddress", Message: "ServerAddress is required"})
+	} else if !isHTTPURL(c.ServerAddress) {
+		problems = append(problems, errors.ConfigProblem{Field: "ServerAddress", Message: "ServerAddress must be an http:// or https:// URL"})
+	}
+
+	// So should every fallback server address:
+	for _, fallbackServerAddress := range c.FallbackServerAddresses {
+		if !isHTTPURL(fallbackServerAddress) {
+			problems = append(problems, errors.ConfigProblem{Field: "FallbackServerAddresses", Message: "FallbackServerAddresses must all be http:// or https:// URLs"})
+			break
+		}
+	}
+
+	// FailoverAfterErrors can't be negative:
+	if c.FailoverAfterErrors < 0 {
+		problems = append(problems, errors.ConfigProblem{Field: "FailoverAfterErrors", Message: "FailoverAfterErrors can't be negative"})
+	}
+
+	// Timeouts can't be negative:
+	if c.ConnectTimeout < 0 {
+		problems = append(problems, errors.ConfigProblem{Field: "ConnectTimeout", Message: "ConnectTimeout can't be negative"})
+	}
+	if c.WaitForDataTimeout < 0 {
+		problems = append(problems, errors.ConfigProblem{Field: "WaitForDataTimeout", Message: "WaitForDataTimeout can't be negative"})
+	}
+	if c.StaleAfter < 0 {
+		problems = append(problems, errors.ConfigProblem{Field: "StaleAfter", Message: "StaleAfter can't be negative"})
+	}
+
+	// A custom HTTP client brings its own timeouts:
+	if c.ConnectTimeout > 0 && c.httpClient != nil {
+		problems = append(problems, errors.ConfigProblem{Field: "ConnectTimeout", Message: "ConnectTimeout can't be used with a custom HTTP client (configure its transport instead)"})
+	}
+
+	return problems
+}
+
+// isHTTPURL tells us whether an address is an HTTP(S) URL:
+func isHTTPURL(address string) bool {
+	parsedURL, err := url.Parse(address)
+	return err == nil && (parsedURL.Scheme == "http" || parsedURL.Scheme == "https") && len(parsedURL.Host) > 0
+}
+
+// evaluate applies a context to a feature, keeping its userkey in any percentage strategy it has been assigned (if there is an assignment store):
+// - a new assignment is stored if the evaluation makes one, so this is only for evaluations which a user actually experiences (Evaluate, Get* and Variant)
+func (c *Config) evaluate(featureState *models.FeatureState, clientContext *models.Context, logger logging.Logger) *models.EvaluatedFeature {
+	return c.evaluateAssigned(featureState, clientContext, logger, true)
+}
+
+// evaluateReadOnly is evaluate without storing any new assignment (for PreviewAll, debugging and analytics, which only look at features):
+func (c *Config) evaluateReadOnly(featureState *models.FeatureState, clientContext *models.Context, logger logging.Logger) *models.EvaluatedFeature {
+	return c.evaluateAssigned(featureState, clientContext, logger, false)
+}
+
+// evaluateAssigned applies a context to a feature using any existing assignment, optionally storing a new one:
+func (c *Config) evaluateAssigned(featureState *models.FeatureState, clientContext *models.Context, logger logging.Logger, storeAssignment bool) *models.EvaluatedFeature {
+	if c == nil || c.assignmentStore == nil || clientContext == nil || len(clientContext.Userkey) == 0 || !featureState.Strategies.HasPercentage() {
+		return featureState.EvaluateWithLogger(clientContext, logger)
+	}
+
+	// A broken store shouldn't stop us from evaluating features:
+	assignment, err := c.assignmentStore.Get(clientContext.Userkey, featureState.Key)
+	if err != nil {
+		logging.OrNoop(logger).WithError(err).Warn("Unable to get an assignment, evaluating without one")
+	}
+
+	// Remember any new assignment:
+	evaluatedFeature, newAssignment := featureState.EvaluateAssigned(clientContext, assignment, logger)
+	if newAssignment != nil && storeAssignment {
+		if err := c.assignmentStore.Set(newAssignment); err != nil {
+			logging.OrNoop(logger).WithError(err).Warn("Unable to store an assignment")
+		}
+	}
+
+	return evaluatedFeature
+}
+
+// getExperiments returns the configured experiments config (or the defaults):
+func (c *Config) getExperiments() experiments.Config {
+	if c == nil {
+		return experiments.Config{}
+	}
+	return c.experiments
+}
+
+// experimentAssigner returns an experiment assigner for clients which don't keep their own, making it the first time we need one (so exposures are only logged once per config):
+func (c *Config) experimentAssigner() *experiments.Assigner {
+	if c == nil {
+		return experiments.NewAssigner(experiments.Config{})
+	}
+
+	c.experimentsMutex.Lock()
+	defer c.experimentsMutex.Unlock()
+
+	if c.experimentsAssigner == nil {
+		c.experimentsAssigner = experiments.NewAssigner(c.experiments)
+	}
+	return c.experimentsAssigner
+}
+
+// getMetrics returns the configured metrics implementation (or one which does nothing):
+func (c *Config) getMetrics() interfaces.Metrics {
+	if c == nil || c.metrics == nil {
+		return metrics.NoopMetrics{}
+	}
+	return c.metrics
+}
+
+// getTracer returns the configured tracer implementation (or one which does nothing):
+func (c *Config) getTracer() interfaces.Tracer {
+	if c == nil || c.tracer == nil {
+		return tracing.NoopTracer{}
+	}
+	return c.tracer
 }
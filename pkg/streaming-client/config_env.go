@@ -0,0 +1,134 @@
+package streamingclient
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// Environment variables which ConfigFromEnv understands:
+const (
+	EnvAPIKey             = "FEATUREHUB_API_KEY"               // SDKKey
+	EnvConnectTimeout     = "FEATUREHUB_CONNECT_TIMEOUT"       // ConnectTimeout (eg "5s")
+	EnvEdgeURL            = "FEATUREHUB_EDGE_URL"              // ServerAddress
+	EnvFailoverAfter      = "FEATUREHUB_FAILOVER_AFTER_ERRORS" // FailoverAfterErrors (eg "3")
+	EnvFallbackEdgeURLs   = "FEATUREHUB_FALLBACK_EDGE_URLS"    // FallbackServerAddresses (comma-separated)
+	EnvLogLevel           = "FEATUREHUB_LOG_LEVEL"             // LogLevel (eg "warn")
+	EnvStaleAfter         = "FEATUREHUB_STALE_AFTER"           // StaleAfter (eg "2m")
+	EnvWaitForData        = "FEATUREHUB_WAIT_FOR_DATA"         // WaitForData (eg "true")
+	EnvWaitForDataTimeout = "FEATUREHUB_WAIT_FOR_DATA_TIMEOUT" // WaitForDataTimeout (eg "30s")
+)
+
+// ConfigFromEnv returns a Config built from FEATUREHUB_* environment variables (which is then validated):
+func ConfigFromEnv() (*Config, error) {
+	return rawConfig{
+		apiKey:             configValue{name: EnvAPIKey, value: os.Getenv(EnvAPIKey)},
+		connectTimeout:     configValue{name: EnvConnectTimeout, value: os.Getenv(EnvConnectTimeout)},
+		edgeURL:            configValue{name: EnvEdgeURL, value: os.Getenv(EnvEdgeURL)},
+		failoverAfter:      configValue{name: EnvFailoverAfter, value: os.Getenv(EnvFailoverAfter)},
+		fallbackEdgeURLs:   configValue{name: EnvFallbackEdgeURLs, value: os.Getenv(EnvFallbackEdgeURLs)},
+		logLevel:           configValue{name: EnvLogLevel, value: os.Getenv(EnvLogLevel)},
+		staleAfter:         configValue{name: EnvStaleAfter, value: os.Getenv(EnvStaleAfter)},
+		waitForData:        configValue{name: EnvWaitForData, value: os.Getenv(EnvWaitForData)},
+		waitForDataTimeout: configValue{name: EnvWaitForDataTimeout, value: os.Getenv(EnvWaitForDataTimeout)},
+	}.config()
+}
+
+// configValue is an unparsed config value, along with where it came from (an environment variable or a key in a file):
+type configValue struct {
+	name  string
+	value string
+}
+
+// rawConfig holds unparsed config values (from the environment or a file):
+type rawConfig struct {
+	apiKey             configValue
+	connectTimeout     configValue
+	edgeURL            configValue
+	failoverAfter      configValue
+	fallbackEdgeURLs   configValue // Comma-separated
+	logLevel           configValue
+	staleAfter         configValue
+	waitForData        configValue
+	waitForDataTimeout configValue
+}
+
+// config parses the raw values into a Config, then validates it (every problem is reported at once):
+func (r rawConfig) config() (*Config, error) {
+	var problems []errors.ConfigProblem
+	config := NewConfig(r.edgeURL.value, r.apiKey.value)
+
+	// Parse the values which aren't strings:
+	if r.logLevel.value != "" {
+		logLevel, err := logrus.ParseLevel(r.logLevel.value)
+		if err != nil {
+			problems = append(problems, errors.ConfigProblem{Field: r.logLevel.name, Message: r.logLevel.name + " must be a log level (eg \"info\")"})
+		}
+		config.LogLevel = logLevel
+	}
+	if r.waitForData.value != "" {
+		waitForData, err := strconv.ParseBool(r.waitForData.value)
+		if err != nil {
+			problems = append(problems, errors.ConfigProblem{Field: r.waitForData.name, Message: r.waitForData.name + " must be true or false"})
+		}
+		config.WaitForData = waitForData
+	}
+	if r.failoverAfter.value != "" {
+		failoverAfter, err := strconv.Atoi(r.failoverAfter.value)
+		if err != nil {
+			problems = append(problems, errors.ConfigProblem{Field: r.failoverAfter.name, Message: r.failoverAfter.name + " must be a whole number"})
+		}
+		config.FailoverAfterErrors = failoverAfter
+	}
+	if r.fallbackEdgeURLs.value != "" {
+		for _, fallbackEdgeURL := range strings.Split(r.fallbackEdgeURLs.value, ",") {
+			config.FallbackServerAddresses = append(config.FallbackServerAddresses, strings.TrimSpace(fallbackEdgeURL))
+		}
+	}
+	config.ConnectTimeout, problems = parseDuration(r.connectTimeout, problems)
+	config.StaleAfter, problems = parseDuration(r.staleAfter, problems)
+	config.WaitForDataTimeout, problems = parseDuration(r.waitForDataTimeout, problems)
+
+	// Validate the config, naming the problems after where the values came from:
+	for _, problem := range config.problems() {
+		switch problem.Field {
+		case "SDKKey":
+			problem.Field = r.apiKey.name
+		case "ServerAddress":
+			problem.Field = r.edgeURL.name
+		case "ConnectTimeout":
+			problem.Field = r.connectTimeout.name
+		case "FailoverAfterErrors":
+			problem.Field = r.failoverAfter.name
+		case "FallbackServerAddresses":
+			problem.Field = r.fallbackEdgeURLs.name
+		case "StaleAfter":
+			problem.Field = r.staleAfter.name
+		case "WaitForDataTimeout":
+			problem.Field = r.waitForDataTimeout.name
+		}
+		problems = append(problems, problem)
+	}
+
+	if len(problems) > 0 {
+		return nil, errors.NewErrBadConfigProblems(problems...)
+	}
+
+	return config, nil
+}
+
+// parseDuration parses a config value into a duration (adding a problem if it isn't one):
+func parseDuration(value configValue, problems []errors.ConfigProblem) (time.Duration, []errors.ConfigProblem) {
+	if value.value == "" {
+		return 0, problems
+	}
+	duration, err := time.ParseDuration(value.value)
+	if err != nil {
+		return 0, append(problems, errors.ConfigProblem{Field: value.name, Message: value.name + " must be a duration (eg \"5s\")"})
+	}
+	return duration, problems
+}
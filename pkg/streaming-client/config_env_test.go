@@ -0,0 +1,66 @@
+package streamingclient
+
+import (
+	"testing"
+	"time"
+
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigFromEnv(t *testing.T) {
+
+	// Nothing set:
+	config, err := ConfigFromEnv()
+	assert.Nil(t, config)
+	assert.IsType(t, &errors.ErrBadConfig{}, err)
+	assert.Equal(t, []errors.ConfigProblem{
+		{Field: EnvAPIKey, Message: "SDKKey is required"},
+		{Field: EnvEdgeURL, Message: "ServerAddress is required"},
+	}, err.(*errors.ErrBadConfig).Problems())
+
+	// Every problem is reported at once:
+	t.Setenv(EnvEdgeURL, "streams.test:8086")
+	t.Setenv(EnvAPIKey, "my-secret-api-key")
+	t.Setenv(EnvLogLevel, "loud")
+	t.Setenv(EnvWaitForData, "maybe")
+	t.Setenv(EnvWaitForDataTimeout, "a while")
+	t.Setenv(EnvConnectTimeout, "-5s")
+	_, err = ConfigFromEnv()
+	assert.EqualError(t, err, `Invalid config: FEATUREHUB_LOG_LEVEL must be a log level (eg "info"); FEATUREHUB_WAIT_FOR_DATA must be true or false; FEATUREHUB_WAIT_FOR_DATA_TIMEOUT must be a duration (eg "5s"); Invalid SDKKey format; ServerAddress must be an http:// or https:// URL; ConnectTimeout can't be negative`)
+	fields := make([]string, 0)
+	for _, problem := range err.(*errors.ErrBadConfig).Problems() {
+		fields = append(fields, problem.Field)
+	}
+	assert.Equal(t, []string{EnvLogLevel, EnvWaitForData, EnvWaitForDataTimeout, EnvAPIKey, EnvEdgeURL, EnvConnectTimeout}, fields)
+
+	// Now a valid config:
+	t.Setenv(EnvEdgeURL, "http://streams.test:8086")
+	t.Setenv(EnvAPIKey, "default/environment-id/my-secret-api-key")
+	t.Setenv(EnvLogLevel, "warn")
+	t.Setenv(EnvWaitForData, "true")
+	t.Setenv(EnvWaitForDataTimeout, "30s")
+	t.Setenv(EnvConnectTimeout, "5s")
+	t.Setenv(EnvFallbackEdgeURLs, "http://fallback1.test:8086, http://fallback2.test:8086")
+	t.Setenv(EnvFailoverAfter, "2")
+	t.Setenv(EnvStaleAfter, "2m")
+	config, err = ConfigFromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, "http://streams.test:8086", config.ServerAddress)
+	assert.Equal(t, "default/environment-id/my-secret-api-key", config.SDKKey)
+	assert.Equal(t, logrus.WarnLevel, config.LogLevel)
+	assert.True(t, config.WaitForData)
+	assert.Equal(t, 30*time.Second, config.WaitForDataTimeout)
+	assert.Equal(t, 5*time.Second, config.ConnectTimeout)
+	assert.Equal(t, []string{"http://fallback1.test:8086", "http://fallback2.test:8086"}, config.FallbackServerAddresses)
+	assert.Equal(t, 2, config.FailoverAfterErrors)
+	assert.Equal(t, 2*time.Minute, config.StaleAfter)
+
+	// Fallback addresses are checked too:
+	t.Setenv(EnvFallbackEdgeURLs, "fallback1.test:8086")
+	t.Setenv(EnvFailoverAfter, "two")
+	_, err = ConfigFromEnv()
+	assert.EqualError(t, err, "Invalid config: FEATUREHUB_FAILOVER_AFTER_ERRORS must be a whole number; FallbackServerAddresses must all be http:// or https:// URLs")
+	assert.Equal(t, EnvFallbackEdgeURLs, err.(*errors.ErrBadConfig).Problems()[1].Field)
+}
@@ -0,0 +1,77 @@
+package streamingclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// configFile is the format of a JSON or YAML config file:
+type configFile struct {
+	APIKey             string   `json:"apiKey" yaml:"apiKey"`
+	ConnectTimeout     string   `json:"connectTimeout" yaml:"connectTimeout"`
+	EdgeURL            string   `json:"edgeUrl" yaml:"edgeUrl"`
+	FailoverAfter      *int     `json:"failoverAfterErrors" yaml:"failoverAfterErrors"`
+	FallbackEdgeURLs   []string `json:"fallbackEdgeUrls" yaml:"fallbackEdgeUrls"`
+	LogLevel           string   `json:"logLevel" yaml:"logLevel"`
+	StaleAfter         string   `json:"staleAfter" yaml:"staleAfter"`
+	WaitForData        *bool    `json:"waitForData" yaml:"waitForData"`
+	WaitForDataTimeout string   `json:"waitForDataTimeout" yaml:"waitForDataTimeout"`
+}
+
+// ConfigFromFile returns a Config loaded from a JSON (".json") or YAML (".yaml" / ".yml") file (which is then validated):
+func ConfigFromFile(path string) (*Config, error) {
+
+	// Read the file:
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal it according to its extension (unknown keys are most likely typos, so they are rejected):
+	var file configFile
+	switch extension := strings.ToLower(filepath.Ext(path)); extension {
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(contents))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&file); err != nil {
+			return nil, errors.NewErrBadConfig(fmt.Sprintf("Unable to parse %s: %s", path, err))
+		}
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(contents))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&file); err != nil {
+			return nil, errors.NewErrBadConfig(fmt.Sprintf("Unable to parse %s: %s", path, err))
+		}
+	default:
+		return nil, errors.NewErrBadConfig(fmt.Sprintf("Unsupported config file extension (%q), expected .json, .yaml or .yml", extension))
+	}
+
+	// Parse and validate the values:
+	raw := rawConfig{
+		apiKey:             configValue{name: "apiKey", value: file.APIKey},
+		connectTimeout:     configValue{name: "connectTimeout", value: file.ConnectTimeout},
+		edgeURL:            configValue{name: "edgeUrl", value: file.EdgeURL},
+		failoverAfter:      configValue{name: "failoverAfterErrors"},
+		fallbackEdgeURLs:   configValue{name: "fallbackEdgeUrls", value: strings.Join(file.FallbackEdgeURLs, ",")},
+		logLevel:           configValue{name: "logLevel", value: file.LogLevel},
+		staleAfter:         configValue{name: "staleAfter", value: file.StaleAfter},
+		waitForData:        configValue{name: "waitForData"},
+		waitForDataTimeout: configValue{name: "waitForDataTimeout", value: file.WaitForDataTimeout},
+	}
+	if file.FailoverAfter != nil {
+		raw.failoverAfter.value = strconv.Itoa(*file.FailoverAfter)
+	}
+	if file.WaitForData != nil {
+		raw.waitForData.value = strconv.FormatBool(*file.WaitForData)
+	}
+
+	return raw.config()
+}
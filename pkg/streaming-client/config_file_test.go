@@ -0,0 +1,74 @@
+package streamingclient
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigFromFile(t *testing.T) {
+
+	// Write some config files:
+	directory := t.TempDir()
+	writeFile := func(name, contents string) string {
+		path := filepath.Join(directory, name)
+		assert.NoError(t, os.WriteFile(path, []byte(contents), 0600))
+		return path
+	}
+
+	// JSON:
+	config, err := ConfigFromFile(writeFile("featurehub.json", `{
+		"edgeUrl": "http://streams.test:8086",
+		"apiKey": "default/environment-id/my-secret-api-key",
+		"logLevel": "debug",
+		"waitForData": true,
+		"waitForDataTimeout": "10s",
+		"connectTimeout": "2s",
+		"fallbackEdgeUrls": ["http://fallback.test:8086"],
+		"failoverAfterErrors": 5
+	}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "http://streams.test:8086", config.ServerAddress)
+	assert.Equal(t, "default/environment-id/my-secret-api-key", config.SDKKey)
+	assert.Equal(t, logrus.DebugLevel, config.LogLevel)
+	assert.True(t, config.WaitForData)
+	assert.Equal(t, 10*time.Second, config.WaitForDataTimeout)
+	assert.Equal(t, 2*time.Second, config.ConnectTimeout)
+	assert.Equal(t, []string{"http://fallback.test:8086"}, config.FallbackServerAddresses)
+	assert.Equal(t, 5, config.FailoverAfterErrors)
+
+	// YAML (log level defaults to info):
+	config, err = ConfigFromFile(writeFile("featurehub.yml", "edgeUrl: https://streams.test\napiKey: environment-id/my-secret-api-key\nfallbackEdgeUrls:\n  - https://fallback.test\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, "https://streams.test", config.ServerAddress)
+	assert.Equal(t, logrus.InfoLevel, config.LogLevel)
+	assert.False(t, config.WaitForData)
+	assert.Equal(t, []string{"https://fallback.test"}, config.FallbackServerAddresses)
+
+	// Problems are named after the keys in the file:
+	_, err = ConfigFromFile(writeFile("invalid.yaml", "edgeUrl: ftp://streams.test\nconnectTimeout: soon\n"))
+	assert.IsType(t, &errors.ErrBadConfig{}, err)
+	assert.Equal(t, []errors.ConfigProblem{
+		{Field: "connectTimeout", Message: `connectTimeout must be a duration (eg "5s")`},
+		{Field: "apiKey", Message: "SDKKey is required"},
+		{Field: "edgeUrl", Message: "ServerAddress must be an http:// or https:// URL"},
+	}, err.(*errors.ErrBadConfig).Problems())
+
+	// Unknown keys, unparseable files and unsupported extensions are rejected:
+	_, err = ConfigFromFile(writeFile("typo.json", `{"edge_url": "http://streams.test:8086"}`))
+	assert.IsType(t, &errors.ErrBadConfig{}, err)
+	assert.Contains(t, err.Error(), "edge_url")
+	_, err = ConfigFromFile(writeFile("garbage.yaml", "edgeUrl: [this is not"))
+	assert.IsType(t, &errors.ErrBadConfig{}, err)
+	_, err = ConfigFromFile(writeFile("featurehub.toml", `edgeUrl = "http://streams.test:8086"`))
+	assert.EqualError(t, err, `Invalid config: Unsupported config file extension (".toml"), expected .json, .yaml or .yml`)
+
+	// Missing files:
+	_, err = ConfigFromFile(filepath.Join(directory, "missing.json"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
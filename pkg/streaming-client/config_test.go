@@ -1,8 +1,12 @@
 package streamingclient
 
 import (
+	"bytes"
+	"net/http"
 	"testing"
+	"time"
 
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/errors"
 	"github.com/featurehub-io/featurehub-go-sdk/pkg/mocks"
 	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
 	"github.com/sirupsen/logrus"
@@ -65,6 +69,50 @@ func TestConfigValidation(t *testing.T) {
 	featuresURL := config.featuresURL()
 	assert.Equal(t, "http://streams.test:8086/features/default/environment-id/my-secret-api-key", featuresURL)
 
+	// Check that we can find the environment ID (with or without a named cache):
+	assert.Equal(t, "environment-id", config.environmentID())
+	config.SDKKey = "environment-id/my-secret-api-key"
+	assert.Equal(t, "environment-id", config.environmentID())
+
 	// Now try a valid config:
 	assert.NoError(t, config.Validate())
+
+	// Everything that is wrong is reported at once:
+	config = &Config{ServerAddress: "streams.test:8086", ConnectTimeout: -time.Second, WaitForDataTimeout: -time.Second}
+	err := config.Validate()
+	assert.EqualError(t, err, "Invalid config: SDKKey is required; ServerAddress must be an http:// or https:// URL; ConnectTimeout can't be negative; WaitForDataTimeout can't be negative")
+	assert.Len(t, err.(*errors.ErrBadConfig).Problems(), 4)
+	assert.Equal(t, "ServerAddress", err.(*errors.ErrBadConfig).Problems()[1].Field)
+
+	// The connect timeout is applied to the HTTP client:
+	assert.Nil(t, config.newHTTPClient().Transport)
+	transport := config.WithConnectTimeout(5 * time.Second).newHTTPClient().Transport.(*http.Transport)
+	assert.Equal(t, 5*time.Second, transport.TLSHandshakeTimeout)
+	assert.Equal(t, 5*time.Second, transport.ResponseHeaderTimeout)
+}
+
+func TestSetLogger(t *testing.T) {
+
+	// Set a (deprecated) package-level logger:
+	logger := logrus.New()
+	logger.SetLevel(logrus.TraceLevel)
+	logBuffer := new(bytes.Buffer)
+	logger.SetOutput(logBuffer)
+	SetLogger(logger)
+	defer SetLogger(nil)
+
+	// New clients log to it:
+	NewConfig("myserver", "default/environment-id/my-secret-api-key").newLogger().Info("Hello from the client")
+	assert.Contains(t, logBuffer.String(), "Hello from the client")
+
+	// So does strategy evaluation (through the model methods which don't take a logger):
+	featureState := &models.FeatureState{
+		Key:        "TestFeature",
+		Type:       models.TypeString,
+		Value:      "default",
+		Strategies: []models.Strategy{{ID: "strategy1", Value: "for the russians", Attributes: []*models.StrategyAttribute{{FieldName: "country", Conditional: "EQUALS", Type: "STRING", Values: []interface{}{"russia"}}}}},
+	}
+	logBuffer.Reset()
+	assert.Equal(t, "default", featureState.Evaluate(&models.Context{Country: models.ContextCountryNewZealand}).Value)
+	assert.Contains(t, logBuffer.String(), "Checking strategy (strategy1)")
 }
@@ -1,12 +1,33 @@
 package streamingclient
 
+import (
+	"sync"
 
-import "github.com/sirupsen/logrus"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
+	"github.com/sirupsen/logrus"
+)
 
-// logger is a package-global logger which can be used by model methods (this prevents us having to pass a logger all over the place):
-var logger *logrus.Logger = logrus.New()
+var (
+	defaultLogrusLogger      *logrus.Logger
+	defaultLogrusLoggerMutex sync.RWMutex
+)
 
-// SetLogger allows other packages to update the logger:
+// SetLogger sets the logrus logger which new clients log to (unless they are configured with their own logger):
+// - the model methods which don't take a logger (eg FeatureState.Evaluate) log to it too, as they did before loggers were configurable
+//
+// Deprecated: configure each client's logger with Config.WithLogger (eg logging.NewLogrusLogger(logger)) instead.
 func SetLogger(newLogger *logrus.Logger) {
-	logger = newLogger
+	defaultLogrusLoggerMutex.Lock()
+	defer defaultLogrusLoggerMutex.Unlock()
+	defaultLogrusLogger = newLogger
+
+	// Strategy evaluation used to log here as well:
+	models.SetLogger(newLogger)
+}
+
+// getDefaultLogrusLogger returns the logrus logger set with SetLogger (if there is one):
+func getDefaultLogrusLogger() *logrus.Logger {
+	defaultLogrusLoggerMutex.RLock()
+	defer defaultLogrusLoggerMutex.RUnlock()
+	return defaultLogrusLogger
 }
@@ -1,7 +1,10 @@
 package streamingclient
 
 import (
+	"time"
+
 	"github.com/featurehub-io/featurehub-go-sdk/pkg/errors"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/interfaces"
 	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
 )
 
@@ -17,8 +20,13 @@ type notifier struct {
 	uuid                string
 }
 
-// notify triggers the appropriate callback function for this notifier type:
-func (n *notifier) notify(feature *models.FeatureState) error {
+// notify triggers the appropriate callback function for this notifier type (reporting how long it took to the given metrics):
+func (n *notifier) notify(feature *models.FeatureState, metrics interfaces.Metrics) error {
+	var callback func()
+
+	// Callbacks run in the background, so they need their own copy of the notifier:
+	notifierCopy := *n
+	n = &notifierCopy
 
 	// Switch on the stored type:
 	switch n.featureValueType {
@@ -28,36 +36,43 @@ func (n *notifier) notify(feature *models.FeatureState) error {
 		if !ok {
 			return errors.NewErrInvalidType("Unable to assert as bool")
 		}
-		go n.callbackFuncBoolean(assertedValue)
+		callback = func() { n.callbackFuncBoolean(assertedValue) }
 
 	case models.TypeFeature:
-		go n.callbackFuncFeature(feature)
+		callback = func() { n.callbackFuncFeature(feature) }
 
 	case models.TypeJSON:
 		assertedValue, ok := feature.Value.(string)
 		if !ok {
 			return errors.NewErrInvalidType("Unable to assert as string")
 		}
-		go n.callbackFuncJSON(assertedValue)
+		callback = func() { n.callbackFuncJSON(assertedValue) }
 
 	case models.TypeNumber:
 		assertedValue, ok := feature.Value.(float64)
 		if !ok {
 			return errors.NewErrInvalidType("Unable to assert as int64")
 		}
-		go n.callbackFuncNumber(assertedValue)
+		callback = func() { n.callbackFuncNumber(assertedValue) }
 
 	case models.TypeString:
 		assertedValue, ok := feature.Value.(string)
 		if !ok {
 			return errors.NewErrInvalidType("Unable to assert as string")
 		}
-		go n.callbackFuncString(assertedValue)
+		callback = func() { n.callbackFuncString(assertedValue) }
 
 	default:
 		return errors.NewErrInvalidType(string(n.featureValueType))
 	}
 
+	// Run the callback in the background, and time it:
+	go func() {
+		startTime := time.Now()
+		callback()
+		metrics.NotifierLatency(n.featureKey, time.Since(startTime))
+	}()
+
 	return nil
 }
 
@@ -0,0 +1,187 @@
+package streamingclient
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/errors"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
+)
+
+// Registry manages several StreamingClients (eg one per environment or named cache), sharing an HTTP transport between them:
+type Registry struct {
+	clients    map[string]*StreamingClient
+	closed     bool
+	httpClient *http.Client
+	mutex      sync.RWMutex
+}
+
+// NewRegistry returns an empty Registry:
+func NewRegistry() *Registry {
+	return &Registry{
+		clients:    make(map[string]*StreamingClient),
+		httpClient: &http.Client{Transport: http.DefaultTransport.(*http.Transport).Clone()},
+	}
+}
+
+// WithHTTPClient configures the HTTP client which is shared by clients whose configs don't have their own:
+func (r *Registry) WithHTTPClient(httpClient *http.Client) *Registry {
+	r.httpClient = httpClient
+	return r
+}
+
+// Add makes a new client with the given config, starts it, and registers it by name (the environment ID from the SDK key if no name is given):
+func (r *Registry) Add(name string, config *Config) (*StreamingClient, error) {
+
+	// Check for nil config:
+	if config == nil {
+		return nil, errors.NewErrBadConfig("Nil config provided")
+	}
+
+	// Default to the environment ID:
+	if name == "" {
+		name = config.environmentID()
+	}
+
+	// Reserve the name (so that concurrent calls can't both connect under it):
+	r.mutex.Lock()
+	if r.closed {
+		r.mutex.Unlock()
+		return nil, errors.NewErrNotReady("the registry is closed")
+	}
+	if _, ok := r.clients[name]; ok {
+		r.mutex.Unlock()
+		return nil, errors.NewErrClientExists(name)
+	}
+	r.clients[name] = nil
+	r.mutex.Unlock()
+
+	// Share our HTTP client (unless the config has its own, or needs one with its own timeouts), without changing the caller's config:
+	var sharedHTTPClient *http.Client
+	if config.httpClient == nil && config.ConnectTimeout <= 0 {
+		sharedHTTPClient = r.httpClient
+	}
+
+	// Make a new client and start it:
+	client, err := newStreamingClient(config, sharedHTTPClient)
+	if err != nil {
+		r.mutex.Lock()
+		delete(r.clients, name)
+		r.mutex.Unlock()
+		return nil, err
+	}
+	client.Start()
+
+	// The registry may have been closed while we were connecting (in which case nobody else will close this client):
+	r.mutex.Lock()
+	if r.closed {
+		r.mutex.Unlock()
+		client.Close()
+		return nil, errors.NewErrNotReady("the registry is closed")
+	}
+	r.clients[name] = client
+	r.mutex.Unlock()
+
+	return client, nil
+}
+
+// Client returns a registered client by name:
+func (r *Registry) Client(name string) (*StreamingClient, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if client := r.clients[name]; client != nil {
+		return client, nil
+	}
+
+	return nil, errors.NewErrClientNotFound(name)
+}
+
+// WithContext returns a ClientWithContext for a registered client (by name):
+func (r *Registry) WithContext(name string, context *models.Context) (*ClientWithContext, error) {
+	client, err := r.Client(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.WithContext(context), nil
+}
+
+// Names returns the names of all registered clients (sorted):
+func (r *Registry) Names() []string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	names := make([]string, 0, len(r.clients))
+	for name, client := range r.clients {
+		if client != nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Ready tells us whether every registered client is ready (an empty registry isn't ready):
+func (r *Registry) Ready() bool {
+	statuses := r.Status()
+	for _, status := range statuses {
+		if status.Readiness != models.ReadinessReady {
+			return false
+		}
+	}
+
+	return len(statuses) > 0
+}
+
+// Status returns the current status of every registered client (by name):
+func (r *Registry) Status() map[string]models.ClientStatus {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	statuses := make(map[string]models.ClientStatus, len(r.clients))
+	for name, client := range r.clients {
+		if client != nil {
+			statuses[name] = client.Status()
+		}
+	}
+
+	return statuses
+}
+
+// Remove closes a registered client, and forgets about it:
+func (r *Registry) Remove(name string) error {
+	r.mutex.Lock()
+	client := r.clients[name]
+	if client != nil {
+		delete(r.clients, name)
+	}
+	r.mutex.Unlock()
+
+	if client == nil {
+		return errors.NewErrClientNotFound(name)
+	}
+
+	client.Close()
+	return nil
+}
+
+// Close closes every registered client (and any connections they were sharing):
+// - clients can't be added once the registry is closed
+func (r *Registry) Close() {
+	r.mutex.Lock()
+	r.closed = true
+	clients := r.clients
+	r.clients = make(map[string]*StreamingClient)
+	r.mutex.Unlock()
+
+	for _, client := range clients {
+		if client != nil {
+			client.Close()
+		}
+	}
+
+	r.httpClient.CloseIdleConnections()
+}
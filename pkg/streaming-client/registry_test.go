@@ -0,0 +1,99 @@
+package streamingclient
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/errors"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry(t *testing.T) {
+
+	// A fake FeatureHub server which streams a different value for each environment (and nothing for "slow" ones):
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		environmentID := strings.Split(r.URL.Path, "/")[3]
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		if !strings.HasPrefix(environmentID, "slow") {
+			fmt.Fprintf(w, "event: features\ndata: [{\"key\":\"environment\",\"type\":\"STRING\",\"value\":%q}]\n\n", environmentID)
+		}
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+	defer server.CloseClientConnections()
+
+	// An empty registry isn't ready:
+	registry := NewRegistry()
+	defer registry.Close()
+	assert.False(t, registry.Ready())
+
+	// Add some clients (by name, and by environment ID):
+	newConfig := func(environmentID string) *Config {
+		return NewConfig(server.URL, "default/"+environmentID+"/my-secret-api-key").WithLogLevel(logrus.FatalLevel)
+	}
+	_, err := registry.Add("production", newConfig("production-id").WithWaitForData(true))
+	assert.NoError(t, err)
+	_, err = registry.Add("", newConfig("staging-id").WithWaitForData(true))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"production", "staging-id"}, registry.Names())
+	assert.True(t, registry.Ready())
+
+	// Lookups are routed by name:
+	value, err := registry.WithContext("production", &models.Context{})
+	assert.NoError(t, err)
+	environment, err := value.GetString("environment")
+	assert.NoError(t, err)
+	assert.Equal(t, "production-id", environment)
+	client, err := registry.Client("staging-id")
+	assert.NoError(t, err)
+	environment, err = client.GetString("environment")
+	assert.NoError(t, err)
+	assert.Equal(t, "staging-id", environment)
+	_, err = registry.Client("development")
+	assert.IsType(t, &errors.ErrClientNotFound{}, err)
+
+	// The clients share the registry's HTTP client (without it leaking into their configs):
+	assert.Same(t, registry.httpClient, client.sharedHTTPClient)
+	assert.Same(t, registry.httpClient.Transport, client.newHTTPClient().Transport)
+	assert.Nil(t, client.config.httpClient)
+
+	// Names can't be re-used, and bad configs are rejected:
+	_, err = registry.Add("production", newConfig("another-id"))
+	assert.IsType(t, &errors.ErrClientExists{}, err)
+	_, err = registry.Add("broken", NewConfig("", ""))
+	assert.IsType(t, &errors.ErrBadConfig{}, err)
+	assert.Equal(t, []string{"production", "staging-id"}, registry.Names())
+
+	// A client without data makes the whole registry unready:
+	_, err = registry.Add("slow", newConfig("slow-id"))
+	assert.NoError(t, err)
+	assert.False(t, registry.Ready())
+	assert.Len(t, registry.Status(), 3)
+	assert.False(t, registry.Status()["slow"].HasData)
+	assert.True(t, registry.Status()["production"].HasData)
+
+	// Removing it closes it:
+	slowClient, _ := registry.Client("slow")
+	assert.NoError(t, registry.Remove("slow"))
+	assert.False(t, slowClient.Status().Connected)
+	assert.IsType(t, &errors.ErrClientNotFound{}, registry.Remove("slow"))
+	assert.True(t, registry.Ready())
+
+	// Closing the registry closes everything:
+	registry.Close()
+	assert.Empty(t, registry.Names())
+	assert.Eventually(t, func() bool { return !client.Status().Connected }, time.Second, 10*time.Millisecond)
+
+	// Nothing can be added once it's closed:
+	_, err = registry.Add("late", newConfig("late-id"))
+	assert.IsType(t, &errors.ErrNotReady{}, err)
+	assert.Empty(t, registry.Names())
+}
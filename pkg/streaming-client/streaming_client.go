@@ -1,36 +1,72 @@
 package streamingclient
 
 import (
+	"context"
 	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/donovanhide/eventsource"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/analytics"
 	"github.com/featurehub-io/featurehub-go-sdk/pkg/errors"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/experiments"
 	"github.com/featurehub-io/featurehub-go-sdk/pkg/interfaces"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/logging"
 	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
-	"github.com/sirupsen/logrus"
 )
 
+// apiClientQuietPeriod is how long an API client has to stop sending us anything before it is safe to close:
+const apiClientQuietPeriod = 50 * time.Millisecond
+
+// apiClientHandle lets us stop handling an API client, and stop it streaming, before we close it:
+type apiClientHandle struct {
+	cancel   context.CancelFunc // Cancels the API client's request
+	handlers sync.WaitGroup     // The handlers reading from the API client
+	stop     chan struct{}      // Closed to tell the handlers to stop reading
+}
+
 // ErrorFunc is called when asynchronous errors are encountered:
 type ErrorFunc func(error, string, map[string]interface{})
 
 // StreamingClient implements the client interface by by subscribing to server-side events:
 type StreamingClient struct {
-	analyticsCollectors []interfaces.AnalyticsCollector
-	analyticsMutex      sync.Mutex
-	apiClient           *eventsource.Stream
-	config              *Config
-	fatalErrorHandler   ErrorFunc
-	features            map[string]*models.FeatureState
-	featuresMutex       sync.Mutex
-	featuresURL         string
-	hasData             bool
-	isRunning           bool
-	logger              *logrus.Logger
-	notifiers           notifiers
-	notifiersMutex      sync.Mutex
-	readinessListener   func()
+	analyticsCollectors      []interfaces.AnalyticsCollector
+	analyticsMutex           sync.Mutex
+	analyticsPipeline        *analytics.Pipeline
+	apiClient                *eventsource.Stream
+	apiClientHandles         map[*eventsource.Stream]*apiClientHandle
+	config                   *Config
+	connected                bool
+	connectionErrors         int
+	deletedFeatures          map[string]*models.FeatureState
+	experiments              *experiments.Assigner
+	failed                   chan struct{}
+	failedReadinessListeners []func(err error)
+	failure                  error
+	fatalErrorHandler        ErrorFunc
+	features                 map[string]*models.FeatureState
+	featuresMutex            sync.Mutex
+	featuresURL              string
+	hasData                  bool
+	impressionFilter         *analytics.ImpressionFilter
+	isRunning                atomic.Bool
+	lastEventAt              time.Time
+	lastEventID              string
+	logImpressions           atomic.Bool
+	logger                   logging.Logger
+	notifiers                notifiers
+	notifiersMutex           sync.Mutex
+	readiness                models.Readiness
+	readinessListeners       []func()
+	ready                    chan struct{}
+	serverAddress            string
+	sharedHTTPClient         *http.Client
+	sharedStrategies         models.SharedStrategies
+	stale                    bool
+	staleListeners           []func(stale bool)
+	statusMutex              sync.Mutex
+	streamHandlers           atomic.Int32
 }
 
 // New wraps NewStreamingClient (as the default / only implementation):
@@ -40,6 +76,11 @@ func New(config *Config) (*StreamingClient, error) {
 
 // NewStreamingClient prepares a new StreamingClient with given config:
 func NewStreamingClient(config *Config) (*StreamingClient, error) {
+	return newStreamingClient(config, nil)
+}
+
+// newStreamingClient prepares a new StreamingClient, which uses a shared HTTP client (if one is given) when the config doesn't have its own:
+func newStreamingClient(config *Config, sharedHTTPClient *http.Client) (*StreamingClient, error) {
 
 	// Check for nil config:
 	if config == nil {
@@ -51,42 +92,55 @@ func NewStreamingClient(config *Config) (*StreamingClient, error) {
 		return nil, err
 	}
 
-	// Make a logger:
-	logger := logrus.New()
-	logger.SetLevel(config.LogLevel)
-
-	// Set this logger in the models package (they use a global to keep the API simple):
-	SetLogger(logger)
+	// Make a logger (which keeps the SDK key out of the logs):
+	logger := config.newLogger()
 
 	// Put this into a new StreamingClient:
 	client := &StreamingClient{
-		config:    config,
-		logger:    logger,
-		notifiers: make(notifiers),
+		config:           config,
+		logger:           logger,
+		notifiers:        make(notifiers),
+		sharedHTTPClient: sharedHTTPClient,
 	}
 
 	// Use the default fatalErrorFunc to handle fatal errors:
 	client.WithFatalErrorHandler(client.fatalErrorFunc)
 
-	// Report that we're starting:
-	logger.WithField("server_address", client.config.ServerAddress).Info("Subscribing to FeatureHub server")
-
-	// Prepare a custom HTTP request:
-	req, err := http.NewRequest("GET", config.featuresURL(), nil)
-	if err != nil {
-		client.logger.WithError(err).Error("Error preparing request")
-		return nil, err
+	// Subscribe to the first server address which works:
+	var err error
+	for _, serverAddress := range config.serverAddresses() {
+		var apiClient *eventsource.Stream
+		if apiClient, err = client.subscribe(serverAddress); err == nil {
+			client.apiClient = apiClient
+			client.serverAddress = serverAddress
+			client.setConnected(true)
+			return client, nil
+		}
 	}
 
-	// Prepare an API client:
-	apiClient, err := eventsource.SubscribeWithRequest("", req)
-	if err != nil {
-		client.logger.WithError(err).Error("Error subscribing to server")
-		return nil, err
-	}
-	client.apiClient = apiClient
+	return nil, err
+}
+
+// Close stops handling events and disconnects from the FeatureHub server (existing data will continue to be served):
+// - queued analytics events are submitted first (for up to 5s), then the analytics workers are stopped
+func (c *StreamingClient) Close() {
+	c.closeStream()
+
+	// Submit any queued analytics events, and stop the analytics workers:
+	c.closeAnalytics()
+}
 
-	return client, nil
+// closeStream stops handling events and disconnects from the FeatureHub server (leaving analytics running):
+func (c *StreamingClient) closeStream() {
+	c.isRunning.Store(false)
+	c.setConnected(false)
+	c.setFailed(errors.NewErrNotReady("closed before receiving any data"))
+
+	// Close the SSE client connection:
+	if apiClient := c.currentAPIClient(); apiClient != nil {
+		c.config.getTracer().Connection(models.ConnectionEventClosed, nil)
+		c.closeAPIClient(apiClient)
+	}
 }
 
 // FatalErrorFunc is called when an unrecoverable asynchronous error is encountered:
@@ -94,26 +148,56 @@ func (c *StreamingClient) fatalErrorFunc(err error, message string, details map[
 	c.logger.WithError(err).WithFields(details).Fatal(message)
 }
 
-// ReadinessListener defines a callback function which will be triggered once the client has received data for the first time:
-func (c *StreamingClient) ReadinessListener(callbackFunc func()) {
-	c.readinessListener = callbackFunc
-}
-
 // Start begins handling events from the streamer:
 func (c *StreamingClient) Start() {
 
 	// Set the isRunning flag:
-	c.isRunning = true
+	c.isRunning.Store(true)
 
 	// Handle incoming events:
-	go c.handleEvents()
-	go c.handleErrors()
+	c.handleStream(c.currentAPIClient())
+
+	// Keep an eye out for connections which have gone quiet:
+	if c.config.StaleAfter > 0 {
+		go c.monitorStaleness()
+	}
 
-	// Block until we have some data:
+	// Block until we have some data (or we run out of time):
 	if c.config.WaitForData {
-		for !c.hasData {
-			time.Sleep(time.Second)
+		ctx := context.Background()
+		if c.config.WaitForDataTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, c.config.WaitForDataTimeout)
+			defer cancel()
 		}
+		if err := c.WaitUntilReady(ctx); err != nil && ctx.Err() != nil {
+			c.logger.WithField("timeout", c.config.WaitForDataTimeout).Warn("Timed out waiting for data")
+		} else if err != nil {
+			c.logger.WithError(err).Warn("Gave up waiting for data")
+		}
+	}
+}
+
+// Status returns the current status of the client:
+func (c *StreamingClient) Status() models.ClientStatus {
+	c.featuresMutex.Lock()
+	featureCount := len(c.features)
+	c.featuresMutex.Unlock()
+
+	c.statusMutex.Lock()
+	defer c.statusMutex.Unlock()
+
+	return models.ClientStatus{
+		Connected:     c.connected,
+		EnvironmentID: c.config.environmentID(),
+		FeatureCount:  featureCount,
+		HasData:       c.hasData,
+		LastEventAt:   c.lastEventAt,
+		LastEventID:   c.lastEventID,
+		ServerAddress: c.serverAddress,
+		Readiness:     c.readinessState(),
+		Running:       c.isRunning.Load() && c.streamHandlers.Load() > 0,
+		Stale:         c.stale,
 	}
 }
 
@@ -132,21 +216,232 @@ func (c *StreamingClient) WithFatalErrorHandler(fatalErrorFunc ErrorFunc) *Strea
 	return c
 }
 
-// isReady triggers various notifications that the client is ready to serve data:
-func (c *StreamingClient) isReady() {
+// setConnected records whether or not we're connected to the server (and when we last heard from it):
+func (c *StreamingClient) setConnected(connected bool) {
+	c.statusMutex.Lock()
+	defer c.statusMutex.Unlock()
+
+	// Hearing from the server means that we're no longer stale:
+	if connected && c.stale {
+		c.stale = false
+		go c.triggerStaleListeners(false)
+	}
+
+	// Trace changes to the connection state:
+	if connected && !c.connected {
+		c.config.getTracer().Connection(models.ConnectionEventConnected, nil)
+	}
+
+	c.connected = connected
+	if connected {
+		c.connectionErrors = 0
+		c.lastEventAt = time.Now()
+	}
+}
+
+// setLastEventID records the ID of the last event we received (if it had one), so that a new connection can resume from there, returning the one it replaced:
+func (c *StreamingClient) setLastEventID(lastEventID string) string {
+	c.statusMutex.Lock()
+	defer c.statusMutex.Unlock()
+
+	previousEventID := c.lastEventID
+	if lastEventID != "" {
+		c.lastEventID = lastEventID
+	}
+	return previousEventID
+}
+
+// subscribe prepares an API client which streams features from the given server address (resuming from the last event we received, if we know it):
+func (c *StreamingClient) subscribe(serverAddress string) (*eventsource.Stream, error) {
 
-	// If we're not already flagged as ready:
-	if !c.hasData {
+	// Report that we're starting:
+	c.logger.WithField("server_address", serverAddress).Info("Subscribing to FeatureHub server")
 
-		// Flag us as ready:
-		c.hasData = true
+	// Prepare a custom HTTP request:
+	req, err := c.config.newRequest(c.config.featuresURLFor(serverAddress))
+	if err != nil {
+		c.logger.WithError(err).Error("Error preparing request")
+		return nil, err
+	}
 
-		// Trigger the registered readinessListener:
-		if c.readinessListener != nil {
-			c.logger.Trace("Calling readinessListener()")
-			c.readinessListener()
+	// Prepare an API client (which we can cancel, to stop it streaming before we close it):
+	c.config.getTracer().Connection(models.ConnectionEventConnecting, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	c.statusMutex.Lock()
+	lastEventID := c.lastEventID
+	c.statusMutex.Unlock()
+	apiClient, err := eventsource.SubscribeWith(lastEventID, c.newHTTPClient(), req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		c.logger.WithError(err).WithField("server_address", serverAddress).Error("Error subscribing to server")
+		c.config.getTracer().Connection(models.ConnectionEventFailed, err)
+		return nil, err
+	}
+
+	c.apiClientHandle(apiClient).cancel = cancel
+
+	return apiClient, nil
+}
+
+// newHTTPClient makes an HTTP client for talking to the FeatureHub server (a copy of the shared one if we have it, otherwise from the config):
+func (c *StreamingClient) newHTTPClient() *http.Client {
+	if c.sharedHTTPClient != nil {
+		httpClient := *c.sharedHTTPClient
+		return &httpClient
+	}
+	return c.config.newHTTPClient()
+}
+
+// apiClientHandle returns the handle for an API client (making one if we don't have it yet):
+func (c *StreamingClient) apiClientHandle(apiClient *eventsource.Stream) *apiClientHandle {
+	c.statusMutex.Lock()
+	defer c.statusMutex.Unlock()
+
+	if c.apiClientHandles == nil {
+		c.apiClientHandles = make(map[*eventsource.Stream]*apiClientHandle)
+	}
+	handle, ok := c.apiClientHandles[apiClient]
+	if !ok {
+		handle = &apiClientHandle{stop: make(chan struct{})}
+		c.apiClientHandles[apiClient] = handle
+	}
+	return handle
+}
+
+// closeAPIClient stops handling an API client, then closes it in the background:
+// - the SSE library panics if it is closed while sending to us, so our handlers stop reading from it first
+// - then its request is cancelled (after which it only reports the error and goes back to sleep)
+// - then we take whatever it sends until it has gone quiet, and only then close it
+func (c *StreamingClient) closeAPIClient(apiClient *eventsource.Stream) {
+	c.statusMutex.Lock()
+	handle, ok := c.apiClientHandles[apiClient]
+	delete(c.apiClientHandles, apiClient)
+	c.statusMutex.Unlock()
+	if !ok {
+		go drainAndCloseAPIClient(apiClient)
+		return
+	}
+
+	close(handle.stop)
+	go func() {
+		handle.handlers.Wait()
+		if handle.cancel != nil {
+			handle.cancel()
+		}
+		drainAndCloseAPIClient(apiClient)
+	}()
+}
+
+// drainAndCloseAPIClient discards events and errors from an API client until it has gone quiet, then closes it:
+func drainAndCloseAPIClient(apiClient *eventsource.Stream) {
+	for {
+		select {
+		case _, ok := <-apiClient.Events:
+			if !ok {
+				return
+			}
+		case _, ok := <-apiClient.Errors:
+			if !ok {
+				return
+			}
+		case <-time.After(apiClientQuietPeriod):
+			apiClient.Close()
+			return
+		}
+	}
+}
+
+// currentAPIClient returns the API client we're currently streaming from (this changes when we fail over):
+func (c *StreamingClient) currentAPIClient() *eventsource.Stream {
+	c.statusMutex.Lock()
+	defer c.statusMutex.Unlock()
+	return c.apiClient
+}
+
+// recordConnectionError counts connection errors from an API client, telling us when there have been enough in a row to fail over:
+func (c *StreamingClient) recordConnectionError(apiClient *eventsource.Stream) bool {
+	c.statusMutex.Lock()
+	defer c.statusMutex.Unlock()
+
+	// Errors from an API client we've already failed over from don't count:
+	if apiClient != c.apiClient {
+		return false
+	}
+
+	c.connectionErrors++
+	return c.connectionErrors >= c.config.failoverAfterErrors() && len(c.config.FallbackServerAddresses) > 0
+}
+
+// failover moves us from a failing API client to the next server address which works (where we stay until it fails too):
+func (c *StreamingClient) failover(failedAPIClient *eventsource.Stream) {
+	if !c.resubscribe(failedAPIClient, 1) {
+
+		// The failing API client will keep retrying by itself:
+		c.logger.Warn("Unable to fail over to another FeatureHub server")
+		c.statusMutex.Lock()
+		c.connectionErrors = 0
+		c.statusMutex.Unlock()
+	}
+}
+
+// reconnect replaces an API client which has stopped hearing from the server (trying the same server address first):
+func (c *StreamingClient) reconnect(staleAPIClient *eventsource.Stream) {
+	if !c.resubscribe(staleAPIClient, 0) {
+		c.logger.Warn("Unable to reconnect to a FeatureHub server")
+	}
+}
+
+// resubscribe replaces an API client with a new one, trying each server address in turn (starting at an offset from the current one):
+func (c *StreamingClient) resubscribe(oldAPIClient *eventsource.Stream, firstOffset int) bool {
+	serverAddresses := c.config.serverAddresses()
+
+	// Find where we are in the list:
+	c.statusMutex.Lock()
+	currentIndex := 0
+	for index, serverAddress := range serverAddresses {
+		if serverAddress == c.serverAddress {
+			currentIndex = index
+		}
+	}
+	c.statusMutex.Unlock()
+
+	// Try each server address in turn:
+	for offset := firstOffset; offset < len(serverAddresses); offset++ {
+		serverAddress := serverAddresses[(currentIndex+offset)%len(serverAddresses)]
+		apiClient, err := c.subscribe(serverAddress)
+		if err != nil {
+			continue
+		}
+
+		// Switch over to the new API client (unless somebody else already has):
+		c.statusMutex.Lock()
+		if c.apiClient != oldAPIClient {
+			c.statusMutex.Unlock()
+			c.closeAPIClient(apiClient)
+			return true
+		}
+		c.apiClient = apiClient
+		c.connectionErrors = 0
+		c.lastEventAt = time.Now()
+		c.serverAddress = serverAddress
+		c.statusMutex.Unlock()
+		c.closeAPIClient(oldAPIClient)
+		if offset == 0 {
+			c.logger.WithField("server_address", serverAddress).Info("Reconnected to FeatureHub server")
 		} else {
-			c.logger.Trace("No registered readinessListener() to call")
+			c.logger.WithField("server_address", serverAddress).Warn("Failed over to another FeatureHub server")
+		}
+
+		// We may have been closed in the meantime:
+		if !c.isRunning.Load() {
+			c.closeAPIClient(apiClient)
+			return true
 		}
+
+		// Handle events from the new API client:
+		c.handleStream(apiClient)
+		return true
 	}
+
+	return false
 }
@@ -1,49 +1,199 @@
 package streamingclient
 
 import (
-	"reflect"
+	"context"
+	"time"
 
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/analytics"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/experiments"
 	"github.com/featurehub-io/featurehub-go-sdk/pkg/interfaces"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
 )
 
+// analyticsCloseTimeout is how long Close waits for queued analytics events to be submitted:
+const analyticsCloseTimeout = 5 * time.Second
+
 // AddAnalyticsCollector configures the client with a new analytics collector:
 func (c *StreamingClient) AddAnalyticsCollector(newAnalyticsCollector interfaces.AnalyticsCollector) {
 	c.analyticsMutex.Lock()
 	defer c.analyticsMutex.Unlock()
 
 	c.analyticsCollectors = append(c.analyticsCollectors, newAnalyticsCollector)
+	c.pipeline().AddCollector(newAnalyticsCollector)
 }
 
-// LogAnalyticsEvent submits analytics events using the client's configured AnalyticsCollector (as a background GoRoutine):
-func (c *StreamingClient) LogAnalyticsEvent(action string, other map[string]string) {
+// AddImpressionCollector configures the client with a new impression collector (which hears about features being evaluated for a context):
+func (c *StreamingClient) AddImpressionCollector(newImpressionCollector interfaces.ImpressionCollector) {
 	c.analyticsMutex.Lock()
 	defer c.analyticsMutex.Unlock()
 
-	// Make a copy of our list of features (in case it changes underneath us):
-	copyOfFeatures := c.features
+	if c.impressionFilter == nil {
+		c.impressionFilter = analytics.NewImpressionFilter(c.config.impressions)
+	}
+	c.pipeline().AddImpressionCollector(newImpressionCollector)
+	c.logImpressions.Store(true)
+}
+
+// AnalyticsCollectorTypes returns the type of each configured analytics collector:
+func (c *StreamingClient) AnalyticsCollectorTypes() []string {
+	c.analyticsMutex.Lock()
+	defer c.analyticsMutex.Unlock()
 
-	// Submit events for each collector:
+	analyticsCollectorTypes := make([]string, 0, len(c.analyticsCollectors))
 	for _, analyticsCollector := range c.analyticsCollectors {
-		c.logger.WithField("analytics_collector", reflect.TypeOf(analyticsCollector)).Debug("Submitting analytics event")
-		go analyticsCollector.LogEvent(action, other, copyOfFeatures)
+		analyticsCollectorTypes = append(analyticsCollectorTypes, analytics.CollectorType(analyticsCollector))
 	}
+
+	return analyticsCollectorTypes
 }
 
-// LogAnalyticsEventSync submits analytics events using the client's configured AnalyticsCollector (blocking until it is complete):
-func (c *StreamingClient) LogAnalyticsEventSync(action string, other map[string]string) error {
+// AnalyticsStats returns what the analytics pipeline has done with events for each configured analytics collector:
+func (c *StreamingClient) AnalyticsStats() []analytics.CollectorStats {
 	c.analyticsMutex.Lock()
 	defer c.analyticsMutex.Unlock()
 
-	// Make a copy of our list of features (in case it changes underneath us):
-	copyOfFeatures := c.features
+	return c.pipeline().Stats()
+}
 
-	// Submit events for each collector:
-	for _, analyticsCollector := range c.analyticsCollectors {
-		c.logger.WithField("analytics_collector", reflect.TypeOf(analyticsCollector)).Debug("Submitting analytics event")
-		if err := analyticsCollector.LogEvent(action, other, copyOfFeatures); err != nil {
-			return err
+// closeAnalytics flushes the analytics pipeline (for up to analyticsCloseTimeout), then stops its workers:
+func (c *StreamingClient) closeAnalytics() {
+	c.analyticsMutex.Lock()
+	pipeline := c.analyticsPipeline
+	c.analyticsMutex.Unlock()
+
+	// Nothing to do if nobody ever logged anything:
+	if pipeline == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), analyticsCloseTimeout)
+	defer cancel()
+	if err := pipeline.Close(ctx); err != nil {
+		c.logger.WithError(err).Warn("Unable to submit every analytics event before closing")
+	}
+}
+
+// FlushAnalytics blocks until every analytics event logged so far has been submitted (or the context is done), eg on shutdown:
+func (c *StreamingClient) FlushAnalytics(ctx context.Context) error {
+	c.analyticsMutex.Lock()
+	pipeline := c.pipeline()
+	c.analyticsMutex.Unlock()
+
+	return pipeline.Flush(ctx)
+}
+
+// LogAnalyticsEvent queues analytics events for the client's configured AnalyticsCollectors (they are submitted in the background):
+func (c *StreamingClient) LogAnalyticsEvent(action string, other map[string]string) {
+	c.logAnalyticsEvent(nil, action, other)
+}
+
+// LogAnalyticsEventSync submits analytics events using the client's configured AnalyticsCollectors (blocking until they are complete):
+// - every collector gets the event, even if some of them fail (in which case an ErrAnalyticsCollectors is returned)
+func (c *StreamingClient) LogAnalyticsEventSync(action string, other map[string]string) error {
+	return c.logAnalyticsEventSync(nil, action, other)
+}
+
+// logAnalyticsEvent queues an analytics event (for a context, if there is one):
+func (c *StreamingClient) logAnalyticsEvent(context *models.Context, action string, other map[string]string) {
+	c.analyticsMutex.Lock()
+	pipeline := c.pipeline()
+	collectorCount := len(c.analyticsCollectors)
+	c.analyticsMutex.Unlock()
+
+	// Don't bother taking a snapshot of the features if nobody is going to see it:
+	if collectorCount == 0 {
+		return
+	}
+
+	c.logger.WithField("analytics_collectors", collectorCount).Debug("Submitting analytics event")
+	pipeline.LogEvent(c.newAnalyticsEvent(context, action, other))
+}
+
+// logAnalyticsEventSync submits an analytics event (for a context, if there is one), waiting for it to complete:
+func (c *StreamingClient) logAnalyticsEventSync(context *models.Context, action string, other map[string]string) error {
+	c.analyticsMutex.Lock()
+	pipeline := c.pipeline()
+	collectorCount := len(c.analyticsCollectors)
+	c.analyticsMutex.Unlock()
+
+	if collectorCount == 0 {
+		return nil
+	}
+
+	c.logger.WithField("analytics_collectors", collectorCount).Debug("Submitting analytics event")
+	err := pipeline.LogEventSync(c.newAnalyticsEvent(context, action, other))
+	if err != nil {
+		c.logger.WithError(err).Debug("Error submitting analytics event")
+	}
+	return err
+}
+
+// logImpression queues an impression of an evaluated feature for the configured impression collectors (if there are any):
+func (c *StreamingClient) logImpression(context *models.Context, evaluatedFeature *models.EvaluatedFeature) {
+	if !c.logImpressions.Load() {
+		return
+	}
+
+	impression := &models.Impression{
+		Key:        evaluatedFeature.Key,
+		StrategyID: evaluatedFeature.StrategyID,
+		Timestamp:  time.Now(),
+		Value:      evaluatedFeature.Value,
+		Version:    evaluatedFeature.Version,
+	}
+	if context != nil {
+		impression.Session = context.Session
+		impression.Userkey = context.Userkey
+	}
+
+	c.analyticsMutex.Lock()
+	impressionFilter, pipeline := c.impressionFilter, c.pipeline()
+	c.analyticsMutex.Unlock()
+
+	if impressionFilter.Allow(impression) {
+		pipeline.LogImpression(impression)
+	}
+}
+
+// newAnalyticsEvent prepares an analytics event (with a snapshot of our features, in case they change underneath us):
+func (c *StreamingClient) newAnalyticsEvent(context *models.Context, action string, other map[string]string) *models.AnalyticsEvent {
+	event := &models.AnalyticsEvent{
+		Action:    action,
+		Features:  c.Features(),
+		Other:     other,
+		Timestamp: time.Now(),
+	}
+
+	// Evaluate the features for the context (taking a copy of it too, in case it changes before the event is submitted):
+	if context != nil {
+		contextCopy := *context
+		event.Context = &contextCopy
+		event.Evaluated = make(models.EvaluatedFeatures, len(event.Features))
+		for key, featureState := range event.Features {
+			event.Evaluated[key] = c.config.evaluateReadOnly(featureState, event.Context, c.logger)
 		}
 	}
 
-	return nil
+	return event
+}
+
+// experimentAssigner returns the experiment assigner, making it the first time we need one (so exposures are only logged once per client):
+func (c *StreamingClient) experimentAssigner() *experiments.Assigner {
+	c.analyticsMutex.Lock()
+	defer c.analyticsMutex.Unlock()
+
+	if c.experiments == nil {
+		c.experiments = experiments.NewAssigner(c.config.getExperiments())
+	}
+	return c.experiments
+}
+
+// pipeline returns the analytics pipeline, making it the first time we need one (analyticsMutex must be held):
+func (c *StreamingClient) pipeline() *analytics.Pipeline {
+	if c.analyticsPipeline == nil {
+		c.analyticsPipeline = analytics.NewPipeline(c.config.analyticsPipeline).
+			WithLogger(c.logger).
+			WithMetrics(c.config.getMetrics())
+	}
+	return c.analyticsPipeline
 }
@@ -2,12 +2,14 @@ package streamingclient
 
 import (
 	"bytes"
+	"context"
 	"testing"
 	"time"
 
 	"github.com/donovanhide/eventsource"
 	"github.com/featurehub-io/featurehub-go-sdk/pkg/analytics"
 	"github.com/featurehub-io/featurehub-go-sdk/pkg/interfaces"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/logging"
 	"github.com/featurehub-io/featurehub-go-sdk/pkg/mocks"
 	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
 	"github.com/sirupsen/logrus"
@@ -35,7 +37,7 @@ func TestStreamingClientAnalytics(t *testing.T) {
 		},
 		config:   config,
 		features: make(map[string]*models.FeatureState),
-		logger:   logger,
+		logger:   logging.NewLogrusLogger(logger),
 	}
 
 	// Configure a new analytics collector:
@@ -89,11 +91,18 @@ func TestStreamingClientAnalytics(t *testing.T) {
 
 	// Log another event (using the asynchronous method):
 	client.LogAnalyticsEvent("more-testing1", testAttributes)
-	time.Sleep(500 * time.Millisecond)
+	assert.NoError(t, client.FlushAnalytics(context.Background()))
 
 	// Make sure our AnalyticsCollector was called twice (because we registered it twice):
 	assert.Equal(t, 2, fakeAnalyticsCollector.LogEventCallCount())
 
+	// The pipeline kept count (of both events, for all 4 collectors):
+	analyticsStats := client.AnalyticsStats()
+	assert.Len(t, analyticsStats, 4)
+	assert.Equal(t, "*analytics.LoggingAnalyticsCollector", analyticsStats[0].Collector)
+	assert.Equal(t, uint64(2), analyticsStats[0].Sent)
+	assert.Equal(t, uint64(1), analyticsStats[3].Sent)
+
 	// Log another asynchronous event, and prove that we're not blocking:
 	fakeAnalyticsCollector.LogEventCalls(logEventWithDelay)
 	timeBefore := time.Now()
@@ -102,6 +111,13 @@ func TestStreamingClientAnalytics(t *testing.T) {
 
 	// Make sure we log something:
 	assert.Contains(t, logBuffer.String(), "Submitting analytics event")
+
+	// Closing the client submits the queued event (and stops the pipeline, so later events are ignored):
+	client.Close()
+	assert.Equal(t, 4, fakeAnalyticsCollector.LogEventCallCount())
+	client.LogAnalyticsEvent("after-closing", testAttributes)
+	assert.NoError(t, client.FlushAnalytics(context.Background()))
+	assert.Equal(t, 4, fakeAnalyticsCollector.LogEventCallCount())
 }
 
 func logEventWithDelay(string, map[string]string, map[string]*models.FeatureState) error {
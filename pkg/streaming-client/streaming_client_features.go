@@ -1,6 +1,8 @@
 package streamingclient
 
 import (
+	"sort"
+
 	"github.com/featurehub-io/featurehub-go-sdk/pkg/errors"
 	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
 )
@@ -13,13 +15,40 @@ func (c *StreamingClient) GetFeature(key string) (*models.FeatureState, error) {
 	// Look for the feature:
 	if feature, ok := c.features[key]; ok {
 		c.logger.WithField("key", key).Trace("Found feature")
-		return feature, nil
+		return c.resolveFeature(feature), nil
 	}
 
 	c.logger.WithField("key", key).Trace("Feature not found")
 	return nil, errors.NewErrFeatureNotFound(key)
 }
 
+// Features returns a snapshot of all of the features we currently have (by key), which can be changed without affecting the client:
+func (c *StreamingClient) Features() map[string]*models.FeatureState {
+	c.featuresMutex.Lock()
+	defer c.featuresMutex.Unlock()
+
+	features := make(map[string]*models.FeatureState, len(c.features))
+	for key, feature := range c.features {
+		features[key] = c.resolveFeature(feature).Copy()
+	}
+
+	return features
+}
+
+// Keys returns the keys of all the features we currently have (sorted):
+func (c *StreamingClient) Keys() []string {
+	c.featuresMutex.Lock()
+	defer c.featuresMutex.Unlock()
+
+	keys := make([]string, 0, len(c.features))
+	for key := range c.features {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
 // GetBoolean searches for a feature by key, returns the value as a boolean:
 func (c *StreamingClient) GetBoolean(key string) (bool, error) {
 
@@ -67,3 +96,16 @@ func (c *StreamingClient) GetString(key string) (string, error) {
 
 	return feature.AsString()
 }
+
+// resolveFeature returns a copy of the feature with any shared strategy references resolved (the caller must hold the featuresMutex):
+func (c *StreamingClient) resolveFeature(feature *models.FeatureState) *models.FeatureState {
+
+	// Most features won't reference shared strategies, so we don't need to copy them:
+	if !feature.Strategies.ReferencesSharedStrategies() {
+		return feature
+	}
+
+	resolvedFeature := *feature
+	resolvedFeature.Strategies = feature.Strategies.ResolveWithLogger(c.sharedStrategies, c.logger)
+	return &resolvedFeature
+}
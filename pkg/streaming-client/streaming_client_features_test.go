@@ -2,10 +2,13 @@ package streamingclient
 
 import (
 	"bytes"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/donovanhide/eventsource"
 	"github.com/featurehub-io/featurehub-go-sdk/pkg/errors"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/logging"
 	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
@@ -32,7 +35,7 @@ func TestStreamingClientFeatures(t *testing.T) {
 		},
 		config:   config,
 		features: make(map[string]*models.FeatureState),
-		logger:   logger,
+		logger:   logging.NewLogrusLogger(logger),
 	}
 
 	// Load the mock apiClient up with a "features" event:
@@ -93,4 +96,33 @@ func TestStreamingClientFeatures(t *testing.T) {
 	stringFeature, err = client.GetString("stringfeature")
 	assert.NoError(t, err)
 	assert.Equal(t, "this is a string", stringFeature)
+
+	// List the keys (sorted):
+	assert.Equal(t, []string{"booleanfeature", "jsonfeature", "numberfeature", "stringfeature"}, client.Keys())
+
+	// Take a snapshot of the features, and make sure that it doesn't change underneath us:
+	features := client.Features()
+	assert.Len(t, features, 4)
+	delete(features, "stringfeature")
+	assert.Len(t, client.Features(), 4)
+
+	// Changing the features in a snapshot doesn't change the client's features either:
+	features = client.Features()
+	features["numberfeature"].Value = float64(1)
+	features["numberfeature"].Strategies = append(features["numberfeature"].Strategies, models.Strategy{ID: "s1", Value: float64(2)})
+	numberFeature, err = client.GetNumber("numberfeature")
+	assert.NoError(t, err)
+	assert.Equal(t, float64(123456789), numberFeature)
+	assert.Empty(t, client.Features()["numberfeature"].Strategies)
+
+	// Check the status:
+	status := client.Status()
+	assert.True(t, status.Connected)
+	assert.True(t, status.HasData)
+	assert.Equal(t, 4, status.FeatureCount)
+	assert.WithinDuration(t, time.Now(), status.LastEventAt, time.Minute)
+
+	// An error from the API client means that we've lost our connection:
+	client.apiClient.Errors <- fmt.Errorf("connection reset by peer")
+	assert.Eventually(t, func() bool { return !client.Status().Connected }, time.Second, 10*time.Millisecond)
 }
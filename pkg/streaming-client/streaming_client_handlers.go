@@ -2,42 +2,81 @@ package streamingclient
 
 import (
 	"encoding/json"
+	"strconv"
 
 	"github.com/donovanhide/eventsource"
 	"github.com/featurehub-io/featurehub-go-sdk/pkg/errors"
 	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
 )
 
-// handleErrors deals with incoming server-side errors:
-func (c *StreamingClient) handleErrors() {
+// handleStream handles events and errors from one API client (in the background):
+func (c *StreamingClient) handleStream(apiClient *eventsource.Stream) {
+	handle := c.apiClientHandle(apiClient)
+	handle.handlers.Add(2)
+	c.streamHandlers.Add(2)
+	go c.handleEvents(apiClient, handle)
+	go c.handleErrors(apiClient, handle)
+}
+
+// handleErrors deals with incoming server-side errors (from one API client):
+func (c *StreamingClient) handleErrors(apiClient *eventsource.Stream, handle *apiClientHandle) {
+	defer handle.handlers.Done()
+	defer c.streamHandlers.Add(-1)
 
 	// Run forever (blocks on receiving events from the client channel):
 	for {
-		event := <-c.apiClient.Errors
+		var event error
+		ok := false
+		select {
+		case event, ok = <-apiClient.Errors:
+		case <-handle.stop:
+		}
 
-		// We may have been shut down by some external process:
-		if !c.isRunning {
+		// We may have been shut down by some external process (or failed over to another server):
+		if !ok || !c.isRunning.Load() {
 			c.logger.Info("No longer handling SSE errors")
 			break
 		}
 
+		// Any error means that the connection has been interrupted (the API client will reconnect by itself):
+		c.setConnected(false)
+		c.config.getMetrics().Reconnect()
+		c.config.getTracer().Connection(models.ConnectionEventDisconnected, event)
+
 		c.logger.WithError(event).Trace("Error from API client")
+
+		// Fail over to another server if this one keeps failing:
+		if c.recordConnectionError(apiClient) {
+			c.failover(apiClient)
+		}
 	}
 }
 
-// handleEvents deals with incoming server-side events:
-func (c *StreamingClient) handleEvents() {
+// handleEvents deals with incoming server-side events (from one API client):
+func (c *StreamingClient) handleEvents(apiClient *eventsource.Stream, handle *apiClientHandle) {
+	defer handle.handlers.Done()
+	defer c.streamHandlers.Add(-1)
 
 	// Run forever (blocks on receiving events from the client channel):
 	for {
-		event := <-c.apiClient.Events
+		var event eventsource.Event
+		ok := false
+		select {
+		case event, ok = <-apiClient.Events:
+		case <-handle.stop:
+		}
 
-		// We may have been shut down by some external process:
-		if !c.isRunning {
+		// We may have been shut down by some external process (or failed over to another server):
+		if !ok || !c.isRunning.Load() {
 			c.logger.Info("No longer handling SSE events")
 			break
 		}
 
+		// Receiving an event means that we're connected (and gives us somewhere to resume from):
+		c.setConnected(true)
+		previousEventID := c.setLastEventID(event.Id())
+		c.config.getMetrics().SSEEvent(event.Event())
+
 		// Handle the different types of events that can be received on this channel:
 		switch models.Event(event.Event()) {
 
@@ -63,7 +102,7 @@ func (c *StreamingClient) handleEvents() {
 				"event":   event.Event(),
 				"message": event.Data(),
 			}
-			c.fatalErrorHandler(&errors.ErrFromAPI{}, "Failure from FeatureHub server", details)
+			c.fail(&errors.ErrFromAPI{}, "Failure from FeatureHub server", details)
 
 		// One specific feature (replaces the previous version):
 		case models.FHFeature:
@@ -71,7 +110,19 @@ func (c *StreamingClient) handleEvents() {
 
 		// An entire feature set (replaces what we currently have):
 		case models.FHFeatures:
-			c.handleFHFeatures(event)
+			c.handleFHFeatures(event, previousEventID)
+
+		// Delete a shared strategy from our list:
+		case models.FHDeleteStrategy:
+			c.handleFHDeleteStrategy(event)
+
+		// One specific shared strategy (replaces the previous version):
+		case models.FHStrategy:
+			c.handleFHStrategy(event)
+
+		// An entire set of shared strategies (replaces what we currently have):
+		case models.FHStrategies:
+			c.handleFHStrategies(event)
 
 		// Everything else just gets logged:
 		default:
@@ -82,15 +133,15 @@ func (c *StreamingClient) handleEvents() {
 
 func (c *StreamingClient) handleSSEError(event eventsource.Event) {
 	// If we're already running then just log an error, otherwise panic:
-	if c.hasData {
+	if c.Status().HasData {
 		c.logger.WithError(&errors.ErrFromAPI{}).WithField("event", event.Event()).WithField("message", event.Data()).Error("Error from API client")
 	} else {
-		// Use the fatailErrorFunc for this one:
+		// Use the fatal error handler for this one:
 		details := map[string]interface{}{
 			"event":   event.Event(),
 			"message": event.Data(),
 		}
-		c.fatalErrorFunc(&errors.ErrFromAPI{}, "Error from API client", details)
+		c.fail(&errors.ErrFromAPI{}, "Error from API client", details)
 	}
 }
 
@@ -100,15 +151,15 @@ func (c *StreamingClient) handleFHConfigEvent(event eventsource.Event) {
 	configEvent := new(models.ConfigEvent)
 	if err := json.Unmarshal([]byte(event.Data()), configEvent); err != nil {
 		c.logger.WithError(err).WithField("event", "config").Error("Error unmarshaling SSE payload")
+		c.config.getMetrics().ParseError(event.Event())
 	}
 
 	// Handle "edge.stale" config:
 	if configEvent.EdgeStale {
 
-		// Close the SSE client connection:
+		// Close the SSE client connection (analytics carry on until the client is closed):
 		c.logger.Warn("The FeatureHub server has requested that we close our connection (edge.stale)! No further updates will be received - existing data will continue to be served")
-		c.isRunning = false
-		c.apiClient.Close()
+		c.closeStream()
 	}
 }
 
@@ -117,15 +168,33 @@ func (c *StreamingClient) handleFHDeleteFeature(event eventsource.Event) {
 	// Unmarshal the event payload:
 	feature := &models.FeatureState{}
 	if err := json.Unmarshal([]byte(event.Data()), feature); err != nil {
-		c.logger.WithError(err).WithField("event", "feature").Error("Error unmarshaling SSE payload")
+		c.logger.WithError(err).WithField("event", "delete_feature").Error("Error unmarshaling SSE payload")
+		c.config.getMetrics().ParseError(event.Event())
+		return
 	}
 
-	// Delete the feature:
+	// Ignore deletions of anything newer than the version which was deleted:
 	c.featuresMutex.Lock()
-	defer c.featuresMutex.Unlock()
+	currentFeature, ok := c.features[feature.Key]
+	if ok && isSameFeature(feature, currentFeature) && feature.Version != 0 && feature.Version < currentFeature.Version {
+		c.featuresMutex.Unlock()
+		c.logger.WithField("key", feature.Key).Debug("Received an old deletion from server")
+		return
+	}
+
+	// Delete the feature (remembering which version was deleted):
 	delete(c.features, feature.Key)
+	if ok && currentFeature.Version > feature.Version {
+		feature.Version = currentFeature.Version
+	}
+	c.rememberDeletedFeature(feature)
+	c.featuresMutex.Unlock()
 
+	// Only notify about features we actually had:
 	c.logger.WithField("key", feature.Key).Debug("Deleted a feature")
+	if ok {
+		c.notifyDeleted(feature)
+	}
 }
 
 func (c *StreamingClient) handleFHFeature(event eventsource.Event) {
@@ -134,55 +203,239 @@ func (c *StreamingClient) handleFHFeature(event eventsource.Event) {
 	feature := &models.FeatureState{}
 	if err := json.Unmarshal([]byte(event.Data()), feature); err != nil {
 		c.logger.WithError(err).WithField("event", "feature").Error("Error unmarshaling SSE payload")
+		c.config.getMetrics().ParseError(event.Event())
+		return
 	}
 
-	// Take the new feature (or ignore if the version is not newer):
+	// Take the new feature (or ignore if the version is not newer, or it is an old version of something which has been deleted):
 	c.featuresMutex.Lock()
 	defer c.featuresMutex.Unlock()
 	if currentFeature, ok := c.features[feature.Key]; ok {
-		if feature.Version <= currentFeature.Version {
+		if isSameFeature(feature, currentFeature) && feature.Version <= currentFeature.Version {
 			c.logger.WithField("key", feature.Key).Debug("Received an old feature from server")
 			return
 		}
 	}
+	if c.isDeletedFeature(feature) {
+		c.logger.WithField("key", feature.Key).Debug("Received an old version of a deleted feature from server")
+		return
+	}
 
 	// Otherwise this is a new feature, so we just take it:
 	c.logger.WithField("key", feature.Key).Debug("Received a new feature from server")
 	c.features[feature.Key] = feature
-	c.notify(feature)
+	delete(c.deletedFeatures, feature.Key)
+	c.notify(c.resolveFeature(feature))
 	c.isReady()
 }
 
-func (c *StreamingClient) handleFHFeatures(event eventsource.Event) {
+func (c *StreamingClient) handleFHFeatures(event eventsource.Event, previousEventID string) {
 
-	// Unmarshal the event payload:
+	// Unmarshal the event payload (a broken payload mustn't wipe out the features we already have):
 	features := []*models.FeatureState{}
 	if err := json.Unmarshal([]byte(event.Data()), &features); err != nil {
 		c.logger.WithError(err).WithField("event", "features").Error("Error unmarshaling SSE payload")
+		c.config.getMetrics().ParseError(event.Event())
+		return
 	}
 
-	// Create a new map of features:
+	// Reconcile the new features with what we already have:
+	// - anything we already have a newer version of is kept (eg if we've reconnected to a server which is lagging behind)
+	// - old versions of features which have since been deleted are ignored
+	// - features which are missing are only deleted if the snapshot is at least as new as what we have
+	c.featuresMutex.Lock()
+	oldFeatures := c.features
 	newFeatures := make(map[string]*models.FeatureState)
+	lagging := isOlderEventID(event.Id(), previousEventID)
 	for _, newFeature := range features {
+		if oldFeature, ok := oldFeatures[newFeature.Key]; ok && isSameFeature(newFeature, oldFeature) && newFeature.Version < oldFeature.Version {
+			c.logger.WithField("key", newFeature.Key).Debug("Received an old feature from server")
+			newFeatures[newFeature.Key] = oldFeature
+			lagging = true
+			continue
+		}
+		if c.isDeletedFeature(newFeature) {
+			c.logger.WithField("key", newFeature.Key).Debug("Received an old version of a deleted feature from server")
+			continue
+		}
 		newFeatures[newFeature.Key] = newFeature
+		delete(c.deletedFeatures, newFeature.Key)
 	}
 
-	// Take the new features:
-	c.featuresMutex.Lock()
-	oldFeatures := c.features
+	// A lagging snapshot may just not have heard about features yet, so we keep the ones it is missing:
+	if lagging {
+		for key, oldFeature := range oldFeatures {
+			if _, ok := newFeatures[key]; !ok {
+				c.logger.WithField("key", key).Debug("Keeping a feature which is missing from an old snapshot")
+				newFeatures[key] = oldFeature
+			}
+		}
+	}
 	c.features = newFeatures
 	c.isReady()
-	c.featuresMutex.Unlock()
 
-	// Compare versions to see who should be notified:
+	// Only features which are new (or newer) need to be notified:
+	var featuresToNotify []*models.FeatureState
 	for _, newFeature := range newFeatures {
 		if oldFeature, ok := oldFeatures[newFeature.Key]; ok {
-			if newFeature.Version <= oldFeature.Version {
+			if isSameFeature(newFeature, oldFeature) && newFeature.Version <= oldFeature.Version {
 				continue
 			}
 		}
-		c.notify(newFeature)
+		featuresToNotify = append(featuresToNotify, c.resolveFeature(newFeature))
+	}
+
+	// Anything which has gone away has been deleted:
+	var deletedFeatures []*models.FeatureState
+	for key, oldFeature := range oldFeatures {
+		if _, ok := newFeatures[key]; !ok {
+			deletedFeatures = append(deletedFeatures, oldFeature)
+			c.rememberDeletedFeature(oldFeature)
+		}
+	}
+	c.featuresMutex.Unlock()
+
+	// Notify outside of the lock:
+	for _, featureToNotify := range featuresToNotify {
+		c.notify(featureToNotify)
+	}
+	for _, deletedFeature := range deletedFeatures {
+		c.notifyDeleted(deletedFeature)
 	}
 
 	c.logger.Debugf("Received %d features from server", len(features))
 }
+
+func (c *StreamingClient) handleFHDeleteStrategy(event eventsource.Event) {
+
+	// Unmarshal the event payload (a broken payload mustn't delete anything):
+	sharedStrategy := &models.SharedStrategy{}
+	if err := json.Unmarshal([]byte(event.Data()), sharedStrategy); err != nil {
+		c.logger.WithError(err).WithField("event", "delete_strategy").Error("Error unmarshaling SSE payload")
+		c.config.getMetrics().ParseError(event.Event())
+		return
+	}
+
+	// Delete the shared strategy:
+	c.featuresMutex.Lock()
+	defer c.featuresMutex.Unlock()
+	delete(c.sharedStrategies, sharedStrategy.ID)
+
+	c.logger.WithField("id", sharedStrategy.ID).Debug("Deleted a shared strategy")
+
+	// Features which depend on this strategy have changed:
+	c.notifyDependentFeatures(sharedStrategy.ID)
+}
+
+func (c *StreamingClient) handleFHStrategy(event eventsource.Event) {
+
+	// Unmarshal the event payload (a broken payload mustn't be stored):
+	sharedStrategy := &models.SharedStrategy{}
+	if err := json.Unmarshal([]byte(event.Data()), sharedStrategy); err != nil {
+		c.logger.WithError(err).WithField("event", "strategy").Error("Error unmarshaling SSE payload")
+		c.config.getMetrics().ParseError(event.Event())
+		return
+	}
+
+	// Take the new shared strategy (or ignore if the version is not newer):
+	c.featuresMutex.Lock()
+	defer c.featuresMutex.Unlock()
+	if currentSharedStrategy, ok := c.sharedStrategies[sharedStrategy.ID]; ok {
+		if sharedStrategy.Version <= currentSharedStrategy.Version {
+			c.logger.WithField("id", sharedStrategy.ID).Debug("Received an old shared strategy from server")
+			return
+		}
+	}
+
+	// Otherwise this is a new shared strategy, so we just take it:
+	c.logger.WithField("id", sharedStrategy.ID).Debug("Received a new shared strategy from server")
+	if c.sharedStrategies == nil {
+		c.sharedStrategies = make(models.SharedStrategies)
+	}
+	c.sharedStrategies[sharedStrategy.ID] = sharedStrategy
+
+	// Features which depend on this strategy have changed:
+	c.notifyDependentFeatures(sharedStrategy.ID)
+}
+
+func (c *StreamingClient) handleFHStrategies(event eventsource.Event) {
+
+	// Unmarshal the event payload (a broken payload mustn't wipe out the shared strategies we already have):
+	sharedStrategies := []*models.SharedStrategy{}
+	if err := json.Unmarshal([]byte(event.Data()), &sharedStrategies); err != nil {
+		c.logger.WithError(err).WithField("event", "strategies").Error("Error unmarshaling SSE payload")
+		c.config.getMetrics().ParseError(event.Event())
+		return
+	}
+
+	// Create a new map of shared strategies:
+	newSharedStrategies := make(models.SharedStrategies)
+	for _, newSharedStrategy := range sharedStrategies {
+		newSharedStrategies[newSharedStrategy.ID] = newSharedStrategy
+	}
+
+	// Take the new shared strategies:
+	c.featuresMutex.Lock()
+	defer c.featuresMutex.Unlock()
+	oldSharedStrategies := c.sharedStrategies
+	c.sharedStrategies = newSharedStrategies
+
+	// Anything which is new, newer, or has gone away affects the features which depend on it:
+	for id, newSharedStrategy := range newSharedStrategies {
+		if oldSharedStrategy, ok := oldSharedStrategies[id]; ok {
+			if newSharedStrategy.Version <= oldSharedStrategy.Version {
+				continue
+			}
+		}
+		c.notifyDependentFeatures(id)
+	}
+	for id := range oldSharedStrategies {
+		if _, ok := newSharedStrategies[id]; !ok {
+			c.notifyDependentFeatures(id)
+		}
+	}
+
+	c.logger.Debugf("Received %d shared strategies from server", len(sharedStrategies))
+}
+
+// notifyDependentFeatures triggers notifiers for every feature which references the given shared strategy (the caller must hold the featuresMutex):
+func (c *StreamingClient) notifyDependentFeatures(sharedStrategyID string) {
+	for _, feature := range c.features {
+		if feature.Strategies.ReferencesSharedStrategy(sharedStrategyID) {
+			c.logger.WithField("key", feature.Key).WithField("id", sharedStrategyID).Debug("Shared strategy changed for feature")
+			c.notify(c.resolveFeature(feature))
+		}
+	}
+}
+
+// isDeletedFeature tells us whether a feature is an old version of one which has since been deleted (featuresMutex must be held):
+func (c *StreamingClient) isDeletedFeature(feature *models.FeatureState) bool {
+	deletedFeature, ok := c.deletedFeatures[feature.Key]
+	return ok && deletedFeature.Version > 0 && isSameFeature(feature, deletedFeature) && feature.Version <= deletedFeature.Version
+}
+
+// rememberDeletedFeature remembers which version of a feature was deleted, so that older versions can't bring it back (featuresMutex must be held):
+func (c *StreamingClient) rememberDeletedFeature(feature *models.FeatureState) {
+	if c.deletedFeatures == nil {
+		c.deletedFeatures = make(map[string]*models.FeatureState)
+	}
+	c.deletedFeatures[feature.Key] = feature
+}
+
+// isSameFeature tells us whether two states belong to the same feature (one which has been deleted and re-created gets a new ID, and starts its versions again):
+func isSameFeature(feature, otherFeature *models.FeatureState) bool {
+	return feature.ID == "" || otherFeature.ID == "" || feature.ID == otherFeature.ID
+}
+
+// isOlderEventID tells us whether an event ID comes from before another one (only when both are numeric, because that's the only way we can compare them):
+func isOlderEventID(eventID, otherEventID string) bool {
+	id, err := strconv.ParseUint(eventID, 10, 64)
+	if err != nil {
+		return false
+	}
+	otherID, err := strconv.ParseUint(otherEventID, 10, 64)
+	if err != nil {
+		return false
+	}
+	return id < otherID
+}
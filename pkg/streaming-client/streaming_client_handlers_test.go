@@ -2,10 +2,15 @@ package streamingclient
 
 import (
 	"bytes"
+	"context"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/donovanhide/eventsource"
 	"github.com/featurehub-io/featurehub-go-sdk/pkg/errors"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/logging"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/mocks"
 	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
@@ -32,7 +37,7 @@ func TestStreamingClientHandlers(t *testing.T) {
 		},
 		config:   config,
 		features: make(map[string]*models.FeatureState),
-		logger:   logger,
+		logger:   logging.NewLogrusLogger(logger),
 	}
 
 	// Load the mock apiClient up with a "feature" event:
@@ -68,6 +73,11 @@ func TestStreamingClientHandlers(t *testing.T) {
 	// Start handling events:
 	client.Start()
 
+	// Start() returns as soon as we're ready, so wait for the "config_stale" event to close the client:
+	assert.Eventually(t, func() bool {
+		return !client.isRunning.Load()
+	}, time.Second, 10*time.Millisecond)
+
 	// Make sure new features with old versions don't clobber values:
 	anotherFeature, err := client.GetFeature("anotherfeature")
 	assert.NoError(t, err)
@@ -85,4 +95,261 @@ func TestStreamingClientHandlers(t *testing.T) {
 
 	// Check that the client knew not to trigger the readiness listener (because there was none):
 	assert.Contains(t, logBuffer.String(), "The FeatureHub server has requested that we close our connection")
+
+	// Analytics carry on until the client itself is closed:
+	fakeCollector := new(mocks.FakeAnalyticsCollector)
+	client.AddAnalyticsCollector(fakeCollector)
+	client.LogAnalyticsEvent("after-edge-stale", nil)
+	assert.NoError(t, client.FlushAnalytics(context.Background()))
+	assert.Equal(t, 1, fakeCollector.LogEventCallCount())
+	client.Close()
+	client.LogAnalyticsEvent("after-close", nil)
+	assert.NoError(t, client.FlushAnalytics(context.Background()))
+	assert.Equal(t, 1, fakeCollector.LogEventCallCount())
+}
+
+func TestStreamingClientSharedStrategies(t *testing.T) {
+
+	// Make a test config:
+	config := &Config{
+		WaitForData: true,
+	}
+
+	// Make a logger:
+	logger := logrus.New()
+	logger.SetLevel(logrus.TraceLevel)
+	logBuffer := new(bytes.Buffer)
+	logger.SetOutput(logBuffer)
+
+	// Use the config to make a new StreamingClient with a mock apiClient::
+	client := &StreamingClient{
+		apiClient: &eventsource.Stream{
+			Errors: make(chan error, 100),
+			Events: make(chan eventsource.Event, 100),
+		},
+		config:    config,
+		features:  make(map[string]*models.FeatureState),
+		logger:    logging.NewLogrusLogger(logger),
+		notifiers: make(notifiers),
+	}
+
+	// Count the notifications for a feature which depends on a shared strategy:
+	var notifications int
+	var notificationsMutex sync.Mutex
+	client.AddNotifierFeature("sharedfeature", func(*models.FeatureState) {
+		notificationsMutex.Lock()
+		defer notificationsMutex.Unlock()
+		notifications++
+	})
+
+	// Load the mock apiClient up with a "features" event (referencing a shared strategy we don't know about yet):
+	client.apiClient.Events <- &testEvent{
+		data:  `[{"key":"sharedfeature","type":"STRING","value":"default","version":1,"strategies":[{"id":"s1","sharedStrategyId":"shared1","value":"for the russians"}]}]`,
+		event: "features",
+	}
+
+	// Start handling events:
+	client.Start()
+
+	// The reference is unresolved, so we should get the default value:
+	russianContext := client.WithContext(&models.Context{Country: models.ContextCountryRussia})
+	stringValue, err := russianContext.GetString("sharedfeature")
+	assert.NoError(t, err)
+	assert.Equal(t, "default", stringValue)
+
+	// Load the mock apiClient up with a "strategies" event:
+	client.apiClient.Events <- &testEvent{
+		data:  `[{"id":"shared1","name":"russia","version":1,"attributes":[{"id":"a1","conditional":"EQUALS","fieldName":"country","values":["russia"],"type":"STRING"}]}]`,
+		event: "strategies",
+	}
+
+	// Now the shared strategy should be applied:
+	assert.Eventually(t, func() bool {
+		stringValue, _ := russianContext.GetString("sharedfeature")
+		return stringValue == "for the russians"
+	}, time.Second, 10*time.Millisecond)
+
+	// An old version of the shared strategy should be ignored:
+	client.apiClient.Events <- &testEvent{
+		data:  `{"id":"shared1","name":"russia","version":1,"attributes":[{"id":"a1","conditional":"EQUALS","fieldName":"country","values":["france"],"type":"STRING"}]}`,
+		event: "strategy",
+	}
+
+	// A new version of the shared strategy should replace it:
+	client.apiClient.Events <- &testEvent{
+		data:  `{"id":"shared1","name":"france","version":2,"attributes":[{"id":"a1","conditional":"EQUALS","fieldName":"country","values":["france"],"type":"STRING"}]}`,
+		event: "strategy",
+	}
+	assert.Eventually(t, func() bool {
+		stringValue, _ := client.WithContext(&models.Context{Country: models.ContextCountryFrance}).GetString("sharedfeature")
+		return stringValue == "for the russians"
+	}, time.Second, 10*time.Millisecond)
+	stringValue, err = russianContext.GetString("sharedfeature")
+	assert.NoError(t, err)
+	assert.Equal(t, "default", stringValue)
+
+	// Broken payloads don't replace, store or delete anything:
+	client.handleFHStrategies(&testEvent{data: `this is not json`, event: "strategies"})
+	client.handleFHStrategy(&testEvent{data: `this is not json either`, event: "strategy"})
+	client.handleFHDeleteStrategy(&testEvent{data: `nor is this`, event: "delete_strategy"})
+	client.featuresMutex.Lock()
+	assert.Len(t, client.sharedStrategies, 1)
+	assert.Equal(t, int64(2), client.sharedStrategies["shared1"].Version)
+	assert.NotContains(t, client.sharedStrategies, "")
+	client.featuresMutex.Unlock()
+	stringValue, err = client.WithContext(&models.Context{Country: models.ContextCountryFrance}).GetString("sharedfeature")
+	assert.NoError(t, err)
+	assert.Equal(t, "for the russians", stringValue)
+
+	// Deleting the shared strategy leaves the reference unresolved again:
+	client.apiClient.Events <- &testEvent{
+		data:  `{"id":"shared1"}`,
+		event: "delete_strategy",
+	}
+	// The dependent feature was notified for the initial load and each of the three shared strategy changes:
+	assert.Eventually(t, func() bool {
+		notificationsMutex.Lock()
+		defer notificationsMutex.Unlock()
+		return notifications == 4
+	}, time.Second, 10*time.Millisecond)
+	stringValue, err = client.WithContext(&models.Context{Country: models.ContextCountryFrance}).GetString("sharedfeature")
+	assert.NoError(t, err)
+	assert.Equal(t, "default", stringValue)
+}
+
+func TestStreamingClientReconciliation(t *testing.T) {
+
+	// Make a logger:
+	logger := logrus.New()
+	logger.SetOutput(new(bytes.Buffer))
+
+	// Use the config to make a new StreamingClient with a mock apiClient::
+	client := &StreamingClient{
+		apiClient: &eventsource.Stream{
+			Errors: make(chan error, 100),
+			Events: make(chan eventsource.Event, 100),
+		},
+		config:    &Config{WaitForData: true},
+		features:  make(map[string]*models.FeatureState),
+		logger:    logging.NewLogrusLogger(logger),
+		notifiers: make(notifiers),
+	}
+
+	// Keep track of which features we're notified about:
+	var notified []string
+	var notifiedMutex sync.Mutex
+	client.AddNotifierAllFeatures(func(feature *models.FeatureState) {
+		notifiedMutex.Lock()
+		defer notifiedMutex.Unlock()
+		notified = append(notified, feature.Key)
+	})
+	takeNotified := func() []string {
+		time.Sleep(100 * time.Millisecond)
+		notifiedMutex.Lock()
+		defer notifiedMutex.Unlock()
+		taken := notified
+		notified = nil
+		return taken
+	}
+
+	// Start with some features:
+	client.apiClient.Events <- &testEvent{
+		data:  `[{"id":"1","key":"feature1","type":"STRING","value":"v2","version":2},{"id":"2","key":"feature2","type":"STRING","value":"v1","version":1},{"id":"3","key":"feature3","type":"STRING","value":"v1","version":1}]`,
+		event: "features",
+		id:    "event-1",
+	}
+	client.Start()
+	assert.ElementsMatch(t, []string{"feature1", "feature2", "feature3"}, takeNotified())
+	assert.Equal(t, "event-1", client.Status().LastEventID)
+
+	// A full set of features from a server which is lagging behind:
+	// - feature1 is an older version (which should be ignored)
+	// - feature2 hasn't changed (so nobody is notified)
+	// - feature3 is missing (but the server may just not have heard about it yet, so it is kept)
+	// - feature4 is new
+	client.apiClient.Events <- &testEvent{
+		data:  `[{"id":"1","key":"feature1","type":"STRING","value":"v1","version":1},{"id":"2","key":"feature2","type":"STRING","value":"v1","version":1},{"id":"4","key":"feature4","type":"STRING","value":"v1","version":1}]`,
+		event: "features",
+	}
+	assert.ElementsMatch(t, []string{"feature4"}, takeNotified())
+	assert.Equal(t, []string{"feature1", "feature2", "feature3", "feature4"}, client.Keys())
+	value, err := client.GetString("feature1")
+	assert.NoError(t, err)
+	assert.Equal(t, "v2", value)
+	assert.Equal(t, "event-1", client.Status().LastEventID)
+
+	// A full set of features which is up to date deletes anything missing from it:
+	client.apiClient.Events <- &testEvent{
+		data:  `[{"id":"1","key":"feature1","type":"STRING","value":"v2","version":2},{"id":"2","key":"feature2","type":"STRING","value":"v1","version":1},{"id":"4","key":"feature4","type":"STRING","value":"v1","version":1}]`,
+		event: "features",
+	}
+	assert.ElementsMatch(t, []string{"feature3"}, takeNotified())
+	assert.Equal(t, []string{"feature1", "feature2", "feature4"}, client.Keys())
+
+	// Old versions of deleted features can't come back:
+	client.apiClient.Events <- &testEvent{
+		data:  `[{"id":"1","key":"feature1","type":"STRING","value":"v2","version":2},{"id":"2","key":"feature2","type":"STRING","value":"v1","version":1},{"id":"3","key":"feature3","type":"STRING","value":"v1","version":1},{"id":"4","key":"feature4","type":"STRING","value":"v1","version":1}]`,
+		event: "features",
+	}
+	client.apiClient.Events <- &testEvent{
+		data:  `{"id":"3","key":"feature3","type":"STRING","value":"v1","version":1}`,
+		event: "feature",
+	}
+	assert.Empty(t, takeNotified())
+	assert.Equal(t, []string{"feature1", "feature2", "feature4"}, client.Keys())
+
+	// But newer versions can, and so can features which have been re-created (with a new ID):
+	client.apiClient.Events <- &testEvent{
+		data:  `{"id":"3","key":"feature3","type":"STRING","value":"v2","version":2}`,
+		event: "feature",
+	}
+	client.apiClient.Events <- &testEvent{
+		data:  `{"id":"2","key":"feature2","version":1}`,
+		event: "delete_feature",
+	}
+	client.apiClient.Events <- &testEvent{
+		data:  `{"id":"2b","key":"feature2","type":"STRING","value":"recreated","version":1}`,
+		event: "feature",
+		id:    "event-2",
+	}
+	assert.ElementsMatch(t, []string{"feature3", "feature2", "feature2"}, takeNotified())
+	value, err = client.GetString("feature2")
+	assert.NoError(t, err)
+	assert.Equal(t, "recreated", value)
+
+	// Deletions of older versions are ignored (and deleting something we don't have notifies nobody):
+	client.apiClient.Events <- &testEvent{
+		data:  `{"id":"3","key":"feature3","version":1}`,
+		event: "delete_feature",
+	}
+	client.apiClient.Events <- &testEvent{
+		data:  `{"id":"5","key":"feature5","version":1}`,
+		event: "delete_feature",
+	}
+	assert.Empty(t, takeNotified())
+	assert.Equal(t, []string{"feature1", "feature2", "feature3", "feature4"}, client.Keys())
+
+	// Broken payloads don't wipe out our features:
+	client.apiClient.Events <- &testEvent{
+		data:  `this is not json`,
+		event: "features",
+	}
+	client.apiClient.Events <- &testEvent{
+		data:  `this is not json either`,
+		event: "feature",
+	}
+	assert.Empty(t, takeNotified())
+	assert.Equal(t, []string{"feature1", "feature2", "feature3", "feature4"}, client.Keys())
+	assert.Equal(t, "event-2", client.Status().LastEventID)
+
+	// Numeric event IDs tell us when a snapshot is older than what we already have:
+	allFeatures := `[{"id":"1","key":"feature1","type":"STRING","value":"v2","version":2},{"id":"2b","key":"feature2","type":"STRING","value":"recreated","version":1},{"id":"3","key":"feature3","type":"STRING","value":"v2","version":2},{"id":"4","key":"feature4","type":"STRING","value":"v1","version":1}]`
+	withoutFeature4 := `[{"id":"1","key":"feature1","type":"STRING","value":"v2","version":2},{"id":"2b","key":"feature2","type":"STRING","value":"recreated","version":1},{"id":"3","key":"feature3","type":"STRING","value":"v2","version":2}]`
+	client.apiClient.Events <- &testEvent{data: allFeatures, event: "features", id: "10"}
+	client.apiClient.Events <- &testEvent{data: withoutFeature4, event: "features", id: "9"}
+	assert.Empty(t, takeNotified())
+	assert.Equal(t, []string{"feature1", "feature2", "feature3", "feature4"}, client.Keys())
+	client.apiClient.Events <- &testEvent{data: withoutFeature4, event: "features", id: "11"}
+	assert.ElementsMatch(t, []string{"feature4"}, takeNotified())
+	assert.Equal(t, []string{"feature1", "feature2", "feature3"}, client.Keys())
 }
@@ -8,6 +8,18 @@ import (
 	"github.com/google/uuid"
 )
 
+// NotifierKeyAllFeatures is the feature key used for notifiers which are interested in every feature (use it to delete them):
+const NotifierKeyAllFeatures = "*"
+
+// AddNotifierAllFeatures adds a notifier callback function which will be executed any time any feature is updated (or deleted):
+func (c *StreamingClient) AddNotifierAllFeatures(callbackFunc models.CallbackFuncFeature) string {
+	return c.addNotifier(notifier{
+		callbackFuncFeature: callbackFunc,
+		featureKey:          NotifierKeyAllFeatures,
+		featureValueType:    models.TypeFeature,
+	})
+}
+
 // AddNotifierBoolean adds a notifier callback function which will be executed any time the feature with the given key is updated:
 func (c *StreamingClient) AddNotifierBoolean(featureKey string, callbackFunc models.CallbackFuncBoolean) string {
 	return c.addNotifier(notifier{
@@ -94,24 +106,52 @@ func (c *StreamingClient) DeleteNotifier(featureKey, notifierUUID string) error
 	return nil
 }
 
-// notify triggers a callback:
+// NotifierCounts returns the number of notifiers registered for each feature key:
+func (c *StreamingClient) NotifierCounts() map[string]int {
+	c.notifiersMutex.Lock()
+	defer c.notifiersMutex.Unlock()
+
+	notifierCounts := make(map[string]int, len(c.notifiers))
+	for featureKey, featureKeyNotifiers := range c.notifiers {
+		notifierCounts[featureKey] = len(featureKeyNotifiers)
+	}
+
+	return notifierCounts
+}
+
+// notify triggers the callbacks for a feature (and any which are interested in every feature):
 func (c *StreamingClient) notify(feature *models.FeatureState) error {
 	c.notifiersMutex.Lock()
 	defer c.notifiersMutex.Unlock()
 
 	// First check that the given featureKey has any notifiers at all:
 	featureKeyNotifiers, featureKeyExists := c.notifiers[feature.Key]
-	if !featureKeyExists {
+	allFeaturesNotifiers, allFeaturesExists := c.notifiers[NotifierKeyAllFeatures]
+	if !featureKeyExists && !allFeaturesExists {
 		err := errors.NewErrNotifierNotFound(feature.Key)
 		c.logger.WithError(err).WithField("key", feature.Key).Trace("Attempt to call a notifier that doesn't exist")
 		return err
 	}
 
 	// Now we just trigger them all:
+	c.triggerNotifiers(featureKeyNotifiers, feature)
+	c.triggerNotifiers(allFeaturesNotifiers, feature)
+
+	return nil
+}
+
+// notifyDeleted triggers the callbacks which are interested in every feature when a feature is deleted:
+func (c *StreamingClient) notifyDeleted(feature *models.FeatureState) {
+	c.notifiersMutex.Lock()
+	defer c.notifiersMutex.Unlock()
+
+	c.triggerNotifiers(c.notifiers[NotifierKeyAllFeatures], feature)
+}
+
+// triggerNotifiers triggers each of the given notifiers (the caller must hold the notifiersMutex):
+func (c *StreamingClient) triggerNotifiers(featureKeyNotifiers map[string]notifier, feature *models.FeatureState) {
 	for _, notifier := range featureKeyNotifiers {
-		notifier.notify(feature)
+		notifier.notify(feature, c.config.getMetrics())
 		c.logger.WithField("key", feature.Key).WithField("uuid", notifier.uuid).Debug("Triggered a notifier")
 	}
-
-	return nil
 }
@@ -2,11 +2,14 @@ package streamingclient
 
 import (
 	"bytes"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/donovanhide/eventsource"
 	"github.com/featurehub-io/featurehub-go-sdk/pkg/errors"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/logging"
 	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
@@ -22,7 +25,7 @@ func TestStreamingClientNotifiers(t *testing.T) {
 	// Make a logger:
 	logger := logrus.New()
 	logger.SetLevel(logrus.TraceLevel)
-	logBuffer := new(bytes.Buffer)
+	logBuffer := new(lockedBuffer)
 	logger.SetOutput(logBuffer)
 
 	// Use the config to make a new StreamingClient with a mock apiClient::
@@ -33,7 +36,7 @@ func TestStreamingClientNotifiers(t *testing.T) {
 		},
 		config:    config,
 		features:  make(map[string]*models.FeatureState),
-		logger:    logger,
+		logger:    logging.NewLogrusLogger(logger),
 		notifiers: make(notifiers),
 	}
 
@@ -62,32 +65,32 @@ func TestStreamingClientNotifiers(t *testing.T) {
 	}
 
 	// Feature1 gets one notifier:
-	var callback1called int
+	var callback1called int32
 	callbackFunc1 := func(*models.FeatureState) {
-		callback1called++
+		atomic.AddInt32(&callback1called, 1)
 	}
 	client.AddNotifierFeature("feature1", callbackFunc1)
 
 	// Feature 2 gets 2 notifiers (1/2):
-	var callback21called int
+	var callback21called int32
 	callbackFunc21 := func(*models.FeatureState) {
-		callback21called++
+		atomic.AddInt32(&callback21called, 1)
 	}
 	feature2UUID1 := client.AddNotifierFeature("feature2", callbackFunc21)
 
 	// Feature 2 gets 2 notifiers (2/2):
-	var callback22called int
+	var callback22called int32
 	callbackFunc22 := func(*models.FeatureState) {
-		callback22called++
+		atomic.AddInt32(&callback22called, 1)
 	}
 	feature2UUID2 := client.AddNotifierFeature("feature2", callbackFunc22)
 	assert.Len(t, client.notifiers["feature2"], 2)
 	assert.NotSame(t, feature2UUID1, feature2UUID2)
 
 	// Feature3 gets 1 notifer, but we'll delete it before it gets called:
-	var callback3called int
+	var callback3called int32
 	callbackFunc3 := func(*models.FeatureState) {
-		callback3called++
+		atomic.AddInt32(&callback3called, 1)
 	}
 	client.AddNotifierFeature("feature3", callbackFunc3)
 
@@ -105,8 +108,12 @@ func TestStreamingClientNotifiers(t *testing.T) {
 	assert.IsType(t, &errors.ErrNotifierNotFound{}, err)
 
 	// Add a readiness-listener:
+	// (the callbacks are called from other goroutines, so everything they set is guarded by a mutex):
+	var callbackMutex sync.Mutex
 	var readinessListenerCalled = false
 	callbackReadiness := func() {
+		callbackMutex.Lock()
+		defer callbackMutex.Unlock()
 		readinessListenerCalled = true
 	}
 	client.ReadinessListener(callbackReadiness)
@@ -114,15 +121,17 @@ func TestStreamingClientNotifiers(t *testing.T) {
 	// Start handling events:
 	client.Start()
 
-	// Check that the the correct callbacks were made:
-	assert.Equal(t, 1, callback1called)
-	assert.Equal(t, 1, callback21called)
-	assert.Equal(t, 1, callback22called)
-	assert.Equal(t, 0, callback3called)
+	// Check that the the correct callbacks were made (Start() returns as soon as the first event is handled):
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&callback1called) == 1 && atomic.LoadInt32(&callback21called) == 1 && atomic.LoadInt32(&callback22called) == 1
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&callback3called))
 
 	// Add a BOOLEAN callback:
 	var callbackBooleanValue = false
 	callbackBoolean := func(value bool) {
+		callbackMutex.Lock()
+		defer callbackMutex.Unlock()
 		callbackBooleanValue = value
 	}
 	client.AddNotifierBoolean("booleanfeature", callbackBoolean)
@@ -136,6 +145,8 @@ func TestStreamingClientNotifiers(t *testing.T) {
 	// Add a JSON callback:
 	var callbackJSONValue = `{}`
 	callbackJSON := func(value string) {
+		callbackMutex.Lock()
+		defer callbackMutex.Unlock()
 		callbackJSONValue = value
 	}
 	client.AddNotifierJSON("jsonfeature", callbackJSON)
@@ -149,6 +160,8 @@ func TestStreamingClientNotifiers(t *testing.T) {
 	// Add a NUMBER callback:
 	var callbackNumberValue float64 = 0
 	callbackNumber := func(value float64) {
+		callbackMutex.Lock()
+		defer callbackMutex.Unlock()
 		callbackNumberValue = value
 	}
 	client.AddNotifierNumber("numberfeature", callbackNumber)
@@ -162,8 +175,15 @@ func TestStreamingClientNotifiers(t *testing.T) {
 	// Add a STRING callback:
 	var callbackStringValue = `{}`
 	callbackString := func(value string) {
+		callbackMutex.Lock()
+		defer callbackMutex.Unlock()
 		callbackStringValue = value
 	}
+	getCallbackStringValue := func() string {
+		callbackMutex.Lock()
+		defer callbackMutex.Unlock()
+		return callbackStringValue
+	}
 	client.AddNotifierString("stringfeature", callbackString)
 
 	// Load the mock apiClient up with a "feature" event:
@@ -176,6 +196,7 @@ func TestStreamingClientNotifiers(t *testing.T) {
 	time.Sleep(250 * time.Millisecond)
 
 	// Check that the callback functions were all triggered (with the correct values):
+	callbackMutex.Lock()
 	assert.Equal(t, true, callbackBooleanValue)
 	assert.Equal(t, `{"is_crufty": true}`, callbackJSONValue)
 	assert.Equal(t, float64(123456789), callbackNumberValue)
@@ -183,5 +204,58 @@ func TestStreamingClientNotifiers(t *testing.T) {
 
 	// Check that the client triggered the readiness listener:
 	assert.True(t, readinessListenerCalled)
+	callbackMutex.Unlock()
 	assert.Contains(t, logBuffer.String(), "Calling readinessListener()")
+
+	// Add a notifier for every feature:
+	var allFeaturesKeys []string
+	var allFeaturesMutex sync.Mutex
+	callbackAllFeatures := func(feature *models.FeatureState) {
+		allFeaturesMutex.Lock()
+		defer allFeaturesMutex.Unlock()
+		allFeaturesKeys = append(allFeaturesKeys, feature.Key)
+	}
+	allFeaturesUUID := client.AddNotifierAllFeatures(callbackAllFeatures)
+
+	// Update one feature and delete another:
+	client.apiClient.Events <- &testEvent{
+		data:  `{"key":"stringfeature","type":"STRING","value":"this is a new string","version":1}`,
+		event: "feature",
+	}
+	client.apiClient.Events <- &testEvent{
+		data:  `{"key":"numberfeature","type":"NUMBER","version":1}`,
+		event: "delete_feature",
+	}
+
+	// Check that the notifier heard about both of them:
+	assert.Eventually(t, func() bool {
+		allFeaturesMutex.Lock()
+		defer allFeaturesMutex.Unlock()
+		return len(allFeaturesKeys) == 2
+	}, time.Second, 10*time.Millisecond)
+	allFeaturesMutex.Lock()
+	assert.ElementsMatch(t, []string{"stringfeature", "numberfeature"}, allFeaturesKeys)
+	allFeaturesMutex.Unlock()
+	assert.Eventually(t, func() bool { return getCallbackStringValue() == "this is a new string" }, time.Second, 10*time.Millisecond)
+	assert.NoError(t, client.DeleteNotifier(NotifierKeyAllFeatures, allFeaturesUUID))
+}
+
+// lockedBuffer is a buffer which the logger can write to while the test reads from it:
+type lockedBuffer struct {
+	buffer bytes.Buffer
+	mutex  sync.Mutex
+}
+
+// String returns the contents of the buffer:
+func (b *lockedBuffer) String() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buffer.String()
+}
+
+// Write appends to the buffer:
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buffer.Write(p)
 }
@@ -0,0 +1,174 @@
+package streamingclient
+
+import (
+	"context"
+
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/errors"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
+)
+
+// FailedReadinessListener adds a function which will be called if the client fails before it is ready:
+func (c *StreamingClient) FailedReadinessListener(callbackFunc func(err error)) {
+	c.statusMutex.Lock()
+	readiness, failure := c.readinessState(), c.failure
+	if readiness != models.ReadinessFailed {
+		c.failedReadinessListeners = append(c.failedReadinessListeners, callbackFunc)
+	}
+	c.statusMutex.Unlock()
+
+	// We've already failed, so this one can be called straight away:
+	if readiness == models.ReadinessFailed && callbackFunc != nil {
+		go callbackFunc(failure)
+	}
+}
+
+// ReadinessListener adds a function which will be called once the client has received data for the first time:
+func (c *StreamingClient) ReadinessListener(callbackFunc func()) {
+	c.statusMutex.Lock()
+	hasData := c.hasData
+	if !hasData {
+		c.readinessListeners = append(c.readinessListeners, callbackFunc)
+	}
+	c.statusMutex.Unlock()
+
+	// We're already ready, so this one can be called straight away:
+	if hasData && callbackFunc != nil {
+		go callbackFunc()
+	}
+}
+
+// Readiness returns the current readiness state of the client (useful for readiness probes):
+func (c *StreamingClient) Readiness() models.Readiness {
+	c.statusMutex.Lock()
+	defer c.statusMutex.Unlock()
+	return c.readinessState()
+}
+
+// Ready returns a channel which is closed once the client has received data for the first time:
+func (c *StreamingClient) Ready() <-chan struct{} {
+	c.statusMutex.Lock()
+	defer c.statusMutex.Unlock()
+	return c.readyChannel()
+}
+
+// WaitUntilReady blocks until the client has received data for the first time (or fails, or the context is done):
+func (c *StreamingClient) WaitUntilReady(ctx context.Context) error {
+	c.statusMutex.Lock()
+	ready, failed := c.readyChannel(), c.failedChannel()
+	c.statusMutex.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-failed:
+		c.statusMutex.Lock()
+		defer c.statusMutex.Unlock()
+		return c.failure
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// fail marks the client as failed (unless it is already ready), then hands the error to the fatal error handler:
+func (c *StreamingClient) fail(err error, message string, details map[string]interface{}) {
+	c.setFailed(errors.NewErrNotReady(message))
+
+	// Use the configured fatal error handler (or the default one):
+	if c.fatalErrorHandler != nil {
+		c.fatalErrorHandler(err, message, details)
+	} else {
+		c.fatalErrorFunc(err, message, details)
+	}
+}
+
+// isReady triggers various notifications that the client is ready to serve data:
+func (c *StreamingClient) isReady() {
+	c.statusMutex.Lock()
+	defer c.statusMutex.Unlock()
+
+	// Only the first data counts:
+	if c.hasData {
+		return
+	}
+	c.hasData = true
+
+	// A client which has failed stays failed:
+	if c.readinessState() == models.ReadinessFailed {
+		return
+	}
+
+	// Flag us as ready:
+	c.readiness = models.ReadinessReady
+	close(c.readyChannel())
+
+	// Trigger the registered readiness listeners (outside of any locks the caller may be holding):
+	readinessListeners := c.readinessListeners
+	c.readinessListeners = nil
+	if len(readinessListeners) == 0 {
+		c.logger.Trace("No registered readinessListener() to call")
+		return
+	}
+	c.logger.Trace("Calling readinessListener()")
+	go func() {
+		for _, readinessListener := range readinessListeners {
+			if readinessListener != nil {
+				readinessListener()
+			}
+		}
+	}()
+}
+
+// setFailed marks the client as failed (if it isn't ready yet), triggering the failed readiness listeners:
+func (c *StreamingClient) setFailed(err error) {
+	c.statusMutex.Lock()
+	defer c.statusMutex.Unlock()
+
+	// Only clients which aren't ready yet can fail:
+	if c.hasData || c.readinessState() == models.ReadinessFailed {
+		return
+	}
+
+	// Flag us as failed:
+	c.failure = err
+	c.readiness = models.ReadinessFailed
+	close(c.failedChannel())
+
+	// Trigger the registered failed readiness listeners (outside of any locks the caller may be holding):
+	failedReadinessListeners := c.failedReadinessListeners
+	c.failedReadinessListeners = nil
+	go func() {
+		for _, failedReadinessListener := range failedReadinessListeners {
+			if failedReadinessListener != nil {
+				failedReadinessListener(err)
+			}
+		}
+	}()
+}
+
+// failedChannel returns the channel which is closed when the client fails (statusMutex must be held):
+func (c *StreamingClient) failedChannel() chan struct{} {
+	if c.failed == nil {
+		c.failed = make(chan struct{})
+	}
+	return c.failed
+}
+
+// readinessState returns the readiness state (statusMutex must be held):
+func (c *StreamingClient) readinessState() models.Readiness {
+	switch {
+	case c.readiness == "":
+		return models.ReadinessNotReady
+	case c.readiness == models.ReadinessReady && c.stale:
+		return models.ReadinessStale
+	default:
+		return c.readiness
+	}
+}
+
+// readyChannel returns the channel which is closed when the client is ready (statusMutex must be held):
+func (c *StreamingClient) readyChannel() chan struct{} {
+	if c.ready == nil {
+		c.ready = make(chan struct{})
+	}
+	return c.ready
+}
@@ -0,0 +1,81 @@
+package streamingclient
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
+)
+
+// minimumStalenessCheckInterval stops us from checking for staleness too often (if StaleAfter is very short):
+const minimumStalenessCheckInterval = 10 * time.Millisecond
+
+// Stale tells us whether we've gone longer than Config.StaleAfter without hearing from the server:
+func (c *StreamingClient) Stale() bool {
+	c.statusMutex.Lock()
+	defer c.statusMutex.Unlock()
+	return c.stale
+}
+
+// StaleListener adds a function which will be called when the client becomes stale (true), and when it hears from the server again (false):
+func (c *StreamingClient) StaleListener(callbackFunc func(stale bool)) {
+	c.statusMutex.Lock()
+	defer c.statusMutex.Unlock()
+	c.staleListeners = append(c.staleListeners, callbackFunc)
+}
+
+// monitorStaleness marks us as stale (and forces a reconnect) whenever we go longer than StaleAfter without hearing from the server:
+func (c *StreamingClient) monitorStaleness() {
+	checkInterval := c.config.StaleAfter / 4
+	if checkInterval < minimumStalenessCheckInterval {
+		checkInterval = minimumStalenessCheckInterval
+	}
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+
+		// We may have been shut down by some external process:
+		if !c.isRunning.Load() {
+			c.logger.Info("No longer monitoring for staleness")
+			return
+		}
+
+		// See how long it has been since we heard from the server (or last reconnected):
+		c.statusMutex.Lock()
+		apiClient := c.apiClient
+		sinceLastEvent := time.Since(c.lastEventAt)
+		if sinceLastEvent < c.config.StaleAfter {
+			c.statusMutex.Unlock()
+			continue
+		}
+		becameStale := !c.stale
+		c.connected = false
+		c.stale = true
+		c.statusMutex.Unlock()
+
+		// Let everybody know:
+		if becameStale {
+			c.logger.WithField("stale_after", c.config.StaleAfter).Warn("No events received from the FeatureHub server, the connection is stale")
+			c.triggerStaleListeners(true)
+		}
+
+		// Force a reconnect (the old connection may be hanging without ever erroring):
+		c.config.getMetrics().Reconnect()
+		c.config.getTracer().Connection(models.ConnectionEventDisconnected, fmt.Errorf("no events received for %s", sinceLastEvent.Round(time.Millisecond)))
+		c.reconnect(apiClient)
+	}
+}
+
+// triggerStaleListeners calls each of the stale listeners:
+func (c *StreamingClient) triggerStaleListeners(stale bool) {
+	c.statusMutex.Lock()
+	staleListeners := append([]func(bool){}, c.staleListeners...)
+	c.statusMutex.Unlock()
+
+	for _, staleListener := range staleListeners {
+		if staleListener != nil {
+			staleListener(stale)
+		}
+	}
+}
@@ -1,9 +1,21 @@
 package streamingclient
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/donovanhide/eventsource"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/errors"
 	"github.com/featurehub-io/featurehub-go-sdk/pkg/interfaces"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/logging"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/mocks"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 )
@@ -33,4 +45,521 @@ func TestStreamingClient(t *testing.T) {
 	client, err := NewStreamingClient(config)
 	assert.Error(t, err)
 	assert.Implements(t, new(interfaces.Client), client)
+
+	// Try again with our own logger:
+	logger := logrus.New()
+	logBuffer := new(bytes.Buffer)
+	logger.SetOutput(logBuffer)
+	_, err = NewStreamingClient(config.WithLogger(logging.NewLogrusLogger(logger)))
+	assert.Error(t, err)
+
+	// The error was logged to our logger, without the SDK key:
+	assert.Contains(t, logBuffer.String(), "Error subscribing to server")
+	assert.Contains(t, logBuffer.String(), "/features/[REDACTED]")
+	assert.NotContains(t, logBuffer.String(), "my-secret-api-key")
+
+	// Clients don't share loggers:
+	otherLogger := logrus.New()
+	otherLogBuffer := new(bytes.Buffer)
+	otherLogger.SetOutput(otherLogBuffer)
+	otherConfig := NewConfig("http://streams.test:8086", "default/environment-id/another-secret-api-key").WithLogger(logging.NewLogrusLogger(otherLogger))
+	client1 := &StreamingClient{config: config, logger: config.newLogger()}
+	client2 := &StreamingClient{config: otherConfig, logger: otherConfig.newLogger()}
+	client1.logger.Info("Hello from client1")
+	client2.logger.Info("Hello from client2")
+	assert.Contains(t, logBuffer.String(), "Hello from client1")
+	assert.NotContains(t, logBuffer.String(), "Hello from client2")
+	assert.Contains(t, otherLogBuffer.String(), "Hello from client2")
+	assert.NotContains(t, otherLogBuffer.String(), "Hello from client1")
+
+	// WaitForData gives up after WaitForDataTimeout:
+	client3 := &StreamingClient{
+		apiClient: &eventsource.Stream{
+			Errors: make(chan error, 100),
+			Events: make(chan eventsource.Event, 100),
+		},
+		config:    config.WithWaitForDataTimeout(50 * time.Millisecond),
+		features:  make(map[string]*models.FeatureState),
+		logger:    logging.NewLogrusLogger(logger),
+		notifiers: make(notifiers),
+	}
+	startedAt := time.Now()
+	client3.Start()
+	assert.Less(t, time.Since(startedAt), time.Second)
+	assert.Contains(t, logBuffer.String(), "Timed out waiting for data")
+}
+
+func TestStreamingClientMetrics(t *testing.T) {
+
+	// Make a test config with some fake metrics:
+	fakeMetrics := new(mocks.FakeMetrics)
+	config := (&Config{WaitForData: true}).WithMetrics(fakeMetrics)
+
+	// Make a logger:
+	logger := logrus.New()
+	logger.SetOutput(new(bytes.Buffer))
+
+	// Use the config to make a new StreamingClient with a mock apiClient::
+	client := &StreamingClient{
+		apiClient: &eventsource.Stream{
+			Errors: make(chan error, 100),
+			Events: make(chan eventsource.Event, 100),
+		},
+		config:    config,
+		features:  make(map[string]*models.FeatureState),
+		logger:    logging.NewLogrusLogger(logger),
+		notifiers: make(notifiers),
+	}
+
+	// Add a notifier, and an analytics collector which always fails:
+	client.AddNotifierBoolean("booleanfeature", func(bool) {})
+	fakeAnalyticsCollector := new(mocks.FakeAnalyticsCollector)
+	fakeAnalyticsCollector.LogEventReturns(fmt.Errorf("nope"))
+	client.AddAnalyticsCollector(fakeAnalyticsCollector)
+
+	// Load the mock apiClient up with some events (one of which is garbage):
+	client.apiClient.Events <- &testEvent{
+		data:  `[{"key":"booleanfeature","type":"BOOLEAN","value":true,"strategies":[{"id":"s1","value":false,"attributes":[{"id":"a1","conditional":"EQUALS","fieldName":"platform","values":["linux"],"type":"STRING"}]}]}]`,
+		event: "features",
+	}
+	client.apiClient.Events <- &testEvent{
+		data:  `this is not json`,
+		event: "feature",
+	}
+	client.apiClient.Errors <- fmt.Errorf("connection reset by peer")
+
+	// Start handling events:
+	client.Start()
+
+	// Check the SSE metrics:
+	assert.Eventually(t, func() bool { return fakeMetrics.SSEEventCallCount() == 2 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, "features", fakeMetrics.SSEEventArgsForCall(0))
+	assert.Equal(t, 1, fakeMetrics.ParseErrorCallCount())
+	assert.Equal(t, "feature", fakeMetrics.ParseErrorArgsForCall(0))
+	assert.Eventually(t, func() bool { return fakeMetrics.ReconnectCallCount() == 1 }, time.Second, 10*time.Millisecond)
+
+	// Check that the notifier was timed:
+	assert.Eventually(t, func() bool { return fakeMetrics.NotifierLatencyCallCount() == 1 }, time.Second, 10*time.Millisecond)
+	featureKey, _ := fakeMetrics.NotifierLatencyArgsForCall(0)
+	assert.Equal(t, "booleanfeature", featureKey)
+
+	// Evaluate some features:
+	client.WithContext(&models.Context{Platform: models.ContextPlatformLinux}).GetBoolean("booleanfeature")
+	client.WithContext(&models.Context{Platform: models.ContextPlatformWindows}).GetBoolean("booleanfeature")
+	client.WithContext(&models.Context{}).GetString("booleanfeature")
+	client.WithContext(&models.Context{}).GetString("nosuchfeature")
+	assert.Equal(t, 4, fakeMetrics.EvaluationCallCount())
+	key, strategyID, outcome := fakeMetrics.EvaluationArgsForCall(0)
+	assert.Equal(t, []interface{}{"booleanfeature", "s1", models.EvaluationOutcomeStrategy}, []interface{}{key, strategyID, outcome})
+	key, strategyID, outcome = fakeMetrics.EvaluationArgsForCall(1)
+	assert.Equal(t, []interface{}{"booleanfeature", "", models.EvaluationOutcomeDefault}, []interface{}{key, strategyID, outcome})
+	_, _, outcome = fakeMetrics.EvaluationArgsForCall(2)
+	assert.Equal(t, models.EvaluationOutcomeTypeMismatch, outcome)
+	_, _, outcome = fakeMetrics.EvaluationArgsForCall(3)
+	assert.Equal(t, models.EvaluationOutcomeNotFound, outcome)
+
+	// Check that analytics failures are counted:
+	assert.Error(t, client.LogAnalyticsEventSync("testing", nil))
+	assert.Equal(t, 1, fakeMetrics.AnalyticsCollectorFailureCallCount())
+	assert.Equal(t, "*mocks.FakeAnalyticsCollector", fakeMetrics.AnalyticsCollectorFailureArgsForCall(0))
+}
+
+func TestStreamingClientTracing(t *testing.T) {
+
+	// Make a test config with a fake tracer:
+	fakeTracer := new(mocks.FakeTracer)
+	config := (&Config{WaitForData: true}).WithTracer(fakeTracer)
+
+	// Make a logger:
+	logger := logrus.New()
+	logger.SetOutput(new(bytes.Buffer))
+
+	// Use the config to make a new StreamingClient with a mock apiClient::
+	client := &StreamingClient{
+		apiClient: &eventsource.Stream{
+			Errors: make(chan error, 100),
+			Events: make(chan eventsource.Event, 100),
+		},
+		config:    config,
+		features:  make(map[string]*models.FeatureState),
+		logger:    logging.NewLogrusLogger(logger),
+		notifiers: make(notifiers),
+	}
+
+	// Load the mock apiClient up with some features:
+	client.apiClient.Events <- &testEvent{
+		data:  `[{"key":"booleanfeature","type":"BOOLEAN","value":true,"version":3}]`,
+		event: "features",
+	}
+
+	// Start handling events:
+	client.Start()
+
+	// The connection should have been traced:
+	assert.Eventually(t, func() bool { return fakeTracer.ConnectionCallCount() == 1 }, time.Second, 10*time.Millisecond)
+	event, err := fakeTracer.ConnectionArgsForCall(0)
+	assert.Equal(t, models.ConnectionEventConnected, event)
+	assert.NoError(t, err)
+
+	// Now interrupt the connection:
+	client.apiClient.Errors <- fmt.Errorf("connection reset by peer")
+	assert.Eventually(t, func() bool { return fakeTracer.ConnectionCallCount() == 2 }, time.Second, 10*time.Millisecond)
+	event, err = fakeTracer.ConnectionArgsForCall(1)
+	assert.Equal(t, models.ConnectionEventDisconnected, event)
+	assert.EqualError(t, err, "connection reset by peer")
+
+	// Evaluations should be traced with the context we provide:
+	type contextKey struct{}
+	traceContext := context.WithValue(context.Background(), contextKey{}, "request")
+	value, err := client.WithContext(&models.Context{}).WithTraceContext(traceContext).GetBoolean("booleanfeature")
+	assert.NoError(t, err)
+	assert.True(t, value)
+	assert.Equal(t, 1, fakeTracer.EvaluationCallCount())
+	ctx, key, evaluatedFeature, outcome := fakeTracer.EvaluationArgsForCall(0)
+	assert.Equal(t, "request", ctx.Value(contextKey{}))
+	assert.Equal(t, "booleanfeature", key)
+	assert.Equal(t, int64(3), evaluatedFeature.Version)
+	assert.Equal(t, models.EvaluationOutcomeDefault, outcome)
+
+	// Without a trace context we get a background one:
+	client.WithContext(&models.Context{}).GetBoolean("nosuchfeature")
+	ctx, _, _, outcome = fakeTracer.EvaluationArgsForCall(1)
+	assert.Equal(t, context.Background(), ctx)
+	assert.Equal(t, models.EvaluationOutcomeNotFound, outcome)
+}
+
+func TestStreamingClientHTTP(t *testing.T) {
+
+	// A fake FeatureHub server (with TLS) which only streams features to clients with the right headers:
+	receivedHeaders := make(chan http.Header, 10)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedHeaders <- r.Header.Clone()
+		if r.Header.Get("Authorization") != "Bearer gateway-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, "event: features\ndata: [{\"key\":\"booleanfeature\",\"type\":\"BOOLEAN\",\"value\":true}]\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+	defer server.CloseClientConnections()
+
+	// The default HTTP client doesn't trust the server's certificate:
+	config := NewConfig(server.URL, "default/environment-id/my-secret-api-key").WithLogLevel(logrus.FatalLevel).WithWaitForData(true)
+	_, err := NewStreamingClient(config)
+	assert.Error(t, err)
+
+	// The server's own client does, but we're still missing the headers:
+	httpClient := server.Client()
+	_, err = NewStreamingClient(config.WithHTTPClient(httpClient))
+	assert.Error(t, err)
+	assert.Empty(t, (<-receivedHeaders).Get("Authorization"))
+
+	// With the headers we get some features:
+	headers := http.Header{"Authorization": []string{"Bearer gateway-token"}, "X-Gateway-Route": []string{"featurehub"}}
+	client, err := NewStreamingClient(config.WithHeaders(headers))
+	assert.NoError(t, err)
+	client.Start()
+	value, err := client.WithContext(&models.Context{}).GetBoolean("booleanfeature")
+	assert.NoError(t, err)
+	assert.True(t, value)
+	assert.Equal(t, "featurehub", (<-receivedHeaders).Get("X-Gateway-Route"))
+
+	// Our HTTP client wasn't changed, and neither were the headers we were given:
+	assert.Nil(t, httpClient.CheckRedirect)
+	headers.Set("Authorization", "changed")
+	assert.Equal(t, "Bearer gateway-token", config.headers.Get("Authorization"))
+
+	// A custom client brings its own timeouts:
+	assert.EqualError(t, config.WithConnectTimeout(time.Second).Validate(), "Invalid config: ConnectTimeout can't be used with a custom HTTP client (configure its transport instead)")
+}
+
+func TestStreamingClientFailover(t *testing.T) {
+
+	// A primary server which streams some features, drops the connection when we tell it to, then refuses any more:
+	var primaryRequests int32
+	dropPrimary := make(chan struct{})
+	primaryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&primaryRequests, 1) > 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, "id: event-1\nevent: features\ndata: [{\"key\":\"stringfeature\",\"type\":\"STRING\",\"value\":\"new\",\"version\":2}]\n\n")
+		w.(http.Flusher).Flush()
+		<-dropPrimary
+	}))
+	defer primaryServer.Close()
+
+	// A fallback server which is lagging behind (it has an older version of one feature, but a new feature as well):
+	lastEventIDs := make(chan string, 10)
+	fallbackServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastEventIDs <- r.Header.Get("Last-Event-ID")
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, "event: features\ndata: [{\"key\":\"stringfeature\",\"type\":\"STRING\",\"value\":\"old\",\"version\":1},{\"key\":\"booleanfeature\",\"type\":\"BOOLEAN\",\"value\":true,\"version\":1}]\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer fallbackServer.Close()
+	defer fallbackServer.CloseClientConnections()
+
+	// Connect to the primary server:
+	config := NewConfig(primaryServer.URL, "default/environment-id/my-secret-api-key").
+		WithLogLevel(logrus.FatalLevel).
+		WithFallbackServerAddresses(fallbackServer.URL).
+		WithFailoverAfterErrors(1).
+		WithWaitForData(true)
+	client, err := NewStreamingClient(config)
+	assert.NoError(t, err)
+	defer client.Close()
+	client.Start()
+	assert.Equal(t, primaryServer.URL, client.Status().ServerAddress)
+
+	// When the primary server drops us we should fail over to the fallback server:
+	close(dropPrimary)
+	assert.Eventually(t, func() bool { return client.Status().ServerAddress == fallbackServer.URL }, 5*time.Second, 10*time.Millisecond)
+	assert.Eventually(t, func() bool { _, err := client.GetFeature("booleanfeature"); return err == nil }, 5*time.Second, 10*time.Millisecond)
+	assert.True(t, client.Status().Connected)
+
+	// We should have asked the fallback server to resume from the last event we received:
+	assert.Equal(t, "event-1", <-lastEventIDs)
+
+	// The lagging server shouldn't have taken us back to an older version:
+	value, err := client.GetString("stringfeature")
+	assert.NoError(t, err)
+	assert.Equal(t, "new", value)
+
+	// If the primary server isn't working when we start then we go straight to the fallback:
+	client2, err := NewStreamingClient(config)
+	assert.NoError(t, err)
+	defer client2.Close()
+	assert.Equal(t, fallbackServer.URL, client2.Status().ServerAddress)
+	assert.Empty(t, <-lastEventIDs)
+
+	// Unless it isn't working either:
+	_, err = NewStreamingClient(NewConfig(primaryServer.URL, "default/environment-id/my-secret-api-key").WithLogLevel(logrus.FatalLevel).WithFallbackServerAddresses(primaryServer.URL))
+	assert.Error(t, err)
+}
+
+func TestStreamingClientClose(t *testing.T) {
+
+	// A server which never stops sending events (so the SSE library is usually in the middle of sending us one):
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, "event: features\ndata: [{\"key\":\"booleanfeature\",\"type\":\"BOOLEAN\",\"value\":true,\"version\":1}]\n\n")
+		for r.Context().Err() == nil {
+			fmt.Fprint(w, "event: ack\ndata: {}\n\n")
+			w.(http.Flusher).Flush()
+		}
+	}))
+	defer server.Close()
+	defer server.CloseClientConnections()
+
+	// Closing a busy client doesn't panic, and its API client is closed once it has gone quiet:
+	config := NewConfig(server.URL, "default/environment-id/my-secret-api-key").WithLogLevel(logrus.FatalLevel).WithWaitForData(true)
+	for i := 0; i < 5; i++ {
+		client, err := NewStreamingClient(config)
+		assert.NoError(t, err)
+		client.Start()
+		apiClient := client.currentAPIClient()
+		time.Sleep(10 * time.Millisecond)
+		client.Close()
+		assert.False(t, client.Status().Running)
+		assert.Eventually(t, func() bool {
+			select {
+			case _, ok := <-apiClient.Events:
+				return !ok
+			default:
+				return false
+			}
+		}, time.Second, 10*time.Millisecond)
+	}
+}
+
+func TestStreamingClientStaleness(t *testing.T) {
+
+	// A fake FeatureHub server which streams some features, then goes quiet (without closing the connection):
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, "event: features\ndata: [{\"key\":\"booleanfeature\",\"type\":\"BOOLEAN\",\"value\":true,\"version\":1}]\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+	defer server.CloseClientConnections()
+
+	// Connect (staleness is disabled by default):
+	config := NewConfig(server.URL, "default/environment-id/my-secret-api-key").WithLogLevel(logrus.FatalLevel).WithWaitForData(true)
+	client, err := NewStreamingClient(config)
+	assert.NoError(t, err)
+	client.Start()
+	time.Sleep(100 * time.Millisecond)
+	assert.False(t, client.Stale())
+	client.Close()
+
+	// Now connect with a short stale threshold, and listen for changes:
+	staleChanges := make(chan bool, 10)
+	client, err = NewStreamingClient(config.WithStaleAfter(250 * time.Millisecond).WithWaitForData(false))
+	assert.NoError(t, err)
+	defer client.Close()
+	requestsBefore := atomic.LoadInt32(&requests)
+	client.StaleListener(func(stale bool) { staleChanges <- stale })
+	client.Start()
+	assert.False(t, client.Stale())
+
+	// When the server goes quiet we become stale, and reconnect (which gets us some more events):
+	select {
+	case stale := <-staleChanges:
+		assert.True(t, stale)
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "We never became stale")
+	}
+	select {
+	case stale := <-staleChanges:
+		assert.False(t, stale)
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "We never stopped being stale")
+	}
+	assert.Greater(t, atomic.LoadInt32(&requests), requestsBefore)
+	value, err := client.WithContext(&models.Context{}).GetBoolean("booleanfeature")
+	assert.NoError(t, err)
+	assert.True(t, value)
+}
+
+func TestStreamingClientReadiness(t *testing.T) {
+
+	// Make a logger:
+	logger := logrus.New()
+	logger.SetOutput(new(bytes.Buffer))
+
+	// Use a mock apiClient (which hasn't received any data yet):
+	client := &StreamingClient{
+		apiClient: &eventsource.Stream{
+			Errors: make(chan error, 100),
+			Events: make(chan eventsource.Event, 100),
+		},
+		config:    &Config{},
+		features:  make(map[string]*models.FeatureState),
+		logger:    logging.NewLogrusLogger(logger),
+		notifiers: make(notifiers),
+	}
+	assert.Equal(t, models.ReadinessNotReady, client.Readiness())
+	assert.Equal(t, models.ReadinessNotReady, client.Status().Readiness)
+
+	// Register several readiness listeners (through the client and a ClientWithContext):
+	readinessListenerCalls := make(chan string, 10)
+	client.ReadinessListener(func() { readinessListenerCalls <- "first" })
+	client.WithContext(&models.Context{}).ReadinessListener(func() { readinessListenerCalls <- "second" })
+	client.FailedReadinessListener(func(error) { readinessListenerCalls <- "failed" })
+	client.Start()
+
+	// Waiting gives up when the context is done:
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, client.WaitUntilReady(ctx), context.DeadlineExceeded)
+	select {
+	case <-client.Ready():
+		assert.Fail(t, "The client shouldn't be ready yet")
+	default:
+	}
+
+	// Now send some data:
+	client.apiClient.Events <- &testEvent{
+		data:  `[{"key":"booleanfeature","type":"BOOLEAN","value":true,"version":1}]`,
+		event: "features",
+	}
+	assert.NoError(t, client.WithContext(&models.Context{}).WaitUntilReady(context.Background()))
+	<-client.Ready()
+	assert.Equal(t, models.ReadinessReady, client.Readiness())
+	assert.Equal(t, models.ReadinessReady, client.Status().Readiness)
+
+	// Both readiness listeners were called (but not the failed one), and listeners registered late are called straight away:
+	client.ReadinessListener(func() { readinessListenerCalls <- "late" })
+	var called []string
+	for len(called) < 3 {
+		select {
+		case call := <-readinessListenerCalls:
+			called = append(called, call)
+		case <-time.After(time.Second):
+			assert.FailNow(t, "Readiness listeners weren't called", called)
+		}
+	}
+	assert.ElementsMatch(t, []string{"first", "second", "late"}, called)
+
+	// Stale clients are still serving data, but we report it:
+	client.statusMutex.Lock()
+	client.stale = true
+	client.statusMutex.Unlock()
+	assert.Equal(t, models.ReadinessStale, client.Readiness())
+	client.setConnected(true)
+	assert.Equal(t, models.ReadinessReady, client.Readiness())
+
+	// Closing a client which is ready doesn't make it fail:
+	client.Close()
+	assert.Equal(t, models.ReadinessReady, client.Readiness())
+	assert.Empty(t, readinessListenerCalls)
+
+	// A failure from the server (before we have any data) fails the client:
+	var fatalErrors int32
+	failingClient := &StreamingClient{
+		apiClient: &eventsource.Stream{
+			Errors: make(chan error, 100),
+			Events: make(chan eventsource.Event, 100),
+		},
+		config:    &Config{WaitForData: true},
+		features:  make(map[string]*models.FeatureState),
+		logger:    logging.NewLogrusLogger(logger),
+		notifiers: make(notifiers),
+	}
+	failingClient.WithFatalErrorHandler(func(error, string, map[string]interface{}) { atomic.AddInt32(&fatalErrors, 1) })
+	failures := make(chan error, 10)
+	failingClient.FailedReadinessListener(func(err error) { failures <- err })
+	failingClient.apiClient.Events <- &testEvent{
+		data:  "not authorised",
+		event: "failure",
+	}
+	startedAt := time.Now()
+	failingClient.Start()
+	assert.Less(t, time.Since(startedAt), time.Second)
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&fatalErrors) == 1 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, models.ReadinessFailed, failingClient.Readiness())
+	err := failingClient.WaitUntilReady(context.Background())
+	assert.IsType(t, &errors.ErrNotReady{}, err)
+	select {
+	case failure := <-failures:
+		assert.Equal(t, err, failure)
+	case <-time.After(time.Second):
+		assert.Fail(t, "The failed readiness listener wasn't called")
+	}
+
+	// Failed listeners registered late are called straight away, and data arriving later doesn't change anything:
+	failingClient.FailedReadinessListener(func(err error) { failures <- err })
+	select {
+	case failure := <-failures:
+		assert.Equal(t, err, failure)
+	case <-time.After(time.Second):
+		assert.Fail(t, "The late failed readiness listener wasn't called")
+	}
+	failingClient.apiClient.Events <- &testEvent{
+		data:  `[{"key":"booleanfeature","type":"BOOLEAN","value":true,"version":1}]`,
+		event: "features",
+	}
+	assert.Eventually(t, func() bool { return failingClient.Status().HasData }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, models.ReadinessFailed, failingClient.Readiness())
+	failingClient.Close()
+
+	// Closing a client before it has any data also fails it:
+	closedClient := &StreamingClient{config: &Config{}, logger: logging.NewLogrusLogger(logger)}
+	closedClient.Close()
+	assert.Equal(t, models.ReadinessFailed, closedClient.Readiness())
+	assert.EqualError(t, closedClient.WaitUntilReady(context.Background()), "Client not ready: closed before receiving any data")
 }
@@ -0,0 +1,17 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
+)
+
+// NoopTracer implements the Tracer interface, but doesn't record anything:
+type NoopTracer struct{}
+
+// Connection does nothing:
+func (t NoopTracer) Connection(event models.ConnectionEvent, err error) {}
+
+// Evaluation does nothing:
+func (t NoopTracer) Evaluation(ctx context.Context, featureKey string, evaluatedFeature *models.EvaluatedFeature, outcome models.EvaluationOutcome) {
+}
@@ -0,0 +1,184 @@
+package opentelemetry
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	instrumentationName = "github.com/featurehub-io/featurehub-go-sdk"
+	providerName        = "FeatureHub"
+
+	// Span event and attribute names (from the OpenTelemetry feature_flag semantic conventions):
+	eventFeatureFlagEvaluation   = "feature_flag.evaluation"
+	attributeErrorType           = "error.type"
+	attributeFeatureFlagKey      = "feature_flag.key"
+	attributeFeatureFlagProvider = "feature_flag.provider.name"
+	attributeFeatureFlagReason   = "feature_flag.result.reason"
+	attributeFeatureFlagValue    = "feature_flag.result.value"
+	attributeFeatureFlagVariant  = "feature_flag.result.variant"
+	attributeFeatureFlagVersion  = "feature_flag.version"
+	attributeStrategyID          = "featurehub.strategy.id"
+
+	// Evaluation reasons:
+	reasonDefault        = "default"
+	reasonError          = "error"
+	reasonTargetingMatch = "targeting_match"
+
+	// Variant reported when no strategy matched:
+	variantDefault = "default"
+
+	// Connection span names:
+	spanConnect   = "featurehub.sse.connect"
+	spanReconnect = "featurehub.sse.reconnect"
+)
+
+// Tracer implements the Tracer interface with OpenTelemetry:
+// - every evaluation adds a "feature_flag.evaluation" event to the span in the evaluation's context (if it is recording)
+// - connecting (and re-connecting after an interruption) to the FeatureHub server is recorded as a span
+// Use one Tracer per client (it keeps track of that client's connection spans).
+type Tracer struct {
+	connectionSpan trace.Span
+	mutex          sync.Mutex
+	tracer         trace.Tracer
+}
+
+// NewTracer returns a Tracer which uses the given TracerProvider (or the global one if nil):
+func NewTracer(tracerProvider trace.TracerProvider) *Tracer {
+	if tracerProvider == nil {
+		tracerProvider = otel.GetTracerProvider()
+	}
+
+	return &Tracer{
+		tracer: tracerProvider.Tracer(instrumentationName),
+	}
+}
+
+// Connection records the lifecycle of the SSE connection as spans:
+func (t *Tracer) Connection(event models.ConnectionEvent, err error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	switch event {
+
+	// Start a new connection span:
+	case models.ConnectionEventConnecting:
+		t.endConnectionSpan(nil)
+		_, t.connectionSpan = t.tracer.Start(context.Background(), spanConnect)
+
+	// The connection was interrupted, so the next span covers the reconnection:
+	case models.ConnectionEventDisconnected:
+		if t.connectionSpan != nil {
+			t.connectionSpan.AddEvent("disconnected")
+			if err != nil {
+				t.connectionSpan.RecordError(err)
+			}
+			return
+		}
+		_, t.connectionSpan = t.tracer.Start(context.Background(), spanReconnect)
+		if err != nil {
+			t.connectionSpan.RecordError(err)
+		}
+
+	// The connection was established:
+	case models.ConnectionEventConnected:
+		t.endConnectionSpan(nil)
+
+	// We gave up:
+	case models.ConnectionEventClosed, models.ConnectionEventFailed:
+		if t.connectionSpan == nil {
+			_, t.connectionSpan = t.tracer.Start(context.Background(), spanConnect)
+		}
+		t.connectionSpan.AddEvent(string(event))
+		if err == nil {
+			err = fmt.Errorf("connection %s", event)
+		}
+		t.endConnectionSpan(err)
+	}
+}
+
+// Evaluation adds a feature_flag event to the span in the given context:
+func (t *Tracer) Evaluation(ctx context.Context, featureKey string, evaluatedFeature *models.EvaluatedFeature, outcome models.EvaluationOutcome) {
+
+	// There is nothing to do without a recording span:
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+
+	attributes := []attribute.KeyValue{
+		attribute.String(attributeFeatureFlagKey, featureKey),
+		attribute.String(attributeFeatureFlagProvider, providerName),
+	}
+
+	switch outcome {
+
+	// A strategy matched:
+	case models.EvaluationOutcomeStrategy:
+		attributes = append(attributes,
+			attribute.String(attributeFeatureFlagReason, reasonTargetingMatch),
+			attribute.String(attributeFeatureFlagVariant, evaluatedFeature.StrategyID),
+			attribute.String(attributeStrategyID, evaluatedFeature.StrategyID),
+		)
+
+	// The default value was used:
+	case models.EvaluationOutcomeDefault:
+		attributes = append(attributes,
+			attribute.String(attributeFeatureFlagReason, reasonDefault),
+			attribute.String(attributeFeatureFlagVariant, variantDefault),
+		)
+
+	// Everything else is an error:
+	default:
+		attributes = append(attributes,
+			attribute.String(attributeFeatureFlagReason, reasonError),
+			attribute.String(attributeErrorType, string(outcome)),
+		)
+	}
+
+	// Add the value and version (if we have them):
+	if evaluatedFeature != nil {
+		attributes = append(attributes,
+			valueAttribute(evaluatedFeature.Value),
+			attribute.Int64(attributeFeatureFlagVersion, evaluatedFeature.Version),
+		)
+	}
+
+	span.AddEvent(eventFeatureFlagEvaluation, trace.WithAttributes(attributes...))
+}
+
+// endConnectionSpan ends the current connection span (if there is one):
+func (t *Tracer) endConnectionSpan(err error) {
+	if t.connectionSpan == nil {
+		return
+	}
+	if err != nil {
+		t.connectionSpan.RecordError(err)
+		t.connectionSpan.SetStatus(codes.Error, err.Error())
+	} else {
+		t.connectionSpan.SetStatus(codes.Ok, "")
+	}
+	t.connectionSpan.End()
+	t.connectionSpan = nil
+}
+
+// valueAttribute makes an attribute for a feature value (using the most appropriate type):
+func valueAttribute(value interface{}) attribute.KeyValue {
+	switch typedValue := value.(type) {
+	case bool:
+		return attribute.Bool(attributeFeatureFlagValue, typedValue)
+	case float64:
+		return attribute.Float64(attributeFeatureFlagValue, typedValue)
+	case string:
+		return attribute.String(attributeFeatureFlagValue, typedValue)
+	default:
+		return attribute.String(attributeFeatureFlagValue, fmt.Sprintf("%v", typedValue))
+	}
+}
@@ -0,0 +1,123 @@
+package opentelemetry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/interfaces"
+	"github.com/featurehub-io/featurehub-go-sdk/pkg/models"
+	streamingclient "github.com/featurehub-io/featurehub-go-sdk/pkg/streaming-client"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracer(t *testing.T) {
+
+	// Make a tracer which records spans in memory:
+	spanRecorder := tracetest.NewSpanRecorder()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spanRecorder))
+	tracer := NewTracer(tracerProvider)
+	assert.Implements(t, new(interfaces.Tracer), tracer)
+
+	// Connect, get interrupted, then reconnect:
+	tracer.Connection(models.ConnectionEventConnecting, nil)
+	tracer.Connection(models.ConnectionEventConnected, nil)
+	tracer.Connection(models.ConnectionEventDisconnected, fmt.Errorf("connection reset by peer"))
+	tracer.Connection(models.ConnectionEventConnected, nil)
+
+	// Try again, but fail:
+	tracer.Connection(models.ConnectionEventConnecting, nil)
+	tracer.Connection(models.ConnectionEventFailed, fmt.Errorf("no such host"))
+
+	// Check the connection spans:
+	spans := spanRecorder.Ended()
+	assert.Len(t, spans, 3)
+	assert.Equal(t, spanConnect, spans[0].Name())
+	assert.Equal(t, codes.Ok, spans[0].Status().Code)
+	assert.Equal(t, spanReconnect, spans[1].Name())
+	assert.Equal(t, codes.Ok, spans[1].Status().Code)
+	assert.Equal(t, "exception", spans[1].Events()[0].Name)
+	assert.Equal(t, spanConnect, spans[2].Name())
+	assert.Equal(t, codes.Error, spans[2].Status().Code)
+	assert.Equal(t, "no such host", spans[2].Status().Description)
+
+	// Evaluations without a recording span are ignored:
+	tracer.Evaluation(context.Background(), "feature1", &models.EvaluatedFeature{Key: "feature1", Value: true}, models.EvaluationOutcomeDefault)
+
+	// Evaluate some features within a span:
+	ctx, span := tracerProvider.Tracer("test").Start(context.Background(), "request")
+	tracer.Evaluation(ctx, "feature1", &models.EvaluatedFeature{Key: "feature1", Value: true, Version: 3}, models.EvaluationOutcomeDefault)
+	tracer.Evaluation(ctx, "feature2", &models.EvaluatedFeature{Key: "feature2", Value: "orange", Version: 1, StrategyID: "s1"}, models.EvaluationOutcomeStrategy)
+	tracer.Evaluation(ctx, "feature3", nil, models.EvaluationOutcomeNotFound)
+	span.End()
+
+	// Check the span events:
+	spans = spanRecorder.Ended()
+	events := spans[len(spans)-1].Events()
+	assert.Len(t, events, 3)
+	assert.Equal(t, eventFeatureFlagEvaluation, events[0].Name)
+	assert.Contains(t, events[0].Attributes, attribute.String(attributeFeatureFlagKey, "feature1"))
+	assert.Contains(t, events[0].Attributes, attribute.String(attributeFeatureFlagProvider, providerName))
+	assert.Contains(t, events[0].Attributes, attribute.String(attributeFeatureFlagReason, reasonDefault))
+	assert.Contains(t, events[0].Attributes, attribute.String(attributeFeatureFlagVariant, variantDefault))
+	assert.Contains(t, events[0].Attributes, attribute.Bool(attributeFeatureFlagValue, true))
+	assert.Contains(t, events[0].Attributes, attribute.Int64(attributeFeatureFlagVersion, 3))
+	assert.Contains(t, events[1].Attributes, attribute.String(attributeFeatureFlagReason, reasonTargetingMatch))
+	assert.Contains(t, events[1].Attributes, attribute.String(attributeFeatureFlagVariant, "s1"))
+	assert.Contains(t, events[1].Attributes, attribute.String(attributeStrategyID, "s1"))
+	assert.Contains(t, events[1].Attributes, attribute.String(attributeFeatureFlagValue, "orange"))
+	assert.Contains(t, events[2].Attributes, attribute.String(attributeFeatureFlagReason, reasonError))
+	assert.Contains(t, events[2].Attributes, attribute.String(attributeErrorType, string(models.EvaluationOutcomeNotFound)))
+}
+
+func TestTracerWithStreamingClient(t *testing.T) {
+
+	// A fake FeatureHub server:
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, "event: features\ndata: [{\"key\":\"booleanfeature\",\"type\":\"BOOLEAN\",\"value\":true,\"version\":2}]\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+	defer server.CloseClientConnections()
+
+	// Make a tracer which records spans in memory:
+	spanRecorder := tracetest.NewSpanRecorder()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spanRecorder))
+
+	// Connect a client:
+	config := streamingclient.NewConfig(server.URL, "default/environment-id/my-secret-api-key").
+		WithLogLevel(logrus.ErrorLevel).
+		WithTracer(NewTracer(tracerProvider)).
+		WithWaitForData(true)
+	client, err := streamingclient.NewStreamingClient(config)
+	assert.NoError(t, err)
+	client.Start()
+
+	// The connection should have been traced:
+	assert.Len(t, spanRecorder.Ended(), 1)
+	assert.Equal(t, spanConnect, spanRecorder.Ended()[0].Name())
+
+	// Evaluate a feature within a span:
+	ctx, span := tracerProvider.Tracer("test").Start(context.Background(), "request")
+	value, err := client.WithContext(&models.Context{}).WithTraceContext(ctx).GetBoolean("booleanfeature")
+	assert.NoError(t, err)
+	assert.True(t, value)
+	span.End()
+
+	// Check that the span saw the flag value:
+	spans := spanRecorder.Ended()
+	events := spans[len(spans)-1].Events()
+	assert.Len(t, events, 1)
+	assert.Contains(t, events[0].Attributes, attribute.String(attributeFeatureFlagKey, "booleanfeature"))
+	assert.Contains(t, events[0].Attributes, attribute.Bool(attributeFeatureFlagValue, true))
+}